@@ -6,30 +6,180 @@ import (
 	"github.com/group14000/golang-todo/internal/middleware"
 )
 
-func SetupRoutes(r *gin.Engine, authHandler *handlers.AuthHandler, todoHandler *handlers.TodoHandler, aiHandler *handlers.AIHandler, authMW *middleware.AuthMiddleware) {
+func SetupRoutes(
+	r *gin.Engine,
+	authHandler *handlers.AuthHandler,
+	todoHandler *handlers.TodoHandler,
+	aiHandler *handlers.AIHandler,
+	adminHandler *handlers.AdminHandler,
+	preferencesHandler *handlers.PreferencesHandler,
+	digestHandler *handlers.DigestHandler,
+	activityHandler *handlers.ActivityHandler,
+	realtimeHandler *handlers.RealtimeHandler,
+	syncHandler *handlers.SyncHandler,
+	webhookHandler *handlers.WebhookHandler,
+	calendarHandler *handlers.CalendarHandler,
+	appPasswordHandler *handlers.AppPasswordHandler,
+	caldavHandler *handlers.CalDAVHandler,
+	shareHandler *handlers.ShareHandler,
+	workspaceHandler *handlers.WorkspaceHandler,
+	commentHandler *handlers.CommentHandler,
+	attachmentHandler *handlers.AttachmentHandler,
+	timeHandler *handlers.TimeHandler,
+	statsHandler *handlers.StatsHandler,
+	templateHandler *handlers.TodoTemplateHandler,
+	filterHandler *handlers.SavedFilterHandler,
+	authMW *middleware.AuthMiddleware,
+	adminMW *middleware.AdminMiddleware,
+	appPasswordMW *middleware.AppPasswordMiddleware,
+	workspaceMW *middleware.WorkspaceMiddleware,
+) {
 	// Public routes
 	r.POST("/signup", authHandler.SignUp)
 	r.POST("/verify-otp", authHandler.VerifyOTP)
 	r.POST("/login", authHandler.Login)
 	r.POST("/forgot-password", authHandler.ForgotPassword)
 	r.POST("/reset-password", authHandler.ResetPassword)
+	r.GET("/unsubscribe", digestHandler.UnsubscribePage)
+	r.POST("/unsubscribe", digestHandler.Unsubscribe)
+	// Authenticated by the secret token in the URL
+	r.GET("/calendar/:token", calendarHandler.Feed)
 
-	// Protected routes
+	// Protected routes (X-Workspace-ID scopes todo data to a workspace)
 	protected := r.Group("")
-	protected.Use(authMW.Handler())
+	protected.Use(authMW.Handler(), workspaceMW.Handler())
 	{
 		protected.GET("/profile", authHandler.GetProfile)
+		protected.GET("/profile/preferences", preferencesHandler.Get)
+		protected.PATCH("/profile/preferences", preferencesHandler.Update)
+		protected.GET("/profile/calendar", calendarHandler.Status)
+		protected.POST("/profile/calendar", calendarHandler.Regenerate)
+		protected.DELETE("/profile/calendar", calendarHandler.Revoke)
+		protected.POST("/profile/app-passwords", appPasswordHandler.Create)
+		protected.GET("/profile/app-passwords", appPasswordHandler.List)
+		protected.DELETE("/profile/app-passwords/:id", appPasswordHandler.Delete)
 		protected.POST("/ai/chat", aiHandler.Chat)
+		protected.GET("/activity", activityHandler.Feed)
+		protected.GET("/sync", syncHandler.Pull)
+		protected.POST("/sync", syncHandler.Push)
+		protected.GET("/shares", shareHandler.Incoming)
+		protected.POST("/shares/:id/accept", shareHandler.Accept)
+		protected.DELETE("/shares/:id", shareHandler.Revoke)
+		protected.POST("/projects/:id/shares", shareHandler.ShareProject)
+		protected.GET("/projects/:id/shares", shareHandler.ListProjectShares)
+		protected.GET("/projects/:id/workflow", todoHandler.Workflow)
+		protected.PUT("/projects/:id/workflow", todoHandler.SetWorkflow)
+		protected.DELETE("/projects/:id/workflow", todoHandler.ResetWorkflow)
+		protected.GET("/timer", timeHandler.Running)
+		protected.PATCH("/time-entries/:id", timeHandler.UpdateEntry)
+		protected.DELETE("/time-entries/:id", timeHandler.DeleteEntry)
+		protected.GET("/time-report", timeHandler.Report)
+		protected.GET("/stats", statsHandler.Stats)
+		protected.POST("/templates", templateHandler.Create)
+		protected.GET("/templates", templateHandler.List)
+		protected.GET("/templates/:id", templateHandler.Get)
+		protected.PUT("/templates/:id", templateHandler.Update)
+		protected.DELETE("/templates/:id", templateHandler.Delete)
+		protected.POST("/templates/:id/instantiate", templateHandler.Instantiate)
+		protected.POST("/filters", filterHandler.Create)
+		protected.GET("/filters", filterHandler.List)
+		protected.GET("/filters/:id", filterHandler.Get)
+		protected.PATCH("/filters/:id", filterHandler.Update)
+		protected.DELETE("/filters/:id", filterHandler.Delete)
+		protected.GET("/filters/:id/todos", filterHandler.Todos)
 	}
 
-	// Todo routes (protected)
+	// Real-time streams (protected, workspace-scoped; token may also come from the query string)
+	stream := r.Group("")
+	stream.Use(authMW.StreamHandler(), workspaceMW.StreamHandler())
+	{
+		stream.GET("/events", realtimeHandler.Events)
+		stream.GET("/ws", realtimeHandler.WebSocket)
+	}
+
+	// Todo routes (protected, workspace-scoped)
 	api := r.Group("/todos")
-	api.Use(authMW.Handler())
+	api.Use(authMW.Handler(), workspaceMW.Handler())
 	{
 		api.POST("", todoHandler.Create)
 		api.GET("", todoHandler.List)
+		api.GET("export", todoHandler.Export)
+		api.GET("assigned", todoHandler.Assigned)
+		api.GET("board", todoHandler.Board)
+		api.GET("shared", shareHandler.SharedTodos)
+		api.POST("import", todoHandler.Import)
+		api.POST("quick", todoHandler.QuickAdd)
+		api.GET("archive", todoHandler.Archived)
 		api.GET(":id", todoHandler.Get)
 		api.PATCH(":id", todoHandler.Update)
 		api.DELETE(":id", todoHandler.Delete)
+		api.POST(":id/restore", todoHandler.Restore)
+		api.POST(":id/archive", todoHandler.Archive)
+		api.POST(":id/unarchive", todoHandler.Unarchive)
+		api.GET(":id/history", activityHandler.History)
+		api.POST(":id/dependencies", todoHandler.AddDependency)
+		api.DELETE(":id/dependencies/:blockerId", todoHandler.RemoveDependency)
+		api.GET(":id/graph", todoHandler.Graph)
+		api.POST(":id/shares", shareHandler.ShareTodo)
+		api.GET(":id/shares", shareHandler.ListTodoShares)
+		api.GET(":id/comments", commentHandler.List)
+		api.POST(":id/comments", commentHandler.Create)
+		api.PATCH(":id/comments/:commentId", commentHandler.Update)
+		api.DELETE(":id/comments/:commentId", commentHandler.Delete)
+		api.POST(":id/attachments", attachmentHandler.Upload)
+		api.GET(":id/attachments", attachmentHandler.List)
+		api.GET(":id/attachments/:attachmentId", attachmentHandler.Download)
+		api.DELETE(":id/attachments/:attachmentId", attachmentHandler.Delete)
+		api.POST(":id/timer/start", timeHandler.StartTimer)
+		api.POST(":id/timer/stop", timeHandler.StopTimer)
+		api.GET(":id/time-entries", timeHandler.ListEntries)
+		api.POST(":id/time-entries", timeHandler.CreateEntry)
+	}
+
+	// Workspace management (protected; not scoped by X-Workspace-ID)
+	workspaces := r.Group("/workspaces")
+	workspaces.Use(authMW.Handler())
+	{
+		workspaces.POST("", workspaceHandler.Create)
+		workspaces.GET("", workspaceHandler.List)
+		workspaces.GET("invitations", workspaceHandler.Invitations)
+		workspaces.GET(":id", workspaceHandler.Get)
+		workspaces.PATCH(":id", workspaceHandler.Update)
+		workspaces.POST(":id/invitations", workspaceHandler.Invite)
+		workspaces.DELETE(":id/invitations/:invitationId", workspaceHandler.RevokeInvitation)
+		workspaces.POST(":id/invitations/:invitationId/accept", workspaceHandler.Accept)
+		workspaces.PATCH(":id/members/:userId", workspaceHandler.SetRole)
+		workspaces.DELETE(":id/members/:userId", workspaceHandler.RemoveMember)
+	}
+
+	// Webhook routes (protected, workspace-scoped)
+	webhooks := r.Group("/webhooks")
+	webhooks.Use(authMW.Handler(), workspaceMW.Handler())
+	{
+		webhooks.POST("", webhookHandler.Create)
+		webhooks.GET("", webhookHandler.List)
+		webhooks.GET(":id", webhookHandler.Get)
+		webhooks.PATCH(":id", webhookHandler.Update)
+		webhooks.DELETE(":id", webhookHandler.Delete)
+		webhooks.GET(":id/deliveries", webhookHandler.Deliveries)
+		webhooks.POST(":id/test", webhookHandler.Test)
+	}
+
+	// CalDAV (protected by app passwords; OPTIONS is open for discovery)
+	r.GET("/.well-known/caldav", caldavHandler.WellKnown)
+	r.Handle("PROPFIND", "/.well-known/caldav", caldavHandler.WellKnown)
+	r.OPTIONS("/dav/*path", caldavHandler.Options)
+	dav := r.Group("/dav")
+	dav.Use(appPasswordMW.Handler())
+	for _, method := range []string{"PROPFIND", "REPORT", "GET", "HEAD", "PUT", "DELETE"} {
+		dav.Handle(method, "/*path", caldavHandler.Serve)
+	}
+
+	// Admin routes (protected + admin flag)
+	admin := r.Group("/admin")
+	admin.Use(authMW.Handler(), adminMW.Handler())
+	{
+		admin.GET("/email-templates", adminHandler.ListEmailTemplates)
+		admin.GET("/email-templates/:name/preview", adminHandler.PreviewEmailTemplate)
 	}
 }
@@ -12,6 +12,7 @@ package main
 import (
 	"context"
 	"log"
+	_ "time/tzdata" // embed IANA zones so user timezones resolve in minimal containers
 
 	"github.com/gin-gonic/gin"
 	"github.com/group14000/golang-todo/api"
@@ -20,6 +21,8 @@ import (
 	"github.com/group14000/golang-todo/internal/handlers"
 	"github.com/group14000/golang-todo/internal/middleware"
 	"github.com/group14000/golang-todo/internal/services"
+	"github.com/group14000/golang-todo/internal/storage"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	// Swagger docs & handlers
 	_ "github.com/group14000/golang-todo/docs"
@@ -46,19 +49,93 @@ func main() {
 	authService := services.NewAuthService(userRepo, otpRepo, emailService, cfg.JWTSecret)
 	authHandler := handlers.NewAuthHandler(authService)
 
+	// Preferences dependencies
+	preferencesService := services.NewPreferencesService(userRepo)
+	preferencesHandler := handlers.NewPreferencesHandler(preferencesService)
+
 	// Todo dependencies
 	todoRepo := database.NewTodoRepository(client)
-	todoService := services.NewTodoService(todoRepo)
+	todoEventRepo := database.NewTodoEventRepository(client)
+	shareRepo := database.NewShareRepository(client)
+	realtimeService := services.NewRealtimeService(todoEventRepo, shareRepo, cfg.RealtimeMode)
+	webhookRepo := database.NewWebhookRepository(client)
+	webhookDeliveryRepo := database.NewWebhookDeliveryRepository(client)
+	webhookService := services.NewWebhookService(webhookRepo, webhookDeliveryRepo, cfg.WebhookAllowPrivate)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	workspaceRepo := database.NewWorkspaceRepository(client)
+	commentRepo := database.NewCommentRepository(client)
+	workflowRepo := database.NewWorkflowRepository(client)
+	workspaceService := services.NewWorkspaceService(workspaceRepo, userRepo, emailService, cfg.AppBaseURL)
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService)
+	todoService := services.NewTodoService(todoRepo, todoEventRepo, preferencesService, shareRepo, workspaceRepo, workflowRepo, realtimeService, webhookService)
 	todoHandler := handlers.NewTodoHandler(todoService)
+	sharingService := services.NewSharingService(shareRepo, todoService, userRepo, emailService, cfg.AppBaseURL)
+	shareHandler := handlers.NewShareHandler(sharingService)
+	commentService := services.NewCommentService(commentRepo, todoService, userRepo, emailService, cfg.AppBaseURL)
+	commentHandler := handlers.NewCommentHandler(commentService)
+	blobStore, err := newBlobStore(cfg, client)
+	if err != nil {
+		log.Fatal(err)
+	}
+	attachmentRepo := database.NewAttachmentRepository(client)
+	attachmentService := services.NewAttachmentService(attachmentRepo, todoService, blobStore, cfg.AttachmentStorage, cfg.AttachmentMaxBytes, cfg.AttachmentTypes)
+	trashPurgeService := services.NewTrashPurgeService(todoRepo, cfg.TrashRetentionDays, attachmentService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+	timeEntryRepo := database.NewTimeEntryRepository(client)
+	if err := timeEntryRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("time entries: create indexes: %v", err)
+	}
+	timeService := services.NewTimeTrackingService(timeEntryRepo, todoService, preferencesService)
+	timeHandler := handlers.NewTimeHandler(timeService)
+	statsService := services.NewStatsService(todoRepo, preferencesService)
+	statsHandler := handlers.NewStatsHandler(statsService)
+	templateRepo := database.NewTodoTemplateRepository(client)
+	templateService := services.NewTodoTemplateService(templateRepo, todoService, preferencesService)
+	templateHandler := handlers.NewTodoTemplateHandler(templateService)
+	filterRepo := database.NewSavedFilterRepository(client)
+	filterService := services.NewSavedFilterService(filterRepo, todoService)
+	filterHandler := handlers.NewSavedFilterHandler(filterService)
+	activityService := services.NewActivityService(todoEventRepo, todoService)
+	activityHandler := handlers.NewActivityHandler(activityService)
+	realtimeHandler := handlers.NewRealtimeHandler(realtimeService)
+	calendarService := services.NewCalendarService(userRepo, todoRepo, cfg.AppBaseURL)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+	appPasswordRepo := database.NewAppPasswordRepository(client)
+	appPasswordService := services.NewAppPasswordService(appPasswordRepo, userRepo)
+	appPasswordHandler := handlers.NewAppPasswordHandler(appPasswordService)
+	caldavService := services.NewCalDAVService(todoService, todoRepo, todoEventRepo)
+	caldavHandler := handlers.NewCalDAVHandler(caldavService)
+	syncService := services.NewSyncService(todoRepo, todoEventRepo, todoService)
+	syncHandler := handlers.NewSyncHandler(syncService)
+
+	// Background workers (stop when main returns)
+	digestService := services.NewDigestService(userRepo, todoRepo, emailService, cfg.JWTSecret, cfg.AppBaseURL, cfg.DigestHour)
+	digestHandler := handlers.NewDigestHandler(digestService)
+	autoArchiveService := services.NewAutoArchiveService(userRepo, todoService)
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go digestService.Start(bgCtx)
+	go autoArchiveService.Start(bgCtx)
+	go trashPurgeService.Start(bgCtx)
+	go webhookService.Start(bgCtx)
+	if err := realtimeService.Start(bgCtx); err != nil {
+		log.Fatal(err)
+	}
 
 	authMW := middleware.NewAuthMiddleware(cfg.JWTSecret)
+	adminMW := middleware.NewAdminMiddleware(userRepo)
+	appPasswordMW := middleware.NewAppPasswordMiddleware(appPasswordService, "golang-todo")
+	workspaceMW := middleware.NewWorkspaceMiddleware(workspaceService)
+	adminHandler := handlers.NewAdminHandler(emailService)
 
 	// AI dependencies
 	aiService := services.NewAIService(cfg.AIAPIKey)
 	aiHandler := handlers.NewAIHandler(aiService)
 
 	r := gin.Default()
-	api.SetupRoutes(r, authHandler, todoHandler, aiHandler, authMW)
+	r.Use(middleware.RequestID(), middleware.ErrorHandler())
+	r.NoRoute(middleware.NoRoute())
+	api.SetupRoutes(r, authHandler, todoHandler, aiHandler, adminHandler, preferencesHandler, digestHandler, activityHandler, realtimeHandler, syncHandler, webhookHandler, calendarHandler, appPasswordHandler, caldavHandler, shareHandler, workspaceHandler, commentHandler, attachmentHandler, timeHandler, statsHandler, templateHandler, filterHandler, authMW, adminMW, appPasswordMW, workspaceMW)
 
 	// Swagger endpoint
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -66,3 +143,22 @@ func main() {
 	log.Println("Server starting on :8080 (swagger at /swagger/index.html)")
 	r.Run(":8080")
 }
+
+// newBlobStore opens the attachment storage backend selected by ATTACHMENT_STORAGE.
+func newBlobStore(cfg *config.Config, client *mongo.Client) (storage.Store, error) {
+	switch cfg.AttachmentStorage {
+	case storage.BackendGridFS:
+		return storage.NewGridFSStore(client, "attachments")
+	case storage.BackendS3:
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+	default:
+		return storage.NewLocalStore(cfg.AttachmentDir)
+	}
+}
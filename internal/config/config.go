@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -17,8 +18,44 @@ type Config struct {
 	EmailPassword string
 	EmailUseTLS   bool
 	AIAPIKey      string
+
+	// Email templating & branding
+	EmailTemplateDir string
+	DefaultLocale    string
+	BrandName        string
+	BrandURL         string
+	BrandLogoURL     string
+
+	// Public base URL used for links in emails (e.g. unsubscribe)
+	AppBaseURL string
+	// Local hour (0-23, user's timezone) at which digests are sent
+	DigestHour int
+	// Days deleted todos stay restorable before they and their attachments are purged; 0 keeps them
+	TrashRetentionDays int
+
+	// Source of real-time todo events: auto, changestream or memory
+	RealtimeMode string
+	// Let webhooks target loopback/private addresses (off by default to prevent SSRF)
+	WebhookAllowPrivate bool
+
+	// Attachment blob storage: local, gridfs or s3
+	AttachmentStorage  string
+	AttachmentDir      string
+	AttachmentMaxBytes int64
+	// Allowed sniffed content types; "image/*" matches a whole family
+	AttachmentTypes []string
+	S3Endpoint      string
+	S3Region        string
+	S3Bucket        string
+	S3AccessKey     string
+	S3SecretKey     string
+	S3PathStyle     bool
 }
 
+// defaultAttachmentTypes are content types http.DetectContentType can recognise
+// and that are safe to keep; HTML and other active content is left out.
+const defaultAttachmentTypes = "image/*,application/pdf,text/plain,application/zip,application/x-gzip,audio/*,video/*"
+
 func LoadConfig() *Config {
 	// Load .env file
 	err := godotenv.Load()
@@ -67,6 +104,83 @@ func LoadConfig() *Config {
 		log.Println("Warning: AI_API_KEY not set; AI endpoints will be disabled")
 	}
 
+	defaultLocale := os.Getenv("DEFAULT_LOCALE")
+	if defaultLocale == "" {
+		defaultLocale = "en"
+	}
+
+	brandName := os.Getenv("BRAND_NAME")
+	if brandName == "" {
+		brandName = "Golang Todo"
+	}
+
+	appBaseURL := os.Getenv("APP_BASE_URL")
+	if appBaseURL == "" {
+		appBaseURL = "http://localhost:8080"
+	}
+
+	digestHour := 7
+	if v := os.Getenv("DIGEST_HOUR"); v != "" {
+		h, err := strconv.Atoi(v)
+		if err != nil || h < 0 || h > 23 {
+			log.Fatal("DIGEST_HOUR must be an integer between 0 and 23")
+		}
+		digestHour = h
+	}
+
+	trashRetentionDays := 30
+	if v := os.Getenv("TRASH_RETENTION_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatal("TRASH_RETENTION_DAYS must be a non-negative integer")
+		}
+		trashRetentionDays = n
+	}
+
+	realtimeMode := strings.ToLower(os.Getenv("REALTIME_MODE"))
+	switch realtimeMode {
+	case "":
+		realtimeMode = "auto"
+	case "auto", "changestream", "memory":
+	default:
+		log.Fatal("REALTIME_MODE must be auto, changestream or memory")
+	}
+
+	attachmentStorage := strings.ToLower(os.Getenv("ATTACHMENT_STORAGE"))
+	switch attachmentStorage {
+	case "":
+		attachmentStorage = "local"
+	case "local", "gridfs", "s3":
+	default:
+		log.Fatal("ATTACHMENT_STORAGE must be local, gridfs or s3")
+	}
+	attachmentDir := os.Getenv("ATTACHMENT_DIR")
+	if attachmentDir == "" {
+		attachmentDir = "./data/attachments"
+	}
+	attachmentMaxMB := 25
+	if v := os.Getenv("ATTACHMENT_MAX_MB"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			log.Fatal("ATTACHMENT_MAX_MB must be a positive integer")
+		}
+		attachmentMaxMB = n
+	}
+	attachmentTypes := os.Getenv("ATTACHMENT_TYPES")
+	if attachmentTypes == "" {
+		attachmentTypes = defaultAttachmentTypes
+	}
+	var allowedTypes []string
+	for _, t := range strings.Split(attachmentTypes, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			allowedTypes = append(allowedTypes, t)
+		}
+	}
+	if attachmentStorage == "s3" && (os.Getenv("S3_ENDPOINT") == "" || os.Getenv("S3_BUCKET") == "" ||
+		os.Getenv("S3_ACCESS_KEY") == "" || os.Getenv("S3_SECRET_KEY") == "") {
+		log.Fatal("ATTACHMENT_STORAGE=s3 needs S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY")
+	}
+
 	return &Config{
 		MongoDBURL:    mongoURL,
 		JWTSecret:     jwtSecret,
@@ -76,5 +190,29 @@ func LoadConfig() *Config {
 		EmailPassword: emailPassword,
 		EmailUseTLS:   emailUseTLS,
 		AIAPIKey:      aiKey,
+
+		EmailTemplateDir: os.Getenv("EMAIL_TEMPLATE_DIR"),
+		DefaultLocale:    defaultLocale,
+		BrandName:        brandName,
+		BrandURL:         os.Getenv("BRAND_URL"),
+		BrandLogoURL:     os.Getenv("BRAND_LOGO_URL"),
+
+		AppBaseURL:         strings.TrimRight(appBaseURL, "/"),
+		DigestHour:         digestHour,
+		TrashRetentionDays: trashRetentionDays,
+
+		RealtimeMode:        realtimeMode,
+		WebhookAllowPrivate: os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true",
+
+		AttachmentStorage:  attachmentStorage,
+		AttachmentDir:      attachmentDir,
+		AttachmentMaxBytes: int64(attachmentMaxMB) << 20,
+		AttachmentTypes:    allowedTypes,
+		S3Endpoint:         os.Getenv("S3_ENDPOINT"),
+		S3Region:           os.Getenv("S3_REGION"),
+		S3Bucket:           os.Getenv("S3_BUCKET"),
+		S3AccessKey:        os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:        os.Getenv("S3_SECRET_KEY"),
+		S3PathStyle:        os.Getenv("S3_PATH_STYLE") != "false",
 	}
 }
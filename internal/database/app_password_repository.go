@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AppPasswordRepository interface {
+	Create(ctx context.Context, p *models.AppPassword) error
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.AppPassword, error)
+	Delete(ctx context.Context, userID, id primitive.ObjectID) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.AppPassword, error)
+	Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error
+}
+
+type appPasswordRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAppPasswordRepository(client *mongo.Client) AppPasswordRepository {
+	return &appPasswordRepository{collection: client.Database("golang-todo").Collection("app_passwords")}
+}
+
+func (r *appPasswordRepository) Create(ctx context.Context, p *models.AppPassword) error {
+	_, err := r.collection.InsertOne(ctx, p)
+	return translate(err, "app password")
+}
+
+func (r *appPasswordRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.AppPassword, error) {
+	cur, err := r.collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	passwords := []*models.AppPassword{}
+	if err := cur.All(ctx, &passwords); err != nil {
+		return nil, err
+	}
+	return passwords, nil
+}
+
+func (r *appPasswordRepository) Delete(ctx context.Context, userID, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return translate(mongo.ErrNoDocuments, "app password")
+	}
+	return nil
+}
+
+func (r *appPasswordRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.AppPassword, error) {
+	var p models.AppPassword
+	if err := r.collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&p); err != nil {
+		return nil, translate(err, "app password")
+	}
+	return &p, nil
+}
+
+// Touch records when the token was last used.
+func (r *appPasswordRepository) Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": at}})
+	return err
+}
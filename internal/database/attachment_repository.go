@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AttachmentRepository stores attachment metadata. Access to the todo is
+// checked by the caller; queries are confined to the ctx tenant, except
+// ListAllByTodo which the trash purge uses after a todo is purged.
+type AttachmentRepository interface {
+	Create(ctx context.Context, att *models.Attachment) error
+	Get(ctx context.Context, todoID, attachmentID primitive.ObjectID) (*models.Attachment, error)
+	// ListByTodo returns a todo's attachments, oldest first.
+	ListByTodo(ctx context.Context, todoID primitive.ObjectID) ([]*models.Attachment, error)
+	// ListAllByTodo is ListByTodo without the tenant filter.
+	ListAllByTodo(ctx context.Context, todoID primitive.ObjectID) ([]*models.Attachment, error)
+	Delete(ctx context.Context, attachmentID primitive.ObjectID) error
+}
+
+type attachmentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAttachmentRepository(client *mongo.Client) AttachmentRepository {
+	return &attachmentRepository{collection: client.Database("golang-todo").Collection("attachments")}
+}
+
+func (r *attachmentRepository) Create(ctx context.Context, att *models.Attachment) error {
+	att.WorkspaceID = stampWorkspace(ctx)
+	_, err := r.collection.InsertOne(ctx, att)
+	return translate(err, "attachment")
+}
+
+func (r *attachmentRepository) Get(ctx context.Context, todoID, attachmentID primitive.ObjectID) (*models.Attachment, error) {
+	var att models.Attachment
+	filter := merge(workspaceFilter(ctx), bson.M{"_id": attachmentID, "todo_id": todoID})
+	if err := r.collection.FindOne(ctx, filter).Decode(&att); err != nil {
+		return nil, translate(err, "attachment")
+	}
+	return &att, nil
+}
+
+func (r *attachmentRepository) ListByTodo(ctx context.Context, todoID primitive.ObjectID) ([]*models.Attachment, error) {
+	return r.find(ctx, merge(workspaceFilter(ctx), bson.M{"todo_id": todoID}))
+}
+
+func (r *attachmentRepository) ListAllByTodo(ctx context.Context, todoID primitive.ObjectID) ([]*models.Attachment, error) {
+	return r.find(ctx, bson.M{"todo_id": todoID})
+}
+
+func (r *attachmentRepository) find(ctx context.Context, filter bson.M) ([]*models.Attachment, error) {
+	cur, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	attachments := []*models.Attachment{}
+	if err := cur.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *attachmentRepository) Delete(ctx context.Context, attachmentID primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": attachmentID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return translate(mongo.ErrNoDocuments, "attachment")
+	}
+	return nil
+}
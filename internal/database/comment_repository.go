@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CommentRepository stores todo comments. Access to the todo is checked by the
+// caller; queries are still confined to the ctx tenant (see workspaceFilter).
+type CommentRepository interface {
+	Create(ctx context.Context, comment *models.Comment) error
+	Get(ctx context.Context, todoID, commentID primitive.ObjectID) (*models.Comment, error)
+	// ListByTodo returns a todo's comments, deleted ones included, oldest first.
+	ListByTodo(ctx context.Context, todoID primitive.ObjectID) ([]*models.Comment, error)
+	Update(ctx context.Context, todoID, commentID primitive.ObjectID, set bson.M) (*models.Comment, error)
+	// CountLiveReplies counts the replies to parentID that are not deleted.
+	CountLiveReplies(ctx context.Context, parentID primitive.ObjectID) (int64, error)
+}
+
+type commentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCommentRepository(client *mongo.Client) CommentRepository {
+	return &commentRepository{collection: client.Database("golang-todo").Collection("comments")}
+}
+
+func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	comment.WorkspaceID = stampWorkspace(ctx)
+	_, err := r.collection.InsertOne(ctx, comment)
+	return translate(err, "comment")
+}
+
+func (r *commentRepository) Get(ctx context.Context, todoID, commentID primitive.ObjectID) (*models.Comment, error) {
+	var comment models.Comment
+	filter := merge(workspaceFilter(ctx), bson.M{"_id": commentID, "todo_id": todoID})
+	if err := r.collection.FindOne(ctx, filter).Decode(&comment); err != nil {
+		return nil, translate(err, "comment")
+	}
+	return &comment, nil
+}
+
+func (r *commentRepository) ListByTodo(ctx context.Context, todoID primitive.ObjectID) ([]*models.Comment, error) {
+	filter := merge(workspaceFilter(ctx), bson.M{"todo_id": todoID})
+	cur, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	comments := []*models.Comment{}
+	if err := cur.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *commentRepository) Update(ctx context.Context, todoID, commentID primitive.ObjectID, set bson.M) (*models.Comment, error) {
+	filter := merge(workspaceFilter(ctx), bson.M{"_id": commentID, "todo_id": todoID, "deleted_at": nil})
+	var comment models.Comment
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": set}, opts).Decode(&comment); err != nil {
+		return nil, translate(err, "comment")
+	}
+	return &comment, nil
+}
+
+func (r *commentRepository) CountLiveReplies(ctx context.Context, parentID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, merge(workspaceFilter(ctx), bson.M{"parent_id": parentID, "deleted_at": nil}))
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/group14000/golang-todo/internal/domain"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// translate maps driver errors onto domain error kinds; other errors pass through unchanged.
+// what names the entity for the client-facing message and code (e.g. "todo" → "todo_not_found").
+func translate(err error, what string) error {
+	code := strings.ToLower(strings.ReplaceAll(what, " ", "_"))
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return domain.NotFound("%s not found", what).WithCode(code + "_not_found")
+	case mongo.IsDuplicateKeyError(err):
+		return domain.Wrap(domain.ErrConflict, err, what+" already exists").WithCode(code + "_exists")
+	}
+	return err
+}
+
+// transactionsUnsupported reports whether err came from a server that cannot
+// run transactions, i.e. a standalone mongod rather than a replica set.
+func transactionsUnsupported(err error) bool {
+	var serverErr mongo.ServerError
+	// 20 is IllegalOperation: "Transaction numbers are only allowed on a replica set member or mongos".
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(20)
+}
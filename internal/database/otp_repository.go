@@ -27,7 +27,7 @@ func NewOTPRepository(client *mongo.Client) OTPRepository {
 
 func (r *otpRepository) Create(ctx context.Context, otp *models.OTP) error {
 	_, err := r.collection.InsertOne(ctx, otp)
-	return err
+	return translate(err, "OTP")
 }
 
 func (r *otpRepository) FindValidOTP(ctx context.Context, email, code string, otpType models.OTPType) (*models.OTP, error) {
@@ -41,7 +41,7 @@ func (r *otpRepository) FindValidOTP(ctx context.Context, email, code string, ot
 	}
 	err := r.collection.FindOne(ctx, filter).Decode(&otp)
 	if err != nil {
-		return nil, err
+		return nil, translate(err, "OTP")
 	}
 	return &otp, nil
 }
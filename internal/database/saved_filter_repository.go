@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SavedFilterRepository stores saved todo filters in the ctx tenant: the user's
+// own filters, or the workspace's when ctx carries one.
+type SavedFilterRepository interface {
+	Create(ctx context.Context, f *models.SavedFilter) error
+	// List returns the tenant's filters by name.
+	List(ctx context.Context, userID primitive.ObjectID) ([]*models.SavedFilter, error)
+	Get(ctx context.Context, userID, filterID primitive.ObjectID) (*models.SavedFilter, error)
+	// Update applies a $set and returns the updated filter.
+	Update(ctx context.Context, userID, filterID primitive.ObjectID, update bson.M) (*models.SavedFilter, error)
+	Delete(ctx context.Context, userID, filterID primitive.ObjectID) error
+}
+
+type savedFilterRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSavedFilterRepository(client *mongo.Client) SavedFilterRepository {
+	return &savedFilterRepository{collection: client.Database("golang-todo").Collection("saved_filters")}
+}
+
+func (r *savedFilterRepository) Create(ctx context.Context, f *models.SavedFilter) error {
+	f.WorkspaceID = stampWorkspace(ctx)
+	_, err := r.collection.InsertOne(ctx, f)
+	return translate(err, "filter")
+}
+
+func (r *savedFilterRepository) List(ctx context.Context, userID primitive.ObjectID) ([]*models.SavedFilter, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.collection.Find(ctx, tenantFilter(ctx, userID), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	filters := []*models.SavedFilter{}
+	if err := cur.All(ctx, &filters); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+func (r *savedFilterRepository) Get(ctx context.Context, userID, filterID primitive.ObjectID) (*models.SavedFilter, error) {
+	var f models.SavedFilter
+	if err := r.collection.FindOne(ctx, merge(tenantFilter(ctx, userID), bson.M{"_id": filterID})).Decode(&f); err != nil {
+		return nil, translate(err, "filter")
+	}
+	return &f, nil
+}
+
+func (r *savedFilterRepository) Update(ctx context.Context, userID, filterID primitive.ObjectID, update bson.M) (*models.SavedFilter, error) {
+	var f models.SavedFilter
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, merge(tenantFilter(ctx, userID), bson.M{"_id": filterID}), bson.M{"$set": update}, opts).Decode(&f)
+	if err != nil {
+		return nil, translate(err, "filter")
+	}
+	return &f, nil
+}
+
+func (r *savedFilterRepository) Delete(ctx context.Context, userID, filterID primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, merge(tenantFilter(ctx, userID), bson.M{"_id": filterID}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return translate(mongo.ErrNoDocuments, "filter")
+	}
+	return nil
+}
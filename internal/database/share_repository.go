@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ShareRepository interface {
+	Create(ctx context.Context, share *models.Share) error
+	GetByID(ctx context.Context, shareID primitive.ObjectID) (*models.Share, error)
+	// Find returns the share of a resource with the given email, if any.
+	Find(ctx context.Context, ownerID primitive.ObjectID, resource models.ShareResource, resourceID primitive.ObjectID, email string) (*models.Share, error)
+	ListByResource(ctx context.Context, ownerID primitive.ObjectID, resource models.ShareResource, resourceID primitive.ObjectID) ([]*models.Share, error)
+	// ListByEmail returns the invitations and shares addressed to email.
+	ListByEmail(ctx context.Context, email string) ([]*models.Share, error)
+	// ListAccepted returns the shares userID has accepted.
+	ListAccepted(ctx context.Context, userID primitive.ObjectID) ([]*models.Share, error)
+	// ListForTodo returns the accepted shares giving userID access to an owner's
+	// todo, either directly or through its project.
+	ListForTodo(ctx context.Context, userID, ownerID, todoID primitive.ObjectID, projectID *primitive.ObjectID) ([]*models.Share, error)
+	// ListAcceptedForTodo returns every accepted share of an owner's todo, made
+	// directly or through its project.
+	ListAcceptedForTodo(ctx context.Context, ownerID, todoID primitive.ObjectID, projectID *primitive.ObjectID) ([]*models.Share, error)
+	// Accept marks a pending share accepted by userID and returns it.
+	Accept(ctx context.Context, shareID, userID primitive.ObjectID, at time.Time) (*models.Share, error)
+	Delete(ctx context.Context, shareID primitive.ObjectID) error
+}
+
+type shareRepository struct {
+	collection *mongo.Collection
+}
+
+func NewShareRepository(client *mongo.Client) ShareRepository {
+	return &shareRepository{collection: client.Database("golang-todo").Collection("shares")}
+}
+
+func (r *shareRepository) Create(ctx context.Context, share *models.Share) error {
+	_, err := r.collection.InsertOne(ctx, share)
+	return translate(err, "share")
+}
+
+func (r *shareRepository) GetByID(ctx context.Context, shareID primitive.ObjectID) (*models.Share, error) {
+	var share models.Share
+	if err := r.collection.FindOne(ctx, bson.M{"_id": shareID}).Decode(&share); err != nil {
+		return nil, translate(err, "share")
+	}
+	return &share, nil
+}
+
+func (r *shareRepository) Find(ctx context.Context, ownerID primitive.ObjectID, resource models.ShareResource, resourceID primitive.ObjectID, email string) (*models.Share, error) {
+	var share models.Share
+	filter := bson.M{"owner_id": ownerID, "resource": resource, "resource_id": resourceID, "email": email}
+	if err := r.collection.FindOne(ctx, filter).Decode(&share); err != nil {
+		return nil, translate(err, "share")
+	}
+	return &share, nil
+}
+
+func (r *shareRepository) ListByResource(ctx context.Context, ownerID primitive.ObjectID, resource models.ShareResource, resourceID primitive.ObjectID) ([]*models.Share, error) {
+	return r.find(ctx, bson.M{"owner_id": ownerID, "resource": resource, "resource_id": resourceID})
+}
+
+func (r *shareRepository) ListByEmail(ctx context.Context, email string) ([]*models.Share, error) {
+	return r.find(ctx, bson.M{"email": email})
+}
+
+func (r *shareRepository) ListAccepted(ctx context.Context, userID primitive.ObjectID) ([]*models.Share, error) {
+	return r.find(ctx, bson.M{"invitee_id": userID, "status": models.ShareStatusAccepted})
+}
+
+func (r *shareRepository) ListForTodo(ctx context.Context, userID, ownerID, todoID primitive.ObjectID, projectID *primitive.ObjectID) ([]*models.Share, error) {
+	return r.find(ctx, merge(todoSharesFilter(ownerID, todoID, projectID), bson.M{"invitee_id": userID}))
+}
+
+func (r *shareRepository) ListAcceptedForTodo(ctx context.Context, ownerID, todoID primitive.ObjectID, projectID *primitive.ObjectID) ([]*models.Share, error) {
+	return r.find(ctx, todoSharesFilter(ownerID, todoID, projectID))
+}
+
+// todoSharesFilter matches the accepted shares covering an owner's todo.
+func todoSharesFilter(ownerID, todoID primitive.ObjectID, projectID *primitive.ObjectID) bson.M {
+	resources := bson.A{bson.M{"resource": models.ShareResourceTodo, "resource_id": todoID}}
+	if projectID != nil {
+		resources = append(resources, bson.M{"resource": models.ShareResourceProject, "resource_id": *projectID})
+	}
+	return bson.M{
+		"owner_id": ownerID,
+		"status":   models.ShareStatusAccepted,
+		"$or":      resources,
+	}
+}
+
+func (r *shareRepository) find(ctx context.Context, filter bson.M) ([]*models.Share, error) {
+	cur, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	shares := []*models.Share{}
+	if err := cur.All(ctx, &shares); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+func (r *shareRepository) Accept(ctx context.Context, shareID, userID primitive.ObjectID, at time.Time) (*models.Share, error) {
+	var share models.Share
+	update := bson.M{"$set": bson.M{"status": models.ShareStatusAccepted, "invitee_id": userID, "accepted_at": at}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": shareID, "status": models.ShareStatusPending}, update, opts).Decode(&share)
+	if err != nil {
+		return nil, translate(err, "share")
+	}
+	return &share, nil
+}
+
+func (r *shareRepository) Delete(ctx context.Context, shareID primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": shareID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return translate(mongo.ErrNoDocuments, "share")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tenant isolation. Todos (and so their projects) and todo events belong either
+// to a user personally or to a workspace. WorkspaceMiddleware puts the workspace
+// of the request into its context after checking membership; repositories build
+// every query from tenantFilter, so a call can only ever see one tenant's data.
+
+type workspaceKey struct{}
+
+// WorkspaceScope is the workspace a request acts in and the caller's role there.
+type WorkspaceScope struct {
+	ID   primitive.ObjectID
+	Role models.WorkspaceRole
+}
+
+// WithWorkspace scopes repository calls made with the returned context to a workspace.
+func WithWorkspace(ctx context.Context, scope WorkspaceScope) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, scope)
+}
+
+// WorkspaceFrom returns the workspace ctx is scoped to, if any.
+func WorkspaceFrom(ctx context.Context) (WorkspaceScope, bool) {
+	scope, ok := ctx.Value(workspaceKey{}).(WorkspaceScope)
+	return scope, ok
+}
+
+// tenantFilter is the base filter for a user's data: the workspace's documents
+// when ctx is scoped to one, otherwise the user's personal (workspace-less) ones.
+func tenantFilter(ctx context.Context, userID primitive.ObjectID) bson.M {
+	if scope, ok := WorkspaceFrom(ctx); ok {
+		return bson.M{"workspace_id": scope.ID}
+	}
+	return bson.M{"user_id": userID, "workspace_id": nil}
+}
+
+// workspaceFilter is tenantFilter without the user: everything in the ctx
+// workspace, or only personal documents when unscoped. Callers that look
+// documents up across users must check access themselves.
+func workspaceFilter(ctx context.Context) bson.M {
+	if scope, ok := WorkspaceFrom(ctx); ok {
+		return bson.M{"workspace_id": scope.ID}
+	}
+	return bson.M{"workspace_id": nil}
+}
+
+// stampWorkspace sets the ctx workspace on a document being created.
+func stampWorkspace(ctx context.Context) *primitive.ObjectID {
+	if scope, ok := WorkspaceFrom(ctx); ok {
+		id := scope.ID
+		return &id
+	}
+	return nil
+}
+
+// merge copies extra into filter and returns it.
+func merge(filter, extra bson.M) bson.M {
+	for k, v := range extra {
+		filter[k] = v
+	}
+	return filter
+}
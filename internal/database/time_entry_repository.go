@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TimeEntryRepository stores tracked time. Entries belong to the user who
+// tracked them and are confined to the ctx tenant, except for the running
+// timer: a user has at most one, whichever tenant it was started in.
+type TimeEntryRepository interface {
+	// EnsureIndexes creates the unique index that allows one running timer per user.
+	EnsureIndexes(ctx context.Context) error
+	// Start inserts a running entry, failing with timer_running if the user already has one.
+	Start(ctx context.Context, entry *models.TimeEntry) error
+	// Running returns the user's running entry in any tenant.
+	Running(ctx context.Context, userID primitive.ObjectID) (*models.TimeEntry, error)
+	// Stop ends the user's running entry on todoID at endedAt.
+	Stop(ctx context.Context, userID, todoID primitive.ObjectID, endedAt time.Time) (*models.TimeEntry, error)
+	Create(ctx context.Context, entry *models.TimeEntry) error
+	Get(ctx context.Context, userID, entryID primitive.ObjectID) (*models.TimeEntry, error)
+	// ListByTodo returns every user's entries on a todo, oldest first.
+	ListByTodo(ctx context.Context, todoID primitive.ObjectID) ([]*models.TimeEntry, error)
+	// ListByUser returns the user's entries started in [from, to), oldest first.
+	ListByUser(ctx context.Context, userID primitive.ObjectID, from, to time.Time) ([]*models.TimeEntry, error)
+	// Update applies a $set and returns the updated entry.
+	Update(ctx context.Context, userID, entryID primitive.ObjectID, set bson.M) (*models.TimeEntry, error)
+	Delete(ctx context.Context, userID, entryID primitive.ObjectID) error
+}
+
+type timeEntryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTimeEntryRepository(client *mongo.Client) TimeEntryRepository {
+	return &timeEntryRepository{collection: client.Database("golang-todo").Collection("time_entries")}
+}
+
+func (r *timeEntryRepository) EnsureIndexes(ctx context.Context) error {
+	// Running entries store ended_at as null; $type is what partial indexes accept for that.
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().
+			SetName("one_running_timer_per_user").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"ended_at": bson.M{"$type": "null"}}),
+	})
+	return err
+}
+
+func (r *timeEntryRepository) Start(ctx context.Context, entry *models.TimeEntry) error {
+	entry.WorkspaceID = stampWorkspace(ctx)
+	// The partial unique index refuses a second running entry, even from a concurrent request.
+	_, err := r.collection.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.Conflict("a timer is already running; stop it first").WithCode("timer_running")
+	}
+	return translate(err, "time entry")
+}
+
+func (r *timeEntryRepository) Running(ctx context.Context, userID primitive.ObjectID) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID, "ended_at": nil}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errTimerNotRunning()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *timeEntryRepository) Stop(ctx context.Context, userID, todoID primitive.ObjectID, endedAt time.Time) (*models.TimeEntry, error) {
+	filter := bson.M{"user_id": userID, "todo_id": todoID, "ended_at": nil}
+	update := bson.M{"$set": bson.M{"ended_at": endedAt, "updated_at": endedAt}}
+	var entry models.TimeEntry
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errTimerNotRunning()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *timeEntryRepository) Create(ctx context.Context, entry *models.TimeEntry) error {
+	entry.WorkspaceID = stampWorkspace(ctx)
+	_, err := r.collection.InsertOne(ctx, entry)
+	return translate(err, "time entry")
+}
+
+func (r *timeEntryRepository) Get(ctx context.Context, userID, entryID primitive.ObjectID) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	if err := r.collection.FindOne(ctx, merge(workspaceFilter(ctx), bson.M{"_id": entryID, "user_id": userID})).Decode(&entry); err != nil {
+		return nil, translate(err, "time entry")
+	}
+	return &entry, nil
+}
+
+func (r *timeEntryRepository) ListByTodo(ctx context.Context, todoID primitive.ObjectID) ([]*models.TimeEntry, error) {
+	return r.find(ctx, merge(workspaceFilter(ctx), bson.M{"todo_id": todoID}))
+}
+
+func (r *timeEntryRepository) ListByUser(ctx context.Context, userID primitive.ObjectID, from, to time.Time) ([]*models.TimeEntry, error) {
+	return r.find(ctx, merge(workspaceFilter(ctx), bson.M{"user_id": userID, "started_at": bson.M{"$gte": from, "$lt": to}}))
+}
+
+func (r *timeEntryRepository) find(ctx context.Context, filter bson.M) ([]*models.TimeEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	entries := []*models.TimeEntry{}
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *timeEntryRepository) Update(ctx context.Context, userID, entryID primitive.ObjectID, set bson.M) (*models.TimeEntry, error) {
+	filter := merge(workspaceFilter(ctx), bson.M{"_id": entryID, "user_id": userID})
+	var entry models.TimeEntry
+	err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": set}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&entry)
+	if err != nil {
+		return nil, translate(err, "time entry")
+	}
+	return &entry, nil
+}
+
+func (r *timeEntryRepository) Delete(ctx context.Context, userID, entryID primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, merge(workspaceFilter(ctx), bson.M{"_id": entryID, "user_id": userID}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return translate(mongo.ErrNoDocuments, "time entry")
+	}
+	return nil
+}
+
+func errTimerNotRunning() error {
+	return domain.NotFound("no timer is running").WithCode("timer_not_running")
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"io"
+
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TodoEventRepository is append-only: events are never updated or deleted.
+// Reads are scoped to the ctx tenant (see tenantFilter).
+type TodoEventRepository interface {
+	Create(ctx context.Context, event *models.TodoEvent) error
+	// ListByTodo returns a todo's events in the ctx workspace (or personal
+	// events when unscoped); callers check access to the todo first.
+	ListByTodo(ctx context.Context, todoID primitive.ObjectID, page EventPage) ([]*models.TodoEvent, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID, page EventPage) ([]*models.TodoEvent, error)
+	// ListByTodoSinceVersion returns the events that produced versions newer than version, oldest first.
+	ListByTodoSinceVersion(ctx context.Context, userID, todoID primitive.ObjectID, version int64) ([]*models.TodoEvent, error)
+	// ListAfter returns up to limit events newer than after, oldest first. Outside
+	// a workspace it includes events of the todos and projects in shared.
+	ListAfter(ctx context.Context, userID, after primitive.ObjectID, shared []*models.Share, limit int64) ([]*models.TodoEvent, error)
+	// Watch opens a change stream of inserted events for all users, resuming after
+	// resumeToken when given. It requires a replica set or sharded cluster and fails
+	// immediately otherwise.
+	Watch(ctx context.Context, resumeToken bson.Raw) (TodoEventStream, error)
+}
+
+// TodoEventStream iterates events from a change stream.
+type TodoEventStream interface {
+	// Next blocks until the next event arrives, ctx is done or the stream fails.
+	Next(ctx context.Context) (*models.TodoEvent, error)
+	// ResumeToken identifies the position after the last event returned by Next.
+	ResumeToken() bson.Raw
+	Close(ctx context.Context) error
+}
+
+// EventPage pages newest-first by event ID. Before is exclusive; zero means from the newest.
+type EventPage struct {
+	Before primitive.ObjectID
+	Limit  int64
+}
+
+type todoEventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTodoEventRepository(client *mongo.Client) TodoEventRepository {
+	return &todoEventRepository{collection: client.Database("golang-todo").Collection("todo_events")}
+}
+
+func (r *todoEventRepository) Create(ctx context.Context, event *models.TodoEvent) error {
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+func (r *todoEventRepository) ListByTodo(ctx context.Context, todoID primitive.ObjectID, page EventPage) ([]*models.TodoEvent, error) {
+	return r.list(ctx, merge(workspaceFilter(ctx), bson.M{"todo_id": todoID}), page)
+}
+
+func (r *todoEventRepository) ListByUser(ctx context.Context, userID primitive.ObjectID, page EventPage) ([]*models.TodoEvent, error) {
+	return r.list(ctx, tenantFilter(ctx, userID), page)
+}
+
+func (r *todoEventRepository) ListByTodoSinceVersion(ctx context.Context, userID, todoID primitive.ObjectID, version int64) ([]*models.TodoEvent, error) {
+	filter := merge(tenantFilter(ctx, userID), bson.M{"todo_id": todoID, "version": bson.M{"$gt": version}})
+	cur, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	events := []*models.TodoEvent{}
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *todoEventRepository) ListAfter(ctx context.Context, userID, after primitive.ObjectID, shared []*models.Share, limit int64) ([]*models.TodoEvent, error) {
+	filter := tenantFilter(ctx, userID)
+	if _, ok := WorkspaceFrom(ctx); !ok && len(shared) > 0 {
+		visible := bson.A{filter}
+		for _, sh := range shared {
+			field := "todo_id"
+			if sh.Resource == models.ShareResourceProject {
+				field = "project_id"
+			}
+			visible = append(visible, bson.M{"user_id": sh.OwnerID, "workspace_id": nil, field: sh.ResourceID})
+		}
+		filter = bson.M{"$or": visible}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit)
+	cur, err := r.collection.Find(ctx, merge(filter, bson.M{"_id": bson.M{"$gt": after}}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	events := []*models.TodoEvent{}
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *todoEventRepository) Watch(ctx context.Context, resumeToken bson.Raw) (TodoEventStream, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": "insert"}}}}
+	opts := options.ChangeStream()
+	if resumeToken != nil {
+		opts.SetResumeAfter(resumeToken)
+	}
+	stream, err := r.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &todoEventStream{stream: stream}, nil
+}
+
+type todoEventStream struct {
+	stream *mongo.ChangeStream
+}
+
+func (s *todoEventStream) Next(ctx context.Context) (*models.TodoEvent, error) {
+	if !s.stream.Next(ctx) {
+		if err := s.stream.Err(); err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	var change struct {
+		FullDocument models.TodoEvent `bson:"fullDocument"`
+	}
+	if err := s.stream.Decode(&change); err != nil {
+		return nil, err
+	}
+	return &change.FullDocument, nil
+}
+
+func (s *todoEventStream) ResumeToken() bson.Raw {
+	return s.stream.ResumeToken()
+}
+
+func (s *todoEventStream) Close(ctx context.Context) error {
+	return s.stream.Close(ctx)
+}
+
+func (r *todoEventRepository) list(ctx context.Context, filter bson.M, page EventPage) ([]*models.TodoEvent, error) {
+	if !page.Before.IsZero() {
+		filter["_id"] = bson.M{"$lt": page.Before}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+	if page.Limit > 0 {
+		opts.SetLimit(page.Limit)
+	}
+
+	cur, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	events := []*models.TodoEvent{}
+	for cur.Next(ctx) {
+		var e models.TodoEvent
+		if err := cur.Decode(&e); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, cur.Err()
+}
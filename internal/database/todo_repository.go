@@ -2,19 +2,197 @@ package database
 
 import (
 	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
 
+	"github.com/group14000/golang-todo/internal/domain"
 	"github.com/group14000/golang-todo/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// TodoRepository scopes every call to the ctx tenant: the user's personal todos,
+// or the workspace's todos when ctx carries one (see WithWorkspace).
 type TodoRepository interface {
 	Create(ctx context.Context, todo *models.Todo) error
-	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.Todo, error)
+	// CreateMany inserts todos all or nothing.
+	CreateMany(ctx context.Context, todos []*models.Todo) error
+	ListByUser(ctx context.Context, userID primitive.ObjectID, opts TodoListOptions) ([]*models.Todo, error)
+	// EachByUser streams the todos ListByUser would return to fn, stopping at the first error.
+	EachByUser(ctx context.Context, userID primitive.ObjectID, opts TodoListOptions, fn func(*models.Todo) error) error
 	GetByID(ctx context.Context, userID, todoID primitive.ObjectID) (*models.Todo, error)
-	Update(ctx context.Context, userID, todoID primitive.ObjectID, update bson.M) error
-	Delete(ctx context.Context, userID, todoID primitive.ObjectID) error
+	// FindByID looks a todo up by ID alone within the ctx tenant, deleted or not.
+	// Outside a workspace it ignores ownership, so callers must check the caller's
+	// access (see TodoService).
+	FindByID(ctx context.Context, todoID primitive.ObjectID) (*models.Todo, error)
+	// ListByIDs returns the live todos in the ctx tenant with the given IDs, whoever owns them.
+	ListByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Todo, error)
+	// ListByIDsIncludingDeleted is ListByIDs that also finds soft-deleted todos.
+	ListByIDsIncludingDeleted(ctx context.Context, ids []primitive.ObjectID) ([]*models.Todo, error)
+	// ListDependents returns the user's live todos blocked by any of ids.
+	ListDependents(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Todo, error)
+	// CountInStatus counts the user's live todos in a project (nil: no project) with the given status.
+	CountInStatus(ctx context.Context, userID primitive.ObjectID, projectID *primitive.ObjectID, status string) (int64, error)
+	// Stats aggregates the user's live todos (see TodoStats).
+	Stats(ctx context.Context, userID primitive.ObjectID, q TodoStatsQuery) (*TodoStats, error)
+	// ListArchived returns a page of archived todos, most recently archived first.
+	ListArchived(ctx context.Context, userID primitive.ObjectID, q TodoArchiveQuery) ([]*models.Todo, error)
+	// ListAssigned returns the live todos in the ctx tenant assigned to assigneeID, whoever owns them.
+	ListAssigned(ctx context.Context, assigneeID primitive.ObjectID, opts TodoListOptions) ([]*models.Todo, error)
+	// GetByCalDAVName finds a todo by the resource name a CalDAV client gave it, deleted or not.
+	GetByCalDAVName(ctx context.Context, userID primitive.ObjectID, name string) (*models.Todo, error)
+	// GetIncludingDeleted is GetByID that also finds soft-deleted todos.
+	GetIncludingDeleted(ctx context.Context, userID, todoID primitive.ObjectID) (*models.Todo, error)
+	// ListChanged returns todos (soft-deleted ones too, if asked) in (updated_at, _id) order for delta sync.
+	ListChanged(ctx context.Context, userID primitive.ObjectID, q TodoChangesQuery) ([]*models.Todo, error)
+	// Update applies a $set, bumps the version and returns the todo as it was before the update.
+	// A non-nil ifVersions makes the write conditional on the current version (If-Match).
+	Update(ctx context.Context, userID, todoID primitive.ObjectID, update bson.M, ifVersions []int64) (*models.Todo, error)
+	// Delete soft-deletes the todo and returns it as it was before deletion.
+	Delete(ctx context.Context, userID, todoID primitive.ObjectID, ifVersions []int64) (*models.Todo, error)
+	// Restore undoes a soft delete and returns the restored todo.
+	Restore(ctx context.Context, userID, todoID primitive.ObjectID) (*models.Todo, error)
+	// AddCommentCount adjusts comment_count by delta and bumps updated_at, so
+	// sync clients pick up the new count. It does not bump the version: comments
+	// are not edits of the todo, and ETags carry the count separately.
+	AddCommentCount(ctx context.Context, todoID primitive.ObjectID, delta int) error
+	// SetNextOccurrence records the occurrence created from a completed recurring
+	// todo, unless one is recorded already. It does not bump the version, so the
+	// completion's ETag stays valid.
+	SetNextOccurrence(ctx context.Context, todoID, nextID primitive.ObjectID) error
+	// ListDeletedBefore returns up to limit todos of any tenant that were
+	// soft-deleted before cutoff, in ID order after the given ID.
+	ListDeletedBefore(ctx context.Context, cutoff time.Time, after primitive.ObjectID, limit int64) ([]*models.Todo, error)
+	// Purge permanently removes a todo that is still soft-deleted since before cutoff.
+	Purge(ctx context.Context, todoID primitive.ObjectID, cutoff time.Time) error
+}
+
+// ArchiveFilter says how a todo list treats archived todos.
+type ArchiveFilter int
+
+const (
+	// ExcludeArchived, the zero value, leaves archived todos out like the default list.
+	ExcludeArchived ArchiveFilter = iota
+	OnlyArchived
+	IncludeArchived
+)
+
+// TodoListOptions narrows and orders ListByUser results. Zero values mean "no
+// constraint", except that archived todos are left out unless Archived says otherwise.
+type TodoListOptions struct {
+	DueFrom   *time.Time // inclusive
+	DueBefore *time.Time // exclusive
+	ProjectID *primitive.ObjectID
+	// NoProject keeps only todos without a project; ignored when ProjectID is set.
+	NoProject bool
+	// Label keeps todos carrying this label.
+	Label     string
+	Completed *bool
+	// CompletedFrom keeps todos completed at or after this instant.
+	CompletedFrom *time.Time
+	// CompletedBefore keeps todos completed before this instant.
+	CompletedBefore *time.Time
+	Archived        ArchiveFilter
+	// Match is an extra filter ANDed with the others, such as a compiled saved filter.
+	Match bson.M
+	// Sort is a field name, prefixed with "-" for descending (e.g. "-created_at").
+	Sort string
+}
+
+// filter builds the Mongo query; the tenant is always included to enforce isolation.
+func (o TodoListOptions) filter(ctx context.Context, userID primitive.ObjectID) bson.M {
+	return o.apply(merge(tenantFilter(ctx, userID), bson.M{"deleted_at": nil}))
+}
+
+// apply adds the options' constraints to a base filter.
+func (o TodoListOptions) apply(filter bson.M) bson.M {
+	if o.DueFrom != nil || o.DueBefore != nil {
+		due := bson.M{}
+		if o.DueFrom != nil {
+			due["$gte"] = *o.DueFrom
+		}
+		if o.DueBefore != nil {
+			due["$lt"] = *o.DueBefore
+		}
+		filter["due_at"] = due
+	}
+	if o.ProjectID != nil {
+		filter["project_id"] = *o.ProjectID
+	} else if o.NoProject {
+		filter["project_id"] = nil
+	}
+	if o.Label != "" {
+		filter["labels"] = o.Label
+	}
+	if o.Completed != nil {
+		filter["completed"] = *o.Completed
+	}
+	if o.CompletedFrom != nil || o.CompletedBefore != nil {
+		completed := bson.M{}
+		if o.CompletedFrom != nil {
+			completed["$gte"] = *o.CompletedFrom
+		}
+		if o.CompletedBefore != nil {
+			completed["$lt"] = *o.CompletedBefore
+		}
+		filter["completed_at"] = completed
+	}
+	switch o.Archived {
+	case ExcludeArchived:
+		filter["archived_at"] = nil
+	case OnlyArchived:
+		filter["archived_at"] = bson.M{"$ne": nil}
+	}
+	if len(o.Match) > 0 {
+		filter["$and"] = bson.A{o.Match}
+	}
+	return filter
+}
+
+func (o TodoListOptions) findOptions() *options.FindOptions {
+	if o.Sort == "" {
+		return options.Find()
+	}
+	field, desc := strings.CutPrefix(o.Sort, "-")
+	dir := 1
+	if desc {
+		dir = -1
+	}
+	// _id as tie-breaker keeps paging and ordering stable.
+	return options.Find().SetSort(bson.D{{Key: field, Value: dir}, {Key: "_id", Value: dir}})
+}
+
+// TodoChangesQuery selects todos for delta sync.
+type TodoChangesQuery struct {
+	// Since keeps todos with updated_at >= Since; zero means all.
+	Since time.Time
+	// After resumes strictly after this (updated_at, _id) position.
+	After          *TodoCursor
+	IncludeDeleted bool
+	Limit          int64
+}
+
+// TodoArchiveQuery selects a page of archived todos.
+type TodoArchiveQuery struct {
+	// After resumes strictly after this position in (archived_at, _id) descending order.
+	After *ArchiveCursor
+	Limit int64
+}
+
+// ArchiveCursor is a position in (archived_at, _id) order.
+type ArchiveCursor struct {
+	ArchivedAt time.Time          `json:"archived_at"`
+	ID         primitive.ObjectID `json:"id"`
+}
+
+// TodoCursor is a position in (updated_at, _id) order.
+type TodoCursor struct {
+	UpdatedAt time.Time
+	ID        primitive.ObjectID
 }
 
 type todoRepository struct {
@@ -25,44 +203,318 @@ func NewTodoRepository(client *mongo.Client) TodoRepository {
 	return &todoRepository{collection: client.Database("golang-todo").Collection("todos")}
 }
 
+// Create stamps the ctx workspace on the todo, so it cannot be written outside the caller's tenant.
 func (r *todoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	todo.WorkspaceID = stampWorkspace(ctx)
 	_, err := r.collection.InsertOne(ctx, todo)
-	return err
+	return translate(err, "todo")
+}
+
+// CreateMany stamps and inserts todos in a transaction. Standalone servers
+// cannot run transactions; there the todos go in as one ordered batch and the
+// inserted ones are removed again if any insert fails.
+func (r *todoRepository) CreateMany(ctx context.Context, todos []*models.Todo) error {
+	docs := make([]any, len(todos))
+	for i, todo := range todos {
+		todo.WorkspaceID = stampWorkspace(ctx)
+		docs[i] = todo
+	}
+
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (any, error) {
+		return r.collection.InsertMany(sc, docs)
+	})
+	if !transactionsUnsupported(err) {
+		return translate(err, "todo")
+	}
+
+	res, err := r.collection.InsertMany(ctx, docs)
+	if err != nil && res != nil && len(res.InsertedIDs) > 0 {
+		if _, cleanupErr := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": res.InsertedIDs}}); cleanupErr != nil {
+			log.Printf("todos: remove partial batch: %v", cleanupErr)
+		}
+	}
+	return translate(err, "todo")
 }
 
-func (r *todoRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.Todo, error) {
-	cur, err := r.collection.Find(ctx, bson.M{"user_id": userID})
+func (r *todoRepository) ListByUser(ctx context.Context, userID primitive.ObjectID, opts TodoListOptions) ([]*models.Todo, error) {
+	var todos []*models.Todo
+	err := r.EachByUser(ctx, userID, opts, func(t *models.Todo) error {
+		todos = append(todos, t)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return todos, nil
+}
+
+func (r *todoRepository) EachByUser(ctx context.Context, userID primitive.ObjectID, opts TodoListOptions, fn func(*models.Todo) error) error {
+	cur, err := r.collection.Find(ctx, opts.filter(ctx, userID), opts.findOptions())
+	if err != nil {
+		return err
+	}
 	defer cur.Close(ctx)
 
-	var todos []*models.Todo
 	for cur.Next(ctx) {
 		var t models.Todo
 		if err := cur.Decode(&t); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(&t); err != nil {
+			return err
 		}
-		todos = append(todos, &t)
 	}
-	return todos, cur.Err()
+	return cur.Err()
 }
 
 func (r *todoRepository) GetByID(ctx context.Context, userID, todoID primitive.ObjectID) (*models.Todo, error) {
 	var todo models.Todo
-	err := r.collection.FindOne(ctx, bson.M{"_id": todoID, "user_id": userID}).Decode(&todo)
+	err := r.collection.FindOne(ctx, merge(tenantFilter(ctx, userID), bson.M{"_id": todoID, "deleted_at": nil})).Decode(&todo)
 	if err != nil {
+		return nil, translate(err, "todo")
+	}
+	return &todo, nil
+}
+
+func (r *todoRepository) FindByID(ctx context.Context, todoID primitive.ObjectID) (*models.Todo, error) {
+	var todo models.Todo
+	if err := r.collection.FindOne(ctx, merge(workspaceFilter(ctx), bson.M{"_id": todoID})).Decode(&todo); err != nil {
+		return nil, translate(err, "todo")
+	}
+	return &todo, nil
+}
+
+func (r *todoRepository) ListByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Todo, error) {
+	return r.find(ctx, merge(workspaceFilter(ctx), bson.M{"_id": bson.M{"$in": ids}, "deleted_at": nil}))
+}
+
+func (r *todoRepository) ListByIDsIncludingDeleted(ctx context.Context, ids []primitive.ObjectID) ([]*models.Todo, error) {
+	return r.find(ctx, merge(workspaceFilter(ctx), bson.M{"_id": bson.M{"$in": ids}}))
+}
+
+func (r *todoRepository) ListDependents(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Todo, error) {
+	return r.find(ctx, merge(tenantFilter(ctx, userID), bson.M{"blocked_by": bson.M{"$in": ids}, "deleted_at": nil}))
+}
+
+func (r *todoRepository) CountInStatus(ctx context.Context, userID primitive.ObjectID, projectID *primitive.ObjectID, status string) (int64, error) {
+	// Archived todos are not on the board, so they do not count against WIP limits.
+	filter := merge(tenantFilter(ctx, userID), bson.M{"project_id": projectID, "status": status, "deleted_at": nil, "archived_at": nil})
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+func (r *todoRepository) find(ctx context.Context, filter bson.M) ([]*models.Todo, error) {
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	todos := []*models.Todo{}
+	if err := cur.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (r *todoRepository) ListAssigned(ctx context.Context, assigneeID primitive.ObjectID, opts TodoListOptions) ([]*models.Todo, error) {
+	cur, err := r.collection.Find(ctx, opts.apply(merge(workspaceFilter(ctx), bson.M{"assignee_id": assigneeID, "deleted_at": nil})), opts.findOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	todos := []*models.Todo{}
+	if err := cur.All(ctx, &todos); err != nil {
 		return nil, err
 	}
+	return todos, nil
+}
+
+func (r *todoRepository) GetByCalDAVName(ctx context.Context, userID primitive.ObjectID, name string) (*models.Todo, error) {
+	var todo models.Todo
+	err := r.collection.FindOne(ctx, merge(tenantFilter(ctx, userID), bson.M{"caldav.name": name})).Decode(&todo)
+	if err != nil {
+		return nil, translate(err, "todo")
+	}
+	return &todo, nil
+}
+
+func (r *todoRepository) GetIncludingDeleted(ctx context.Context, userID, todoID primitive.ObjectID) (*models.Todo, error) {
+	var todo models.Todo
+	err := r.collection.FindOne(ctx, merge(tenantFilter(ctx, userID), bson.M{"_id": todoID})).Decode(&todo)
+	if err != nil {
+		return nil, translate(err, "todo")
+	}
 	return &todo, nil
 }
 
-func (r *todoRepository) Update(ctx context.Context, userID, todoID primitive.ObjectID, update bson.M) error {
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": todoID, "user_id": userID}, bson.M{"$set": update})
+func (r *todoRepository) ListArchived(ctx context.Context, userID primitive.ObjectID, q TodoArchiveQuery) ([]*models.Todo, error) {
+	filter := merge(tenantFilter(ctx, userID), bson.M{"deleted_at": nil, "archived_at": bson.M{"$ne": nil}})
+	if q.After != nil {
+		filter["$or"] = bson.A{
+			bson.M{"archived_at": bson.M{"$lt": q.After.ArchivedAt}},
+			bson.M{"archived_at": q.After.ArchivedAt, "_id": bson.M{"$lt": q.After.ID}},
+		}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "archived_at", Value: -1}, {Key: "_id", Value: -1}})
+	if q.Limit > 0 {
+		opts.SetLimit(q.Limit)
+	}
+
+	cur, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	todos := []*models.Todo{}
+	if err := cur.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (r *todoRepository) ListChanged(ctx context.Context, userID primitive.ObjectID, q TodoChangesQuery) ([]*models.Todo, error) {
+	filter := tenantFilter(ctx, userID)
+	if !q.IncludeDeleted {
+		filter["deleted_at"] = nil
+	}
+	and := bson.A{}
+	if !q.Since.IsZero() {
+		and = append(and, bson.M{"updated_at": bson.M{"$gte": q.Since}})
+	}
+	if q.After != nil {
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"updated_at": bson.M{"$gt": q.After.UpdatedAt}},
+			bson.M{"updated_at": q.After.UpdatedAt, "_id": bson.M{"$gt": q.After.ID}},
+		}})
+	}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}})
+	if q.Limit > 0 {
+		opts.SetLimit(q.Limit)
+	}
+
+	cur, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	todos := []*models.Todo{}
+	if err := cur.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (r *todoRepository) Update(ctx context.Context, userID, todoID primitive.ObjectID, update bson.M, ifVersions []int64) (*models.Todo, error) {
+	filter := merge(tenantFilter(ctx, userID), bson.M{"_id": todoID, "deleted_at": nil})
+	return r.conditionalUpdate(ctx, filter, bson.M{"$set": update}, ifVersions)
+}
+
+func (r *todoRepository) Delete(ctx context.Context, userID, todoID primitive.ObjectID, ifVersions []int64) (*models.Todo, error) {
+	now := time.Now()
+	filter := merge(tenantFilter(ctx, userID), bson.M{"_id": todoID, "deleted_at": nil})
+	return r.conditionalUpdate(ctx, filter, bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}}, ifVersions)
+}
+
+// conditionalUpdate applies update (returning the prior document) only if the version matches.
+// When nothing matched it distinguishes a missing todo (ErrNotFound) from a stale version
+// (ErrPreconditionFailed).
+func (r *todoRepository) conditionalUpdate(ctx context.Context, filter, update bson.M, ifVersions []int64) (*models.Todo, error) {
+	if ifVersions == nil {
+		return r.findOneAndUpdate(ctx, filter, update, options.Before)
+	}
+
+	conditional := bson.M{"version": bson.M{"$in": ifVersions}}
+	for k, v := range filter {
+		conditional[k] = v
+	}
+	for _, v := range ifVersions {
+		if v == 0 {
+			// Todos written before versioning have no version field; treat it as 0.
+			conditional["$or"] = bson.A{bson.M{"version": bson.M{"$in": ifVersions}}, bson.M{"version": bson.M{"$exists": false}}}
+			delete(conditional, "version")
+			break
+		}
+	}
+
+	todo, err := r.findOneAndUpdate(ctx, conditional, update, options.Before)
+	if errors.Is(err, domain.ErrNotFound) {
+		if n, cerr := r.collection.CountDocuments(ctx, filter); cerr == nil && n > 0 {
+			return nil, domain.PreconditionFailed("todo was modified by another request").WithCode("version_mismatch")
+		}
+	}
+	return todo, err
+}
+
+func (r *todoRepository) Restore(ctx context.Context, userID, todoID primitive.ObjectID) (*models.Todo, error) {
+	filter := merge(tenantFilter(ctx, userID), bson.M{"_id": todoID, "deleted_at": bson.M{"$ne": nil}})
+	update := bson.M{"$unset": bson.M{"deleted_at": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	todo, err := r.findOneAndUpdate(ctx, filter, update, options.After)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.NotFound("deleted todo not found").WithCode("todo_not_found")
+	}
+	return todo, err
+}
+
+func (r *todoRepository) AddCommentCount(ctx context.Context, todoID primitive.ObjectID, delta int) error {
+	filter := merge(workspaceFilter(ctx), bson.M{"_id": todoID})
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$inc": bson.M{"comment_count": delta},
+		"$set": bson.M{"updated_at": time.Now()},
+	})
 	return err
 }
 
-func (r *todoRepository) Delete(ctx context.Context, userID, todoID primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": todoID, "user_id": userID})
+func (r *todoRepository) SetNextOccurrence(ctx context.Context, todoID, nextID primitive.ObjectID) error {
+	filter := merge(workspaceFilter(ctx), bson.M{"_id": todoID, "next_occurrence_id": nil})
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"next_occurrence_id": nextID}})
 	return err
 }
+
+func (r *todoRepository) ListDeletedBefore(ctx context.Context, cutoff time.Time, after primitive.ObjectID, limit int64) ([]*models.Todo, error) {
+	filter := bson.M{"deleted_at": bson.M{"$ne": nil, "$lt": cutoff}, "_id": bson.M{"$gt": after}}
+	cur, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	todos := []*models.Todo{}
+	if err := cur.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (r *todoRepository) Purge(ctx context.Context, todoID primitive.ObjectID, cutoff time.Time) error {
+	// Conditional on deleted_at, so a todo restored meanwhile is kept.
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": todoID, "deleted_at": bson.M{"$ne": nil, "$lt": cutoff}})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return translate(mongo.ErrNoDocuments, "todo")
+	}
+	return nil
+}
+
+// findOneAndUpdate applies update and increments the version, so every write is versioned.
+func (r *todoRepository) findOneAndUpdate(ctx context.Context, filter, update bson.M, doc options.ReturnDocument) (*models.Todo, error) {
+	update["$inc"] = bson.M{"version": 1}
+	var todo models.Todo
+	opts := options.FindOneAndUpdate().SetReturnDocument(doc)
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&todo); err != nil {
+		return nil, translate(err, "todo")
+	}
+	return &todo, nil
+}
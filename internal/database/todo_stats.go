@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TodoStatsQuery sets the clock and timezone that Stats buckets by.
+type TodoStatsQuery struct {
+	// Timezone is an IANA name; completions are counted on their local day.
+	Timezone string
+	// Now decides which open todos are overdue.
+	Now time.Time
+}
+
+// TodoStats is the result of the Stats aggregation.
+type TodoStats struct {
+	Total     int64 `bson:"total"`
+	Completed int64 `bson:"completed"`
+	Overdue   int64 `bson:"overdue"`
+	// AvgCompletionMillis averages completed_at - created_at over completed todos.
+	AvgCompletionMillis float64 `bson:"avg_completion_ms"`
+	// CompletedByDay counts completions per local day (YYYY-MM-DD), oldest first, over all time.
+	CompletedByDay []TodoStatsDay `bson:"-"`
+	// ByProject has a nil ProjectID for todos without a project.
+	ByProject []TodoStatsProject `bson:"-"`
+	// ByLabel has an empty Label for todos without labels; a todo counts under each of its labels.
+	ByLabel []TodoStatsLabel `bson:"-"`
+}
+
+type TodoStatsDay struct {
+	Day   string `bson:"_id"`
+	Count int64  `bson:"count"`
+}
+
+type TodoStatsCounts struct {
+	Total     int64 `bson:"total"`
+	Completed int64 `bson:"completed"`
+	Overdue   int64 `bson:"overdue"`
+}
+
+type TodoStatsProject struct {
+	ProjectID       *primitive.ObjectID `bson:"_id"`
+	TodoStatsCounts `bson:",inline"`
+}
+
+type TodoStatsLabel struct {
+	Label           *string `bson:"_id"`
+	TodoStatsCounts `bson:",inline"`
+}
+
+func (r *todoRepository) Stats(ctx context.Context, userID primitive.ObjectID, q TodoStatsQuery) (*TodoStats, error) {
+	completed := bson.M{"$cond": bson.A{"$completed", 1, 0}}
+	// due_at is compared by type so that todos without a due date are never overdue.
+	overdue := bson.M{"$cond": bson.A{bson.M{"$and": bson.A{
+		bson.M{"$not": bson.A{"$completed"}},
+		bson.M{"$eq": bson.A{bson.M{"$type": "$due_at"}, "date"}},
+		bson.M{"$lt": bson.A{"$due_at", q.Now}},
+	}}, 1, 0}}
+	counts := bson.M{
+		"total":     bson.M{"$sum": 1},
+		"completed": bson.M{"$sum": completed},
+		"overdue":   bson.M{"$sum": overdue},
+	}
+	group := func(key any, extra bson.M) bson.M {
+		g := bson.M{"_id": key}
+		for k, v := range counts {
+			g[k] = v
+		}
+		for k, v := range extra {
+			g[k] = v
+		}
+		return bson.M{"$group": g}
+	}
+	byCount := bson.M{"$sort": bson.D{{Key: "total", Value: -1}, {Key: "_id", Value: 1}}}
+
+	pipeline := bson.A{
+		bson.M{"$match": merge(tenantFilter(ctx, userID), bson.M{"deleted_at": nil})},
+		bson.M{"$facet": bson.M{
+			"summary": bson.A{group(nil, bson.M{
+				// $avg skips the nulls of open todos and of completions without completed_at.
+				"avg_completion_ms": bson.M{"$avg": bson.M{"$cond": bson.A{
+					"$completed", bson.M{"$subtract": bson.A{"$completed_at", "$created_at"}}, nil,
+				}}},
+			})},
+			"days": bson.A{
+				bson.M{"$match": bson.M{"completed": true, "completed_at": bson.M{"$type": "date"}}},
+				bson.M{"$group": bson.M{
+					"_id":   bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$completed_at", "timezone": q.Timezone}},
+					"count": bson.M{"$sum": 1},
+				}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"projects": bson.A{group("$project_id", nil), byCount},
+			"labels": bson.A{
+				bson.M{"$unwind": bson.M{"path": "$labels", "preserveNullAndEmptyArrays": true}},
+				group("$labels", nil),
+				byCount,
+			},
+		}},
+	}
+
+	cur, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var facets []struct {
+		Summary  []TodoStats        `bson:"summary"`
+		Days     []TodoStatsDay     `bson:"days"`
+		Projects []TodoStatsProject `bson:"projects"`
+		Labels   []TodoStatsLabel   `bson:"labels"`
+	}
+	if err := cur.All(ctx, &facets); err != nil {
+		return nil, err
+	}
+	stats := &TodoStats{}
+	if len(facets) == 0 {
+		return stats, nil
+	}
+	f := facets[0]
+	if len(f.Summary) > 0 {
+		*stats = f.Summary[0]
+	}
+	stats.CompletedByDay, stats.ByProject, stats.ByLabel = f.Days, f.Projects, f.Labels
+	return stats, nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TodoTemplateRepository stores todo templates in the ctx tenant: the user's
+// own templates, or the workspace's when ctx carries one.
+type TodoTemplateRepository interface {
+	Create(ctx context.Context, tmpl *models.TodoTemplate) error
+	// List returns the tenant's templates by name.
+	List(ctx context.Context, userID primitive.ObjectID) ([]*models.TodoTemplate, error)
+	Get(ctx context.Context, userID, templateID primitive.ObjectID) (*models.TodoTemplate, error)
+	// Update applies a $set and returns the updated template.
+	Update(ctx context.Context, userID, templateID primitive.ObjectID, update bson.M) (*models.TodoTemplate, error)
+	Delete(ctx context.Context, userID, templateID primitive.ObjectID) error
+}
+
+type todoTemplateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTodoTemplateRepository(client *mongo.Client) TodoTemplateRepository {
+	return &todoTemplateRepository{collection: client.Database("golang-todo").Collection("todo_templates")}
+}
+
+func (r *todoTemplateRepository) Create(ctx context.Context, tmpl *models.TodoTemplate) error {
+	tmpl.WorkspaceID = stampWorkspace(ctx)
+	_, err := r.collection.InsertOne(ctx, tmpl)
+	return translate(err, "template")
+}
+
+func (r *todoTemplateRepository) List(ctx context.Context, userID primitive.ObjectID) ([]*models.TodoTemplate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.collection.Find(ctx, tenantFilter(ctx, userID), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	templates := []*models.TodoTemplate{}
+	if err := cur.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *todoTemplateRepository) Get(ctx context.Context, userID, templateID primitive.ObjectID) (*models.TodoTemplate, error) {
+	var tmpl models.TodoTemplate
+	if err := r.collection.FindOne(ctx, merge(tenantFilter(ctx, userID), bson.M{"_id": templateID})).Decode(&tmpl); err != nil {
+		return nil, translate(err, "template")
+	}
+	return &tmpl, nil
+}
+
+func (r *todoTemplateRepository) Update(ctx context.Context, userID, templateID primitive.ObjectID, update bson.M) (*models.TodoTemplate, error) {
+	var tmpl models.TodoTemplate
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, merge(tenantFilter(ctx, userID), bson.M{"_id": templateID}), bson.M{"$set": update}, opts).Decode(&tmpl)
+	if err != nil {
+		return nil, translate(err, "template")
+	}
+	return &tmpl, nil
+}
+
+func (r *todoTemplateRepository) Delete(ctx context.Context, userID, templateID primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, merge(tenantFilter(ctx, userID), bson.M{"_id": templateID}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return translate(mongo.ErrNoDocuments, "template")
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 
+	"github.com/group14000/golang-todo/internal/domain"
 	"github.com/group14000/golang-todo/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -14,6 +15,14 @@ type UserRepository interface {
 	FindUserByEmail(ctx context.Context, email string) (*models.User, error)
 	FindUserByID(ctx context.Context, userID primitive.ObjectID) (*models.User, error)
 	UpdatePassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string) error
+	UpdatePreferences(ctx context.Context, userID primitive.ObjectID, update bson.M) error
+	// SetCalendarFeed replaces the user's calendar feed; nil revokes it.
+	SetCalendarFeed(ctx context.Context, userID primitive.ObjectID, feed *models.CalendarFeed) error
+	FindUserByCalendarToken(ctx context.Context, tokenHash string) (*models.User, error)
+	ListDigestSubscribers(ctx context.Context) ([]*models.User, error)
+	// ListAutoArchivers returns verified users whose auto-archive policy is on.
+	ListAutoArchivers(ctx context.Context) ([]*models.User, error)
+	ClaimDigest(ctx context.Context, userID primitive.ObjectID, kind, localDate string) (bool, error)
 }
 
 type userRepository struct {
@@ -28,14 +37,14 @@ func NewUserRepository(client *mongo.Client) UserRepository {
 
 func (r *userRepository) CreateUser(ctx context.Context, user *models.User) error {
 	_, err := r.collection.InsertOne(ctx, user)
-	return err
+	return translate(err, "user")
 }
 
 func (r *userRepository) FindUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
 	if err != nil {
-		return nil, err
+		return nil, translate(err, "user")
 	}
 	return &user, nil
 }
@@ -44,12 +53,94 @@ func (r *userRepository) FindUserByID(ctx context.Context, userID primitive.Obje
 	var user models.User
 	err := r.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
 	if err != nil {
-		return nil, err
+		return nil, translate(err, "user")
 	}
 	return &user, nil
 }
 
 func (r *userRepository) UpdatePassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string) error {
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"password": hashedPassword}})
-	return err
+	return r.updateOne(ctx, userID, bson.M{"$set": bson.M{"password": hashedPassword}})
+}
+
+// UpdatePreferences applies a $set of dotted "preferences.*" keys.
+func (r *userRepository) UpdatePreferences(ctx context.Context, userID primitive.ObjectID, update bson.M) error {
+	return r.updateOne(ctx, userID, bson.M{"$set": update})
+}
+
+func (r *userRepository) SetCalendarFeed(ctx context.Context, userID primitive.ObjectID, feed *models.CalendarFeed) error {
+	if feed == nil {
+		return r.updateOne(ctx, userID, bson.M{"$unset": bson.M{"calendar": ""}})
+	}
+	return r.updateOne(ctx, userID, bson.M{"$set": bson.M{"calendar": feed}})
+}
+
+func (r *userRepository) FindUserByCalendarToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	var user models.User
+	err := r.collection.FindOne(ctx, bson.M{"calendar.token_hash": tokenHash}).Decode(&user)
+	if err != nil {
+		return nil, translate(err, "calendar")
+	}
+	return &user, nil
+}
+
+// updateOne applies update to a single user and reports a missing user as not found.
+func (r *userRepository) updateOne(ctx context.Context, userID primitive.ObjectID, update bson.M) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.NotFound("user not found").WithCode("user_not_found")
+	}
+	return nil
+}
+
+// ListDigestSubscribers returns verified users opted in to at least one digest.
+func (r *userRepository) ListDigestSubscribers(ctx context.Context) ([]*models.User, error) {
+	filter := bson.M{
+		"is_verified": true,
+		"$or": bson.A{
+			bson.M{"preferences.email_notifications.daily_digest": true},
+			bson.M{"preferences.email_notifications.weekly_digest": true},
+		},
+	}
+	return r.findUsers(ctx, filter)
+}
+
+func (r *userRepository) ListAutoArchivers(ctx context.Context) ([]*models.User, error) {
+	// Auto-archive is opt-in, so a missing auto_archive_days means off.
+	return r.findUsers(ctx, bson.M{"is_verified": true, "preferences.auto_archive_days": bson.M{"$gt": 0}})
+}
+
+func (r *userRepository) findUsers(ctx context.Context, filter bson.M) ([]*models.User, error) {
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var users []*models.User
+	for cur.Next(ctx) {
+		var u models.User
+		if err := cur.Decode(&u); err != nil {
+			return nil, err
+		}
+		users = append(users, &u)
+	}
+	return users, cur.Err()
+}
+
+// ClaimDigest atomically records that the digest of the given kind ("daily" or "weekly")
+// is being sent for localDate. It returns false if it was already claimed, so concurrent
+// schedulers never send the same digest twice.
+func (r *userRepository) ClaimDigest(ctx context.Context, userID primitive.ObjectID, kind, localDate string) (bool, error) {
+	field := "digest.last_" + kind
+	res, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": userID, field: bson.M{"$ne": localDate}},
+		bson.M{"$set": bson.M{field: localDate}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
 }
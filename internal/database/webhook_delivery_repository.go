@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebhookDeliveryRepository stores the delivery log, whose pending entries are the retry queue.
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *models.WebhookDelivery) error
+	// Claim leases the oldest pending delivery that is due, so only one worker
+	// attempts it at a time. It returns nil when nothing is due.
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error)
+	// Finish records an attempt's outcome and releases the lease.
+	Finish(ctx context.Context, deliveryID primitive.ObjectID, update bson.M) error
+	// ListByWebhook returns a webhook's deliveries; callers check access to the webhook first.
+	ListByWebhook(ctx context.Context, hookID primitive.ObjectID, page EventPage) ([]*models.WebhookDelivery, error)
+}
+
+type webhookDeliveryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookDeliveryRepository(client *mongo.Client) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{collection: client.Database("golang-todo").Collection("webhook_deliveries")}
+}
+
+func (r *webhookDeliveryRepository) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := r.collection.InsertOne(ctx, delivery)
+	return err
+}
+
+func (r *webhookDeliveryRepository) Claim(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	filter := bson.M{
+		"status":          models.WebhookDeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"locked_until": nil},
+			bson.M{"locked_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"locked_until": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var d models.WebhookDelivery
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *webhookDeliveryRepository) Finish(ctx context.Context, deliveryID primitive.ObjectID, update bson.M) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": deliveryID}, bson.M{
+		"$set":   update,
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"locked_until": ""},
+	})
+	return err
+}
+
+func (r *webhookDeliveryRepository) ListByWebhook(ctx context.Context, hookID primitive.ObjectID, page EventPage) ([]*models.WebhookDelivery, error) {
+	filter := bson.M{"webhook_id": hookID}
+	if !page.Before.IsZero() {
+		filter["_id"] = bson.M{"$lt": page.Before}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+	if page.Limit > 0 {
+		opts.SetLimit(page.Limit)
+	}
+
+	cur, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	deliveries := []*models.WebhookDelivery{}
+	if err := cur.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
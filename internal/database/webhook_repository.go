@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebhookRepository stores webhooks. Reads and writes are scoped to the ctx
+// tenant (see tenantFilter), so a workspace's webhooks are shared by its members.
+type WebhookRepository interface {
+	Create(ctx context.Context, hook *models.Webhook) error
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.Webhook, error)
+	GetByID(ctx context.Context, userID, hookID primitive.ObjectID) (*models.Webhook, error)
+	// FindByID loads a webhook regardless of tenant, for the delivery workers.
+	FindByID(ctx context.Context, hookID primitive.ObjectID) (*models.Webhook, error)
+	// Update applies a $set and returns the updated webhook.
+	Update(ctx context.Context, userID, hookID primitive.ObjectID, update bson.M) (*models.Webhook, error)
+	Delete(ctx context.Context, userID, hookID primitive.ObjectID) error
+	// ListActive returns the enabled webhooks of a workspace, or the user's
+	// personal ones when workspaceID is nil.
+	ListActive(ctx context.Context, userID primitive.ObjectID, workspaceID *primitive.ObjectID) ([]*models.Webhook, error)
+	// RecordAttempt resets the failure streak on success, or extends it and
+	// disables the webhook once it reaches maxFailures. It reports whether this
+	// call disabled the webhook.
+	RecordAttempt(ctx context.Context, hookID primitive.ObjectID, success bool, maxFailures int) (bool, error)
+}
+
+type webhookRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookRepository(client *mongo.Client) WebhookRepository {
+	return &webhookRepository{collection: client.Database("golang-todo").Collection("webhooks")}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, hook *models.Webhook) error {
+	hook.WorkspaceID = stampWorkspace(ctx)
+	_, err := r.collection.InsertOne(ctx, hook)
+	return translate(err, "webhook")
+}
+
+func (r *webhookRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.Webhook, error) {
+	return r.find(ctx, tenantFilter(ctx, userID))
+}
+
+func (r *webhookRepository) ListActive(ctx context.Context, userID primitive.ObjectID, workspaceID *primitive.ObjectID) ([]*models.Webhook, error) {
+	if workspaceID != nil {
+		return r.find(ctx, bson.M{"workspace_id": *workspaceID, "active": true})
+	}
+	return r.find(ctx, bson.M{"user_id": userID, "workspace_id": nil, "active": true})
+}
+
+func (r *webhookRepository) find(ctx context.Context, filter bson.M) ([]*models.Webhook, error) {
+	cur, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	hooks := []*models.Webhook{}
+	if err := cur.All(ctx, &hooks); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, userID, hookID primitive.ObjectID) (*models.Webhook, error) {
+	var hook models.Webhook
+	if err := r.collection.FindOne(ctx, merge(tenantFilter(ctx, userID), bson.M{"_id": hookID})).Decode(&hook); err != nil {
+		return nil, translate(err, "webhook")
+	}
+	return &hook, nil
+}
+
+func (r *webhookRepository) FindByID(ctx context.Context, hookID primitive.ObjectID) (*models.Webhook, error) {
+	var hook models.Webhook
+	if err := r.collection.FindOne(ctx, bson.M{"_id": hookID}).Decode(&hook); err != nil {
+		return nil, translate(err, "webhook")
+	}
+	return &hook, nil
+}
+
+func (r *webhookRepository) Update(ctx context.Context, userID, hookID primitive.ObjectID, update bson.M) (*models.Webhook, error) {
+	var hook models.Webhook
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, merge(tenantFilter(ctx, userID), bson.M{"_id": hookID}), bson.M{"$set": update}, opts).Decode(&hook)
+	if err != nil {
+		return nil, translate(err, "webhook")
+	}
+	return &hook, nil
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, userID, hookID primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, merge(tenantFilter(ctx, userID), bson.M{"_id": hookID}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return translate(mongo.ErrNoDocuments, "webhook")
+	}
+	return nil
+}
+
+func (r *webhookRepository) RecordAttempt(ctx context.Context, hookID primitive.ObjectID, success bool, maxFailures int) (bool, error) {
+	if success {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": hookID}, bson.M{"$set": bson.M{"consecutive_failures": 0}})
+		return false, err
+	}
+
+	var hook models.Webhook
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": hookID}, bson.M{"$inc": bson.M{"consecutive_failures": 1}}, opts).Decode(&hook)
+	if err != nil {
+		return false, translate(err, "webhook")
+	}
+	if hook.ConsecutiveFailures < maxFailures {
+		return false, nil
+	}
+	// Only the attempt that flips active reports the disable.
+	now := time.Now()
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": hookID, "active": true}, bson.M{"$set": bson.M{
+		"active":          false,
+		"disabled_at":     now,
+		"disabled_reason": "too many consecutive delivery failures",
+		"updated_at":      now,
+	}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
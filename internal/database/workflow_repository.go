@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WorkflowRepository stores the board workflows of projects, one per project
+// in a tenant. Projects without one use models.DefaultWorkflow.
+type WorkflowRepository interface {
+	Get(ctx context.Context, userID, projectID primitive.ObjectID) (*models.Workflow, error)
+	// Put creates or replaces the workflow of w.ProjectID.
+	Put(ctx context.Context, w *models.Workflow) error
+	Delete(ctx context.Context, userID, projectID primitive.ObjectID) error
+}
+
+type workflowRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWorkflowRepository(client *mongo.Client) WorkflowRepository {
+	return &workflowRepository{collection: client.Database("golang-todo").Collection("workflows")}
+}
+
+func (r *workflowRepository) Get(ctx context.Context, userID, projectID primitive.ObjectID) (*models.Workflow, error) {
+	var w models.Workflow
+	err := r.collection.FindOne(ctx, merge(tenantFilter(ctx, userID), bson.M{"project_id": projectID})).Decode(&w)
+	if err != nil {
+		return nil, translate(err, "workflow")
+	}
+	return &w, nil
+}
+
+func (r *workflowRepository) Put(ctx context.Context, w *models.Workflow) error {
+	w.WorkspaceID = stampWorkspace(ctx)
+	filter := merge(tenantFilter(ctx, w.UserID), bson.M{"project_id": w.ProjectID})
+	// Without an _id the replacement keeps the existing one, and an upsert gets a new one.
+	w.ID = primitive.NilObjectID
+	_, err := r.collection.ReplaceOne(ctx, filter, w, options.Replace().SetUpsert(true))
+	return translate(err, "workflow")
+}
+
+func (r *workflowRepository) Delete(ctx context.Context, userID, projectID primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, merge(tenantFilter(ctx, userID), bson.M{"project_id": projectID}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return translate(mongo.ErrNoDocuments, "workflow")
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WorkspaceRepository interface {
+	Create(ctx context.Context, ws *models.Workspace) error
+	// ListByMember returns the workspaces userID belongs to.
+	ListByMember(ctx context.Context, userID primitive.ObjectID) ([]*models.Workspace, error)
+	// GetForMember returns the workspace only if userID is a member.
+	GetForMember(ctx context.Context, workspaceID, userID primitive.ObjectID) (*models.Workspace, error)
+	// ListInvited returns the workspaces with a pending invitation for email.
+	ListInvited(ctx context.Context, email string) ([]*models.Workspace, error)
+	Rename(ctx context.Context, workspaceID primitive.ObjectID, name string) (*models.Workspace, error)
+	// AddInvitation fails with a conflict if email is already invited.
+	AddInvitation(ctx context.Context, workspaceID primitive.ObjectID, inv models.WorkspaceInvitation) (*models.Workspace, error)
+	RemoveInvitation(ctx context.Context, workspaceID, invitationID primitive.ObjectID) (*models.Workspace, error)
+	// AcceptInvitation turns a pending invitation into a membership in one write.
+	AcceptInvitation(ctx context.Context, workspaceID, invitationID primitive.ObjectID, member models.WorkspaceMember) (*models.Workspace, error)
+	SetMemberRole(ctx context.Context, workspaceID, userID primitive.ObjectID, role models.WorkspaceRole) (*models.Workspace, error)
+	RemoveMember(ctx context.Context, workspaceID, userID primitive.ObjectID) (*models.Workspace, error)
+}
+
+type workspaceRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWorkspaceRepository(client *mongo.Client) WorkspaceRepository {
+	return &workspaceRepository{collection: client.Database("golang-todo").Collection("workspaces")}
+}
+
+func (r *workspaceRepository) Create(ctx context.Context, ws *models.Workspace) error {
+	_, err := r.collection.InsertOne(ctx, ws)
+	return translate(err, "workspace")
+}
+
+func (r *workspaceRepository) ListByMember(ctx context.Context, userID primitive.ObjectID) ([]*models.Workspace, error) {
+	return r.find(ctx, bson.M{"members.user_id": userID})
+}
+
+func (r *workspaceRepository) ListInvited(ctx context.Context, email string) ([]*models.Workspace, error) {
+	return r.find(ctx, bson.M{"invitations.email": email})
+}
+
+func (r *workspaceRepository) find(ctx context.Context, filter bson.M) ([]*models.Workspace, error) {
+	cur, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	workspaces := []*models.Workspace{}
+	if err := cur.All(ctx, &workspaces); err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+func (r *workspaceRepository) GetForMember(ctx context.Context, workspaceID, userID primitive.ObjectID) (*models.Workspace, error) {
+	var ws models.Workspace
+	if err := r.collection.FindOne(ctx, bson.M{"_id": workspaceID, "members.user_id": userID}).Decode(&ws); err != nil {
+		return nil, translate(err, "workspace")
+	}
+	return &ws, nil
+}
+
+func (r *workspaceRepository) Rename(ctx context.Context, workspaceID primitive.ObjectID, name string) (*models.Workspace, error) {
+	return r.update(ctx, bson.M{"_id": workspaceID}, bson.M{"$set": bson.M{"name": name}}, "workspace")
+}
+
+func (r *workspaceRepository) AddInvitation(ctx context.Context, workspaceID primitive.ObjectID, inv models.WorkspaceInvitation) (*models.Workspace, error) {
+	filter := bson.M{"_id": workspaceID, "invitations.email": bson.M{"$ne": inv.Email}}
+	ws, err := r.update(ctx, filter, bson.M{"$push": bson.M{"invitations": inv}}, "workspace")
+	if domain.Code(err) == "workspace_not_found" {
+		if n, cerr := r.collection.CountDocuments(ctx, bson.M{"_id": workspaceID}); cerr == nil && n > 0 {
+			return nil, domain.Conflict("%s is already invited", inv.Email).WithCode("invitation_exists")
+		}
+	}
+	return ws, err
+}
+
+func (r *workspaceRepository) RemoveInvitation(ctx context.Context, workspaceID, invitationID primitive.ObjectID) (*models.Workspace, error) {
+	filter := bson.M{"_id": workspaceID, "invitations._id": invitationID}
+	return r.update(ctx, filter, bson.M{"$pull": bson.M{"invitations": bson.M{"_id": invitationID}}}, "invitation")
+}
+
+func (r *workspaceRepository) AcceptInvitation(ctx context.Context, workspaceID, invitationID primitive.ObjectID, member models.WorkspaceMember) (*models.Workspace, error) {
+	filter := bson.M{"_id": workspaceID, "invitations._id": invitationID, "members.user_id": bson.M{"$ne": member.UserID}}
+	update := bson.M{
+		"$pull": bson.M{"invitations": bson.M{"_id": invitationID}},
+		"$push": bson.M{"members": member},
+	}
+	return r.update(ctx, filter, update, "invitation")
+}
+
+func (r *workspaceRepository) SetMemberRole(ctx context.Context, workspaceID, userID primitive.ObjectID, role models.WorkspaceRole) (*models.Workspace, error) {
+	filter := bson.M{"_id": workspaceID, "members.user_id": userID}
+	return r.update(ctx, filter, bson.M{"$set": bson.M{"members.$.role": role}}, "member")
+}
+
+func (r *workspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID primitive.ObjectID) (*models.Workspace, error) {
+	filter := bson.M{"_id": workspaceID, "members.user_id": userID}
+	return r.update(ctx, filter, bson.M{"$pull": bson.M{"members": bson.M{"user_id": userID}}}, "member")
+}
+
+// update applies update, stamping updated_at, and returns the workspace after it.
+// what names the entity in the not-found error when filter matches nothing.
+func (r *workspaceRepository) update(ctx context.Context, filter, update bson.M, what string) (*models.Workspace, error) {
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updated_at"] = time.Now()
+
+	var ws models.Workspace
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ws); err != nil {
+		return nil, translate(err, what)
+	}
+	return &ws, nil
+}
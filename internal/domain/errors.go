@@ -0,0 +1,146 @@
+// Package domain holds error kinds shared by repositories, services and the HTTP layer.
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel error kinds. Test with errors.Is; the error middleware maps them to HTTP statuses.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrValidation         = errors.New("validation failed")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrUpstream           = errors.New("upstream failure")
+	ErrUnavailable        = errors.New("unavailable")
+)
+
+// FieldError describes one invalid input field.
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Code    string `json:"code" example:"email"`
+	Message string `json:"message" example:"must be a valid email address"`
+}
+
+// Error pairs an error kind with a message that is safe to show to clients.
+// Code is an optional stable machine-readable identifier (e.g. "todo_not_found");
+// when empty the kind's default code is used. The optional cause is kept for
+// logging and errors.Is/As but never exposed.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Fields  []FieldError
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{e.Kind, e.Cause}
+	}
+	return []error{e.Kind}
+}
+
+// WithCode sets the machine-readable code.
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
+func newError(kind error, format string, args ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func NotFound(format string, args ...any) *Error {
+	return newError(ErrNotFound, format, args...)
+}
+
+func Conflict(format string, args ...any) *Error {
+	return newError(ErrConflict, format, args...)
+}
+
+func Validation(format string, args ...any) *Error {
+	return newError(ErrValidation, format, args...)
+}
+
+func PreconditionFailed(format string, args ...any) *Error {
+	return newError(ErrPreconditionFailed, format, args...)
+}
+
+func Unauthorized(format string, args ...any) *Error {
+	return newError(ErrUnauthorized, format, args...)
+}
+
+func Forbidden(format string, args ...any) *Error {
+	return newError(ErrForbidden, format, args...)
+}
+
+func Unavailable(format string, args ...any) *Error {
+	return newError(ErrUnavailable, format, args...)
+}
+
+// InvalidFields builds a validation error carrying per-field details.
+func InvalidFields(fields []FieldError) *Error {
+	return &Error{Kind: ErrValidation, Code: "validation_failed", Message: "request validation failed", Fields: fields}
+}
+
+// Wrap attaches a kind and client message to an underlying cause.
+func Wrap(kind error, cause error, message string) *Error {
+	return &Error{Kind: kind, Message: message, Cause: cause}
+}
+
+// Message returns the client-safe message for err, or "" if it carries none.
+func Message(err error) string {
+	var de *Error
+	if errors.As(err, &de) {
+		return de.Message
+	}
+	return ""
+}
+
+// Code returns the machine-readable code for err: the explicit code if set,
+// otherwise a default derived from its kind.
+func Code(err error) string {
+	var de *Error
+	if errors.As(err, &de) && de.Code != "" {
+		return de.Code
+	}
+	switch {
+	case errors.Is(err, ErrValidation):
+		return "validation_failed"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrConflict):
+		return "conflict"
+	case errors.Is(err, ErrPreconditionFailed):
+		return "precondition_failed"
+	case errors.Is(err, ErrUpstream):
+		return "upstream_error"
+	case errors.Is(err, ErrUnavailable):
+		return "service_unavailable"
+	}
+	return "internal_error"
+}
+
+// Fields returns per-field validation details carried by err, if any.
+func Fields(err error) []FieldError {
+	var de *Error
+	if errors.As(err, &de) {
+		return de.Fields
+	}
+	return nil
+}
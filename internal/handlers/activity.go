@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ActivityHandler struct {
+	service *services.ActivityService
+}
+
+func NewActivityHandler(s *services.ActivityService) *ActivityHandler {
+	return &ActivityHandler{service: s}
+}
+
+// @Summary      Todo history
+// @Description  Lists audit events (created, updated, completed, reopened, deleted, restored, commented, archived, unarchived) for a todo, newest first, with field-level before/after values. Collaborators with viewer access see the full history. Page with before=<id of the last event received>.
+// @Tags         activity
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      string  true   "Todo ID"
+// @Param        before  query     string  false  "Return events older than this event ID"
+// @Param        limit   query     int     false  "Max events (default 50, max 200)"
+// @Success      200     {array}   models.TodoEvent
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      404     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /todos/{id}/history [get]
+func (h *ActivityHandler) History(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	before, limit, err := parseEventPage(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	events, err := h.service.TodoHistory(c.Request.Context(), uid, tid, before, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// @Summary      Activity feed
+// @Description  Lists audit events across all of the user's todos, newest first. Page with before=<id of the last event received>.
+// @Tags         activity
+// @Produce      json
+// @Security     BearerAuth
+// @Param        before  query     string  false  "Return events older than this event ID"
+// @Param        limit   query     int     false  "Max events (default 50, max 200)"
+// @Success      200     {array}   models.TodoEvent
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /activity [get]
+func (h *ActivityHandler) Feed(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	before, limit, err := parseEventPage(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	events, err := h.service.Feed(c.Request.Context(), uid, before, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+func parseEventPage(c *gin.Context) (primitive.ObjectID, int64, error) {
+	var before primitive.ObjectID
+	if b := c.Query("before"); b != "" {
+		id, err := primitive.ObjectIDFromHex(b)
+		if err != nil {
+			return before, 0, invalidField("before", "objectid", "must be an event ID")
+		}
+		before = id
+	}
+	var limit int64
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.ParseInt(l, 10, 64)
+		if err != nil || n < 1 {
+			return before, 0, invalidField("limit", "min", "must be a positive integer")
+		}
+		limit = n
+	}
+	return before, limit, nil
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type AdminHandler struct {
+	emailService *services.EmailService
+}
+
+func NewAdminHandler(emailService *services.EmailService) *AdminHandler {
+	return &AdminHandler{emailService: emailService}
+}
+
+// @Summary      List email templates
+// @Description  Lists available email template names and locales (admin only).
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  EmailTemplateListDTO
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /admin/email-templates [get]
+func (h *AdminHandler) ListEmailTemplates(c *gin.Context) {
+	t := h.emailService.Templates()
+	c.JSON(http.StatusOK, EmailTemplateListDTO{Templates: t.Names(), Locales: t.Locales()})
+}
+
+// @Summary      Preview email template
+// @Description  Renders an email template with sample data (admin only). format=html (default) or text returns the body directly; format=json returns subject, text and html together.
+// @Tags         admin
+// @Produce      html,plain,json
+// @Security     BearerAuth
+// @Param        name    path      string  true   "Template name (e.g. otp_signup)"
+// @Param        locale  query     string  false  "Locale or Accept-Language value (e.g. es)"
+// @Param        format  query     string  false  "html | text | json"  Enums(html, text, json)
+// @Success      200     {object}  services.RenderedEmail
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      403     {object}  ErrorResponse
+// @Failure      404     {object}  ErrorResponse
+// @Router       /admin/email-templates/{name}/preview [get]
+func (h *AdminHandler) PreviewEmailTemplate(c *gin.Context) {
+	locale := c.Query("locale")
+	if locale == "" {
+		locale = c.GetHeader("Accept-Language")
+	}
+
+	rendered, err := h.emailService.Preview(c.Param("name"), locale)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	switch c.DefaultQuery("format", "html") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(rendered.HTML))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(rendered.Text))
+	case "json":
+		c.JSON(http.StatusOK, rendered)
+	default:
+		c.Error(invalidField("format", "oneof", "must be one of: html, text, json"))
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"io"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/middleware"
 	"github.com/group14000/golang-todo/internal/services"
 )
 
@@ -44,8 +47,8 @@ func (h *AIHandler) Chat(c *gin.Context) {
 	start := time.Now()
 	userID := c.GetString("user_id")
 	var req AIChatRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -53,7 +56,7 @@ func (h *AIHandler) Chat(c *gin.Context) {
 	messages := req.Messages
 	if len(messages) == 0 {
 		if req.Prompt == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "prompt or messages required"})
+			c.Error(domain.Validation("prompt or messages required").WithCode("empty_prompt"))
 			return
 		}
 		messages = []services.AIMessage{{Role: "user", Content: req.Prompt}}
@@ -72,7 +75,7 @@ func (h *AIHandler) Chat(c *gin.Context) {
 		// Streaming path
 		streamCh, err := h.service.ChatStream(c.Request.Context(), messages)
 		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			c.Error(aiError(err))
 			return
 		}
 		c.Header("Content-Type", "text/event-stream")
@@ -84,7 +87,8 @@ func (h *AIHandler) Chat(c *gin.Context) {
 				return false
 			}
 			if chunk.Err != nil {
-				c.SSEvent("error", gin.H{"error": chunk.Err.Error()})
+				log.Printf("[%s] AI stream: %v", middleware.RequestIDFrom(c), chunk.Err)
+				c.SSEvent("error", gin.H{"code": "ai_upstream_error", "detail": "AI provider stream failed"})
 				return false
 			}
 			c.SSEvent("token", gin.H{"data": chunk.Text})
@@ -96,7 +100,8 @@ func (h *AIHandler) Chat(c *gin.Context) {
 	answer, err := h.service.Chat(c.Request.Context(), messages)
 	latency := time.Since(start)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "latency_ms": latency.Milliseconds()})
+		c.Header("X-AI-Latency-MS", intToString(int(latency.Milliseconds())))
+		c.Error(aiError(err))
 		return
 	}
 
@@ -110,4 +115,12 @@ func (h *AIHandler) Chat(c *gin.Context) {
 	c.JSON(http.StatusOK, AIChatResponse{Answer: answer})
 }
 
+// aiError keeps domain errors (e.g. not configured) and reports anything else as an upstream failure.
+func aiError(err error) error {
+	if domain.Message(err) != "" {
+		return err
+	}
+	return domain.Wrap(domain.ErrUpstream, err, "AI provider request failed").WithCode("ai_upstream_error")
+}
+
 func intToString(i int) string { return strconv.FormatInt(int64(i), 10) }
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/models"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type AppPasswordHandler struct {
+	service *services.AppPasswordService
+}
+
+func NewAppPasswordHandler(s *services.AppPasswordService) *AppPasswordHandler {
+	return &AppPasswordHandler{service: s}
+}
+
+type CreateAppPasswordRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// AppPasswordCreatedResponse is returned once on creation; the token is not shown again.
+type AppPasswordCreatedResponse struct {
+	*models.AppPassword
+	Token string `json:"token" example:"tdp_3f9c2a..."`
+}
+
+// @Summary      Create app password
+// @Description  Issues a personal token for clients that cannot use the normal login, such as CalDAV apps (use it as the password with your email as user name, or as a Bearer token on /dav). The token is only returned here.
+// @Tags         profile
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload  body      CreateAppPasswordRequestDTO  true  "App password"
+// @Success      201      {object}  AppPasswordCreatedResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /profile/app-passwords [post]
+func (h *AppPasswordHandler) Create(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req CreateAppPasswordRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	p, token, err := h.service.Create(c.Request.Context(), uid, req.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, AppPasswordCreatedResponse{AppPassword: p, Token: token})
+}
+
+// @Summary      List app passwords
+// @Description  Lists the authenticated user's app passwords with their prefix and last use; tokens are never shown again.
+// @Tags         profile
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.AppPassword
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /profile/app-passwords [get]
+func (h *AppPasswordHandler) List(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	passwords, err := h.service.List(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, passwords)
+}
+
+// @Summary      Revoke app password
+// @Description  Deletes an app password; clients using it are signed out immediately.
+// @Tags         profile
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "App password ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /profile/app-passwords/{id} [delete]
+func (h *AppPasswordHandler) Delete(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	id, err := pathObjectID(c, "id", "app password")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), uid, id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "revoked"})
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type AttachmentHandler struct {
+	service *services.AttachmentService
+}
+
+func NewAttachmentHandler(s *services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{service: s}
+}
+
+// @Summary      Upload attachment
+// @Description  Attaches a file (multipart field "file") to a todo. The type is sniffed from the content and must be allowed by ATTACHMENT_TYPES; the size is limited by ATTACHMENT_MAX_MB (25 MB by default). Collaborators need the editor role.
+// @Tags         attachments
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string  true  "Todo ID"
+// @Param        file  formData  file    true  "File to attach"
+// @Success      201   {object}  models.Attachment
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      404   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Failure      503   {object}  ErrorResponse
+// @Router       /todos/{id}/attachments [post]
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxBytes()+64<<10)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.Error(h.service.TooLarge())
+		default:
+			c.Error(invalidField("file", "required", "is required"))
+		}
+		return
+	}
+	defer file.Close()
+
+	att, err := h.service.Upload(c.Request.Context(), uid, tid, services.AttachmentUpload{
+		Filename: header.Filename,
+		Size:     header.Size,
+		Body:     file,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, att)
+}
+
+// @Summary      List attachments
+// @Description  Lists a todo's attachments, oldest first.
+// @Tags         attachments
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Todo ID"
+// @Success      200  {array}   models.Attachment
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /todos/{id}/attachments [get]
+func (h *AttachmentHandler) List(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	attachments, err := h.service.List(c.Request.Context(), uid, tid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, attachments)
+}
+
+// @Summary      Download attachment
+// @Description  Streams an attachment. Supports Range and If-Range for partial and resumed downloads, and If-None-Match against the ETag (the content's SHA-256). Images, PDFs, audio and video are served inline, everything else as a download.
+// @Tags         attachments
+// @Produce      application/octet-stream
+// @Security     BearerAuth
+// @Param        id            path    string  true   "Todo ID"
+// @Param        attachmentId  path    string  true   "Attachment ID"
+// @Param        Range         header  string  false  "Byte range, e.g. bytes=0-1023"
+// @Success      200  {file}    file
+// @Success      206  {file}    file
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      416  {string}  string
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Router       /todos/{id}/attachments/{attachmentId} [get]
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	aid, err := pathObjectID(c, "attachmentId", "attachment")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	att, content, err := h.service.Open(c.Request.Context(), uid, tid, aid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer content.Close()
+
+	disposition := "attachment"
+	if inlineType(att.ContentType) {
+		disposition = "inline"
+	}
+	if v := mime.FormatMediaType(disposition, map[string]string{"filename": att.Filename}); v != "" {
+		disposition = v
+	}
+	c.Header("Content-Type", att.ContentType)
+	c.Header("Content-Disposition", disposition)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private")
+	c.Header("ETag", `"`+att.SHA256+`"`)
+	http.ServeContent(c.Writer, c.Request, att.Filename, att.CreatedAt, content)
+}
+
+// @Summary      Delete attachment
+// @Description  Deletes an attachment and its stored file. Collaborators need the editor role.
+// @Tags         attachments
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id            path      string  true  "Todo ID"
+// @Param        attachmentId  path      string  true  "Attachment ID"
+// @Success      200           {object}  map[string]string
+// @Failure      400           {object}  ErrorResponse
+// @Failure      401           {object}  ErrorResponse
+// @Failure      403           {object}  ErrorResponse
+// @Failure      404           {object}  ErrorResponse
+// @Failure      500           {object}  ErrorResponse
+// @Router       /todos/{id}/attachments/{attachmentId} [delete]
+func (h *AttachmentHandler) Delete(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	aid, err := pathObjectID(c, "attachmentId", "attachment")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), uid, tid, aid); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// inlineType reports whether browsers may display a file in place; text and
+// anything that could run script in the API's origin is always a download.
+func inlineType(contentType string) bool {
+	for _, prefix := range []string{"image/", "audio/", "video/", "application/pdf"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
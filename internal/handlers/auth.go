@@ -4,7 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
+	"github.com/group14000/golang-todo/internal/domain"
 	"github.com/group14000/golang-todo/internal/models"
 	"github.com/group14000/golang-todo/internal/services"
 )
@@ -53,26 +53,23 @@ type ResetPasswordRequest struct {
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        payload  body      SignupRequestDTO  true  "Signup request"
+// @Param        payload          body      SignupRequestDTO  true   "Signup request"
+// @Param        Accept-Language  header    string            false  "Preferred email language (e.g. es, en-US)"
 // @Success      200      {object}  map[string]string
 // @Failure      400      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
 // @Router       /signup [post]
 func (h *AuthHandler) SignUp(c *gin.Context) {
 	var req SignupRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	validate := validator.New()
-	if err := validate.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err := h.service.SignUp(c.Request.Context(), req.Name, req.Email, req.Password)
+	err := h.service.SignUp(c.Request.Context(), req.Name, req.Email, req.Password, c.GetHeader("Accept-Language"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -88,23 +85,19 @@ func (h *AuthHandler) SignUp(c *gin.Context) {
 // @Success      200      {object}  services.LoginResponse
 // @Failure      400      {object}  ErrorResponse
 // @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
 // @Router       /login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	validate := validator.New()
-	if err := validate.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
 	tokens, err := h.service.Login(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		c.Error(err)
 		return
 	}
 
@@ -116,26 +109,23 @@ func (h *AuthHandler) Login(c *gin.Context) {
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        payload  body      VerifyOTPRequestDTO  true  "Verify OTP"
+// @Param        payload          body      VerifyOTPRequestDTO  true   "Verify OTP"
+// @Param        Accept-Language  header    string               false  "Initial locale preference (e.g. es, en-US)"
 // @Success      201      {object}  map[string]string
 // @Failure      400      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
 // @Router       /verify-otp [post]
 func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 	var req VerifyOTPRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	validate := validator.New()
-	if err := validate.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err := h.service.VerifyOTP(c.Request.Context(), req.Email, req.OTP, req.Name, req.Password)
+	err := h.service.VerifyOTP(c.Request.Context(), req.Email, req.OTP, req.Name, req.Password, c.GetHeader("Accept-Language"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -147,26 +137,23 @@ func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        payload  body      ForgotPasswordRequestDTO  true  "Forgot password"
+// @Param        payload          body      ForgotPasswordRequestDTO  true   "Forgot password"
+// @Param        Accept-Language  header    string                    false  "Preferred email language (e.g. es, en-US)"
 // @Success      200      {object}  map[string]string
 // @Failure      400      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
 // @Router       /forgot-password [post]
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	var req ForgotPasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	validate := validator.New()
-	if err := validate.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	err := h.service.ForgotPassword(c.Request.Context(), req.Email)
+	err := h.service.ForgotPassword(c.Request.Context(), req.Email, c.GetHeader("Accept-Language"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -181,23 +168,18 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 // @Param        payload  body      ResetPasswordRequestDTO  true  "Reset password"
 // @Success      200      {object}  map[string]string
 // @Failure      400      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
 // @Router       /reset-password [post]
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	var req ResetPasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	validate := validator.New()
-	if err := validate.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
 	err := h.service.ResetPassword(c.Request.Context(), req.Email, req.OTP, req.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -212,17 +194,18 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 // @Success      200  {object}  models.User
 // @Failure      401  {object}  ErrorResponse
 // @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
 // @Router       /profile [get]
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		c.Error(domain.Unauthorized("user not authenticated").WithCode("invalid_token"))
 		return
 	}
 
 	user, err := h.service.GetProfile(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		c.Error(err)
 		return
 	}
 
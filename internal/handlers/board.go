@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/models"
+	"github.com/group14000/golang-todo/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SetWorkflowRequest struct {
+	Columns     []WorkflowColumnRequest `json:"columns" validate:"required,min=2,max=20,dive"`
+	Transitions map[string][]string     `json:"transitions"`
+}
+
+type WorkflowColumnRequest struct {
+	Key      string `json:"key" validate:"required,max=32"`
+	Name     string `json:"name" validate:"required,max=50"`
+	WIPLimit int    `json:"wip_limit" validate:"min=0"`
+	Done     bool   `json:"done"`
+}
+
+// @Summary      Kanban board
+// @Description  Returns todos grouped into the columns of their project's workflow, in the user's default sort order. Without project_id the board shows todos that have no project, using the default workflow (backlog, in_progress, review, done).
+// @Tags         boards
+// @Produce      json
+// @Security     BearerAuth
+// @Param        project_id  query     string  false  "Project ID"
+// @Success      200         {object}  services.Board
+// @Failure      400         {object}  ErrorResponse
+// @Failure      401         {object}  ErrorResponse
+// @Failure      500         {object}  ErrorResponse
+// @Router       /todos/board [get]
+func (h *TodoHandler) Board(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var projectID *primitive.ObjectID
+	if pid := c.Query("project_id"); pid != "" {
+		id, err := primitive.ObjectIDFromHex(pid)
+		if err != nil {
+			c.Error(invalidField("project_id", "objectid", "must be a project ID"))
+			return
+		}
+		projectID = &id
+	}
+
+	board, err := h.service.Board(c.Request.Context(), uid, projectID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, board)
+}
+
+// @Summary      Get project workflow
+// @Description  Returns the status columns, WIP limits and transitions of a project's board; projects that have not configured one get the default workflow.
+// @Tags         boards
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Project ID"
+// @Success      200  {object}  models.Workflow
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /projects/{id}/workflow [get]
+func (h *TodoHandler) Workflow(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	pid, err := pathObjectID(c, "id", "project")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	workflow, err := h.service.Workflow(c.Request.Context(), uid, pid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, workflow)
+}
+
+// @Summary      Set project workflow
+// @Description  Replaces a project's board columns (2-20, at least one open and one done column). wip_limit 0 means unlimited. transitions maps a column key to the columns its todos may move to; columns without an entry allow any move. Todos in removed columns, or in columns whose done flag changed, move to the first column that matches their completion. In a workspace it needs the owner or admin role.
+// @Tags         boards
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                 true  "Project ID"
+// @Param        payload  body      SetWorkflowRequestDTO  true  "Workflow"
+// @Success      200      {object}  models.Workflow
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /projects/{id}/workflow [put]
+func (h *TodoHandler) SetWorkflow(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	pid, err := pathObjectID(c, "id", "project")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req SetWorkflowRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	in := services.WorkflowInput{Columns: make([]models.WorkflowColumn, len(req.Columns)), Transitions: req.Transitions}
+	for i, col := range req.Columns {
+		in.Columns[i] = models.WorkflowColumn{Key: col.Key, Name: col.Name, WIPLimit: col.WIPLimit, Done: col.Done}
+	}
+
+	workflow, err := h.service.SetWorkflow(c.Request.Context(), uid, pid, in)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, workflow)
+}
+
+// @Summary      Reset project workflow
+// @Description  Deletes a project's workflow so it uses the default one again, moving todos into matching default columns. In a workspace it needs the owner or admin role.
+// @Tags         boards
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Project ID"
+// @Success      200  {object}  models.Workflow
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /projects/{id}/workflow [delete]
+func (h *TodoHandler) ResetWorkflow(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	pid, err := pathObjectID(c, "id", "project")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	workflow, err := h.service.ResetWorkflow(c.Request.Context(), uid, pid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, workflow)
+}
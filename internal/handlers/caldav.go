@@ -0,0 +1,678 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"github.com/group14000/golang-todo/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CalDAV (RFC 4791) with sync-collection (RFC 6578). The tree is fixed:
+//
+//	/dav/                     root
+//	/dav/principals/me/       the authenticated user
+//	/dav/calendars/           calendar home
+//	/dav/calendars/todos/     the task collection (VTODO only)
+//	/dav/calendars/todos/x    one todo
+const (
+	davPrefix     = "/dav"
+	davPrincipal  = davPrefix + "/principals/me/"
+	davHome       = davPrefix + "/calendars/"
+	davCollection = davHome + "todos/"
+	davMaxBody    = 1 << 20
+
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+)
+
+var davAllow = strings.Join([]string{"OPTIONS", "PROPFIND", "REPORT", "GET", "HEAD", "PUT", "DELETE"}, ", ")
+
+type davKind int
+
+const (
+	davRootKind davKind = iota
+	davPrincipalKind
+	davHomeKind
+	davCollectionKind
+	davResourceKind
+)
+
+type CalDAVHandler struct {
+	service *services.CalDAVService
+}
+
+func NewCalDAVHandler(s *services.CalDAVService) *CalDAVHandler {
+	return &CalDAVHandler{service: s}
+}
+
+// WellKnown points CalDAV clients at the DAV root (RFC 6764).
+func (h *CalDAVHandler) WellKnown(c *gin.Context) {
+	c.Redirect(http.StatusMovedPermanently, davPrefix+"/")
+}
+
+// Options advertises CalDAV support; it does not require authentication.
+func (h *CalDAVHandler) Options(c *gin.Context) {
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Header("Allow", davAllow)
+	c.Status(http.StatusOK)
+}
+
+// Serve dispatches an authenticated CalDAV request on /dav/*path.
+func (h *CalDAVHandler) Serve(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	kind, name, ok := davTarget(c.Param("path"))
+	if !ok {
+		c.Error(domain.NotFound("resource not found").WithCode("resource_not_found"))
+		return
+	}
+	d := &davRequest{c: c, h: h, uid: uid}
+
+	switch c.Request.Method {
+	case "PROPFIND":
+		d.propfind(kind, name)
+	case "REPORT":
+		if kind != davCollectionKind {
+			davError(c, http.StatusForbidden, "<D:supported-report/>")
+			return
+		}
+		d.report()
+	case http.MethodGet, http.MethodHead:
+		if kind != davResourceKind {
+			c.Header("Allow", "OPTIONS, PROPFIND")
+			davError(c, http.StatusMethodNotAllowed, "")
+			return
+		}
+		d.get(name)
+	case http.MethodPut:
+		if kind != davResourceKind {
+			davError(c, http.StatusMethodNotAllowed, "")
+			return
+		}
+		d.put(name)
+	case http.MethodDelete:
+		if kind != davResourceKind {
+			davError(c, http.StatusForbidden, "")
+			return
+		}
+		d.delete(name)
+	default:
+		c.Header("Allow", davAllow)
+		davError(c, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// davTarget classifies a path below /dav.
+func davTarget(path string) (davKind, string, bool) {
+	switch strings.TrimSuffix(path, "/") {
+	case "":
+		return davRootKind, "", true
+	case "/principals/me":
+		return davPrincipalKind, "", true
+	case "/calendars":
+		return davHomeKind, "", true
+	case "/calendars/todos":
+		return davCollectionKind, "", true
+	}
+	name, ok := strings.CutPrefix(path, "/calendars/todos/")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return 0, "", false
+	}
+	return davResourceKind, name, true
+}
+
+func davResourceHref(name string) string {
+	return davCollection + url.PathEscape(name)
+}
+
+// davRequest holds per-request state, so the sync token and timezone are
+// looked up at most once.
+type davRequest struct {
+	c     *gin.Context
+	h     *CalDAVHandler
+	uid   primitive.ObjectID
+	token string
+	loc   *time.Location
+}
+
+func (d *davRequest) syncToken() (string, error) {
+	if d.token == "" {
+		token, err := d.h.service.SyncToken(d.c.Request.Context(), d.uid)
+		if err != nil {
+			return "", err
+		}
+		d.token = token
+	}
+	return d.token, nil
+}
+
+func (d *davRequest) location() (*time.Location, error) {
+	if d.loc == nil {
+		loc, err := d.h.service.Location(d.c.Request.Context(), d.uid)
+		if err != nil {
+			return nil, err
+		}
+		d.loc = loc
+	}
+	return d.loc, nil
+}
+
+func (d *davRequest) propfind(kind davKind, name string) {
+	var body davPropfindBody
+	if err := decodeDAVBody(d.c, &body); err != nil {
+		d.c.Error(err)
+		return
+	}
+	req := davPropRequest{All: body.AllProp != nil || (body.PropName == nil && len(body.Prop) == 0), Names: body.PropName != nil, Props: body.Prop}
+	depth := d.c.GetHeader("Depth") != "0"
+	ctx := d.c.Request.Context()
+
+	var responses []davResponse
+	switch kind {
+	case davResourceKind:
+		todo, err := d.h.service.Get(ctx, d.uid, name)
+		if err != nil {
+			d.c.Error(err)
+			return
+		}
+		r, err := d.resourceResponse(todo, req)
+		if err != nil {
+			d.c.Error(err)
+			return
+		}
+		responses = append(responses, r)
+	case davCollectionKind:
+		r, err := d.containerResponse(davCollectionKind, req)
+		if err != nil {
+			d.c.Error(err)
+			return
+		}
+		responses = append(responses, r)
+		if depth {
+			todos, err := d.h.service.List(ctx, d.uid)
+			if err != nil {
+				d.c.Error(err)
+				return
+			}
+			for _, t := range todos {
+				r, err := d.resourceResponse(t, req)
+				if err != nil {
+					d.c.Error(err)
+					return
+				}
+				responses = append(responses, r)
+			}
+		}
+	default:
+		r, err := d.containerResponse(kind, req)
+		if err != nil {
+			d.c.Error(err)
+			return
+		}
+		responses = append(responses, r)
+		if depth && kind == davHomeKind {
+			r, err := d.containerResponse(davCollectionKind, req)
+			if err != nil {
+				d.c.Error(err)
+				return
+			}
+			responses = append(responses, r)
+		}
+	}
+	writeMultistatus(d.c, responses, "")
+}
+
+func (d *davRequest) report() {
+	var body davReportBody
+	if err := decodeDAVBody(d.c, &body); err != nil {
+		d.c.Error(err)
+		return
+	}
+	req := davPropRequest{All: body.AllProp != nil || len(body.Prop) == 0, Props: body.Prop}
+	ctx := d.c.Request.Context()
+
+	switch body.XMLName {
+	case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+		var responses []davResponse
+		if body.Filter.wantsTodos() {
+			todos, err := d.h.service.List(ctx, d.uid)
+			if err != nil {
+				d.c.Error(err)
+				return
+			}
+			for _, t := range todos {
+				r, err := d.resourceResponse(t, req)
+				if err != nil {
+					d.c.Error(err)
+					return
+				}
+				responses = append(responses, r)
+			}
+		}
+		writeMultistatus(d.c, responses, "")
+
+	case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+		responses := make([]davResponse, 0, len(body.Hrefs))
+		for _, href := range body.Hrefs {
+			name, ok := davHrefName(href)
+			if !ok {
+				responses = append(responses, davResponse{Href: href, Status: http.StatusNotFound})
+				continue
+			}
+			todo, err := d.h.service.Get(ctx, d.uid, name)
+			if errors.Is(err, domain.ErrNotFound) {
+				responses = append(responses, davResponse{Href: href, Status: http.StatusNotFound})
+				continue
+			}
+			if err != nil {
+				d.c.Error(err)
+				return
+			}
+			r, err := d.resourceResponse(todo, req)
+			if err != nil {
+				d.c.Error(err)
+				return
+			}
+			responses = append(responses, r)
+		}
+		writeMultistatus(d.c, responses, "")
+
+	case xml.Name{Space: nsDAV, Local: "sync-collection"}:
+		changes, err := d.h.service.Changes(ctx, d.uid, strings.TrimSpace(body.SyncToken))
+		if domain.Code(err) == "invalid_sync_token" {
+			davError(d.c, http.StatusForbidden, "<D:valid-sync-token/>")
+			return
+		}
+		if err != nil {
+			d.c.Error(err)
+			return
+		}
+		responses := make([]davResponse, 0, len(changes.Changed)+len(changes.Removed))
+		for _, t := range changes.Changed {
+			r, err := d.resourceResponse(t, req)
+			if err != nil {
+				d.c.Error(err)
+				return
+			}
+			responses = append(responses, r)
+		}
+		for _, name := range changes.Removed {
+			responses = append(responses, davResponse{Href: davResourceHref(name), Status: http.StatusNotFound})
+		}
+		writeMultistatus(d.c, responses, changes.Token)
+
+	default:
+		davError(d.c, http.StatusForbidden, "<D:supported-report/>")
+	}
+}
+
+func (d *davRequest) get(name string) {
+	todo, err := d.h.service.Get(d.c.Request.Context(), d.uid, name)
+	if err != nil {
+		d.c.Error(err)
+		return
+	}
+	etag := versionETag(todo.Version)
+	d.c.Header("ETag", etag)
+	d.c.Header("Last-Modified", todo.UpdatedAt.UTC().Format(http.TimeFormat))
+	if noneMatch(d.c.GetHeader("If-None-Match"), etag) {
+		d.c.Status(http.StatusNotModified)
+		return
+	}
+	loc, err := d.location()
+	if err != nil {
+		d.c.Error(err)
+		return
+	}
+	d.c.Data(http.StatusOK, "text/calendar; charset=utf-8", d.h.service.Render(todo, loc))
+}
+
+func (d *davRequest) put(name string) {
+	ifMatch, ok := parseIfMatch(d.c.GetHeader("If-Match"))
+	if !ok {
+		d.c.Error(domain.PreconditionFailed("If-Match does not match the current version").WithCode("version_mismatch"))
+		return
+	}
+	cond := services.CalDAVPrecondition{
+		IfMatch:        ifMatch,
+		IfNoneMatchAny: strings.TrimSpace(d.c.GetHeader("If-None-Match")) == "*",
+	}
+	body := http.MaxBytesReader(d.c.Writer, d.c.Request.Body, davMaxBody)
+
+	todo, created, err := d.h.service.Put(d.c.Request.Context(), d.uid, name, body, cond)
+	if err != nil {
+		d.c.Error(err)
+		return
+	}
+	d.c.Header("ETag", versionETag(todo.Version))
+	if created {
+		d.c.Header("Location", davResourceHref(name))
+		d.c.Status(http.StatusCreated)
+		return
+	}
+	d.c.Status(http.StatusNoContent)
+}
+
+func (d *davRequest) delete(name string) {
+	ifMatch, ok := parseIfMatch(d.c.GetHeader("If-Match"))
+	if !ok {
+		d.c.Error(domain.PreconditionFailed("If-Match does not match the current version").WithCode("version_mismatch"))
+		return
+	}
+	if err := d.h.service.Delete(d.c.Request.Context(), d.uid, name, ifMatch); err != nil {
+		d.c.Error(err)
+		return
+	}
+	d.c.Status(http.StatusNoContent)
+}
+
+// containerResponse describes the root, principal, home or collection.
+func (d *davRequest) containerResponse(kind davKind, req davPropRequest) (davResponse, error) {
+	principal := davHref(davPrincipal)
+	props := []davProp{
+		{davName("current-user-principal"), principal},
+		{davName("owner"), principal},
+		{davName("current-user-privilege-set"), davPrivileges(kind == davCollectionKind)},
+	}
+	var href string
+	switch kind {
+	case davRootKind:
+		href = davPrefix + "/"
+		props = append(props,
+			davProp{davName("resourcetype"), "<D:collection/>"},
+			davProp{calName("calendar-home-set"), davHref(davHome)},
+		)
+	case davPrincipalKind:
+		href = davPrincipal
+		email := d.c.GetString("user_email")
+		props = append(props,
+			davProp{davName("resourcetype"), "<D:collection/><D:principal/>"},
+			davProp{davName("displayname"), xmlText(email)},
+			davProp{davName("principal-URL"), principal},
+			davProp{calName("calendar-home-set"), davHref(davHome)},
+			davProp{calName("calendar-user-address-set"), davHref("mailto:" + email)},
+		)
+	case davHomeKind:
+		href = davHome
+		props = append(props,
+			davProp{davName("resourcetype"), "<D:collection/>"},
+			davProp{davName("displayname"), "Calendars"},
+		)
+	case davCollectionKind:
+		token, err := d.syncToken()
+		if err != nil {
+			return davResponse{}, err
+		}
+		href = davCollection
+		props = append(props,
+			davProp{davName("resourcetype"), "<D:collection/><C:calendar/>"},
+			davProp{davName("displayname"), "Todos"},
+			davProp{calName("supported-calendar-component-set"), `<C:comp name="VTODO"/>`},
+			davProp{calName("supported-calendar-data"), `<C:calendar-data content-type="text/calendar" version="2.0"/>`},
+			davProp{xml.Name{Space: nsCS, Local: "getctag"}, xmlText(token)},
+			davProp{davName("sync-token"), xmlText(token)},
+			davProp{davName("supported-report-set"), "<D:supported-report><D:report><C:calendar-query/></D:report></D:supported-report>" +
+				"<D:supported-report><D:report><C:calendar-multiget/></D:report></D:supported-report>" +
+				"<D:supported-report><D:report><D:sync-collection/></D:report></D:supported-report>"},
+		)
+	}
+	return req.response(href, props), nil
+}
+
+// resourceResponse describes one todo. calendar-data is only rendered when asked for.
+func (d *davRequest) resourceResponse(todo *models.Todo, req davPropRequest) (davResponse, error) {
+	props := []davProp{
+		{davName("resourcetype"), ""},
+		{davName("getetag"), xmlText(versionETag(todo.Version))},
+		{davName("getcontenttype"), "text/calendar; charset=utf-8; component=VTODO"},
+		{davName("getlastmodified"), todo.UpdatedAt.UTC().Format(http.TimeFormat)},
+	}
+	if req.wants(calName("calendar-data")) {
+		loc, err := d.location()
+		if err != nil {
+			return davResponse{}, err
+		}
+		props = append(props, davProp{calName("calendar-data"), xmlText(string(d.h.service.Render(todo, loc)))})
+	}
+	return req.response(davResourceHref(services.CalDAVResourceName(todo)), props), nil
+}
+
+// davHrefName extracts the resource name from a multiget href (path or URL).
+func davHrefName(href string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", false
+	}
+	name, ok := strings.CutPrefix(u.Path, davCollection)
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return name, true
+}
+
+func davName(local string) xml.Name { return xml.Name{Space: nsDAV, Local: local} }
+func calName(local string) xml.Name { return xml.Name{Space: nsCalDAV, Local: local} }
+
+func davHref(href string) string {
+	return "<D:href>" + xmlText(href) + "</D:href>"
+}
+
+func davPrivileges(write bool) string {
+	privileges := []string{"read", "read-current-user-privilege-set"}
+	if write {
+		privileges = append(privileges, "write", "write-content", "bind", "unbind")
+	}
+	var b strings.Builder
+	for _, p := range privileges {
+		b.WriteString("<D:privilege><D:" + p + "/></D:privilege>")
+	}
+	return b.String()
+}
+
+func xmlText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// davProp is a property and its value as inner XML.
+type davProp struct {
+	Name  xml.Name
+	Inner string
+}
+
+// davPropRequest is the set of properties a PROPFIND or REPORT asked for.
+type davPropRequest struct {
+	All   bool // allprop
+	Names bool // propname: names only
+	Props []xml.Name
+}
+
+func (r davPropRequest) wants(name xml.Name) bool {
+	for _, p := range r.Props {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// response picks the requested properties; unknown ones are reported as 404.
+func (r davPropRequest) response(href string, available []davProp) davResponse {
+	resp := davResponse{Href: href}
+	if r.All || r.Names {
+		for _, p := range available {
+			if r.Names {
+				p.Inner = ""
+			}
+			resp.Found = append(resp.Found, p)
+		}
+		return resp
+	}
+	for _, name := range r.Props {
+		found := false
+		for _, p := range available {
+			if p.Name == name {
+				resp.Found = append(resp.Found, p)
+				found = true
+				break
+			}
+		}
+		if !found {
+			resp.Missing = append(resp.Missing, name)
+		}
+	}
+	return resp
+}
+
+// davResponse is one <D:response>; a non-zero Status replaces the propstats.
+type davResponse struct {
+	Href    string
+	Status  int
+	Found   []davProp
+	Missing []xml.Name
+}
+
+func writeMultistatus(c *gin.Context, responses []davResponse, syncToken string) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<D:multistatus xmlns:D="` + nsDAV + `" xmlns:C="` + nsCalDAV + `" xmlns:CS="` + nsCS + `">`)
+	for _, r := range responses {
+		b.WriteString("<D:response>" + davHref(r.Href))
+		if r.Status != 0 {
+			b.WriteString(davStatus(r.Status))
+		}
+		if len(r.Found) > 0 {
+			b.WriteString("<D:propstat><D:prop>")
+			for _, p := range r.Found {
+				b.WriteString(davElement(p.Name, p.Inner))
+			}
+			b.WriteString("</D:prop>" + davStatus(http.StatusOK) + "</D:propstat>")
+		}
+		if len(r.Missing) > 0 {
+			b.WriteString("<D:propstat><D:prop>")
+			for _, name := range r.Missing {
+				b.WriteString(davElement(name, ""))
+			}
+			b.WriteString("</D:prop>" + davStatus(http.StatusNotFound) + "</D:propstat>")
+		}
+		b.WriteString("</D:response>")
+	}
+	if syncToken != "" {
+		b.WriteString("<D:sync-token>" + xmlText(syncToken) + "</D:sync-token>")
+	}
+	b.WriteString("</D:multistatus>")
+	c.Data(http.StatusMultiStatus, "application/xml; charset=utf-8", []byte(b.String()))
+}
+
+// davElement writes a property element, declaring namespaces other than the
+// three bound on the multistatus root.
+func davElement(name xml.Name, inner string) string {
+	var tag, attrs string
+	switch name.Space {
+	case nsDAV:
+		tag = "D:" + name.Local
+	case nsCalDAV:
+		tag = "C:" + name.Local
+	case nsCS:
+		tag = "CS:" + name.Local
+	default:
+		tag = "X:" + name.Local
+		attrs = ` xmlns:X="` + xmlText(name.Space) + `"`
+	}
+	if inner == "" {
+		return "<" + tag + attrs + "/>"
+	}
+	return "<" + tag + attrs + ">" + inner + "</" + tag + ">"
+}
+
+func davStatus(code int) string {
+	return "<D:status>HTTP/1.1 " + strconv.Itoa(code) + " " + http.StatusText(code) + "</D:status>"
+}
+
+// davError writes a DAV:error body naming the failed precondition, if any.
+func davError(c *gin.Context, status int, condition string) {
+	c.Data(status, "application/xml; charset=utf-8",
+		[]byte(xml.Header+`<D:error xmlns:D="`+nsDAV+`" xmlns:C="`+nsCalDAV+`">`+condition+`</D:error>`))
+}
+
+func decodeDAVBody(c *gin.Context, v any) error {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, davMaxBody)
+	if err := xml.NewDecoder(body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return domain.Wrap(domain.ErrValidation, err, "malformed XML body").WithCode("malformed_xml")
+	}
+	return nil
+}
+
+type davPropfindBody struct {
+	AllProp  *struct{}   `xml:"DAV: allprop"`
+	PropName *struct{}   `xml:"DAV: propname"`
+	Prop     davPropList `xml:"DAV: prop"`
+}
+
+type davReportBody struct {
+	XMLName   xml.Name
+	AllProp   *struct{}     `xml:"DAV: allprop"`
+	Prop      davPropList   `xml:"DAV: prop"`
+	Hrefs     []string      `xml:"DAV: href"`
+	SyncToken string        `xml:"DAV: sync-token"`
+	Filter    *calDAVFilter `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+// davPropList collects the element names inside <D:prop>.
+type davPropList []xml.Name
+
+func (p *davPropList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			*p = append(*p, t.Name)
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type calDAVFilter struct {
+	Comp calDAVCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type calDAVCompFilter struct {
+	Name  string             `xml:"name,attr"`
+	Comps []calDAVCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// wantsTodos reports whether a calendar-query filter can match VTODOs. Other
+// conditions (time ranges, properties) are left to the client.
+func (f *calDAVFilter) wantsTodos() bool {
+	if f == nil || len(f.Comp.Comps) == 0 {
+		return true
+	}
+	for _, comp := range f.Comp.Comps {
+		if strings.EqualFold(comp.Name, "VTODO") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/middleware"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type CalendarHandler struct {
+	service *services.CalendarService
+}
+
+func NewCalendarHandler(s *services.CalendarService) *CalendarHandler {
+	return &CalendarHandler{service: s}
+}
+
+// @Summary      Calendar feed
+// @Description  iCalendar feed of the user's todos for calendar subscriptions, authenticated by the secret token in the URL (from POST /profile/calendar). Each todo is a VTODO, and each todo with a due date is also a VEVENT for calendar apps that ignore tasks; midnight due dates become all-day entries. Use components to limit the feed to one kind.
+// @Tags         calendar
+// @Produce      text/calendar
+// @Param        token       path      string  true   "Feed token, with or without the .ics suffix"
+// @Param        components  query     string  false  "vtodo, vevent or both (default)"  Enums(vtodo, vevent, both)
+// @Success      200         {string}  string  "iCalendar data"
+// @Failure      400         {object}  ErrorResponse
+// @Failure      404         {object}  ErrorResponse
+// @Failure      500         {object}  ErrorResponse
+// @Router       /calendar/{token} [get]
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	var components services.CalendarComponents
+	switch c.DefaultQuery("components", "both") {
+	case "vtodo":
+		components.Todos = true
+	case "vevent":
+		components.Events = true
+	case "both":
+		components.Todos, components.Events = true, true
+	default:
+		c.Error(invalidField("components", "oneof", "must be one of: vtodo, vevent, both"))
+		return
+	}
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Status(http.StatusOK)
+	if err := h.service.Feed(c.Request.Context(), token, components, c.Writer); err != nil {
+		if c.Writer.Written() {
+			log.Printf("[%s] calendar feed: %v", middleware.RequestIDFrom(c), err)
+			return
+		}
+		c.Writer.Header().Del("Cache-Control")
+		c.Error(err)
+	}
+}
+
+// @Summary      Calendar feed status
+// @Description  Reports whether the authenticated user has a calendar feed. The URL itself is only shown when it is generated.
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  services.CalendarFeedInfo
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /profile/calendar [get]
+func (h *CalendarHandler) Status(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	info, err := h.service.Status(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// @Summary      Generate calendar feed URL
+// @Description  Creates a secret iCalendar feed URL, or replaces the existing one (the old URL stops working). Store the returned url; it is not shown again.
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  services.CalendarFeedInfo
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /profile/calendar [post]
+func (h *CalendarHandler) Regenerate(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	info, err := h.service.Regenerate(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// @Summary      Revoke calendar feed
+// @Description  Disables the calendar feed; subscribed calendars stop receiving updates.
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /profile/calendar [delete]
+func (h *CalendarHandler) Revoke(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), uid); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "revoked"})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CommentHandler struct {
+	service *services.CommentService
+}
+
+func NewCommentHandler(s *services.CommentService) *CommentHandler {
+	return &CommentHandler{service: s}
+}
+
+type CreateCommentRequest struct {
+	Body     string `json:"body" validate:"required,max=10000"`
+	ParentID string `json:"parent_id"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+// @Summary      List comments
+// @Description  Lists a todo's comment threads oldest first: top-level comments with their replies. Deleted comments only appear (with an empty body) while they have replies.
+// @Tags         comments
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Todo ID"
+// @Success      200  {array}   models.Comment
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /todos/{id}/comments [get]
+func (h *CommentHandler) List(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	comments, err := h.service.List(c.Request.Context(), uid, tid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, comments)
+}
+
+// @Summary      Add comment
+// @Description  Adds a Markdown comment to a todo, or a reply when parent_id is set (replies to a reply join its thread). Mention people with @ and their email, e.g. @jane@example.com; those who can see the todo are emailed unless they turned mention emails off.
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                   true  "Todo ID"
+// @Param        payload  body      CreateCommentRequestDTO  true  "Comment"
+// @Success      201      {object}  models.Comment
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /todos/{id}/comments [post]
+func (h *CommentHandler) Create(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req CreateCommentRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	in := services.CommentInput{Body: req.Body}
+	if req.ParentID != "" {
+		pid, err := primitive.ObjectIDFromHex(req.ParentID)
+		if err != nil {
+			c.Error(invalidField("parent_id", "objectid", "must be a comment ID"))
+			return
+		}
+		in.ParentID = &pid
+	}
+
+	comment, err := h.service.Create(c.Request.Context(), uid, tid, in)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, comment)
+}
+
+// @Summary      Edit comment
+// @Description  Replaces the body of one of your comments. People newly mentioned by the edit are notified.
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      string                   true  "Todo ID"
+// @Param        commentId  path      string                   true  "Comment ID"
+// @Param        payload    body      UpdateCommentRequestDTO  true  "Comment"
+// @Success      200        {object}  models.Comment
+// @Failure      400        {object}  ErrorResponse
+// @Failure      401        {object}  ErrorResponse
+// @Failure      403        {object}  ErrorResponse
+// @Failure      404        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /todos/{id}/comments/{commentId} [patch]
+func (h *CommentHandler) Update(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	cid, err := pathObjectID(c, "commentId", "comment")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req UpdateCommentRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	comment, err := h.service.Edit(c.Request.Context(), uid, tid, cid, req.Body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, comment)
+}
+
+// @Summary      Delete comment
+// @Description  Deletes one of your comments; co-owners of the todo can delete anyone's.
+// @Tags         comments
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      string  true  "Todo ID"
+// @Param        commentId  path      string  true  "Comment ID"
+// @Success      200        {object}  map[string]string
+// @Failure      400        {object}  ErrorResponse
+// @Failure      401        {object}  ErrorResponse
+// @Failure      403        {object}  ErrorResponse
+// @Failure      404        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /todos/{id}/comments/{commentId} [delete]
+func (h *CommentHandler) Delete(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	cid, err := pathObjectID(c, "commentId", "comment")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), uid, tid, cid); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
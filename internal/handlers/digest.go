@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type DigestHandler struct {
+	service *services.DigestService
+}
+
+func NewDigestHandler(s *services.DigestService) *DigestHandler {
+	return &DigestHandler{service: s}
+}
+
+// unsubscribePage is shown for GET /unsubscribe. Opening the link changes
+// nothing, so mail scanners that follow it do not unsubscribe anyone; the
+// button POSTs back to the same URL.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+{{if .Done}}<p>You have been unsubscribed.</p>
+{{else if .Error}}<p>{{.Error}}</p>
+{{else}}<p>Stop receiving {{if eq .Kind "all"}}digest emails{{else}}the {{.Kind}} digest{{end}}?</p>
+<form method="post"><button type="submit">Unsubscribe</button></form>
+{{end}}</body>
+</html>
+`))
+
+type unsubscribeView struct {
+	Kind  string
+	Error string
+	Done  bool
+}
+
+func renderUnsubscribePage(c *gin.Context, status int, view unsubscribeView) {
+	var buf bytes.Buffer
+	if err := unsubscribePage.Execute(&buf, view); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// @Summary      Unsubscribe confirmation page
+// @Description  Renders a page asking to confirm the unsubscribe from an email link. No login required. Nothing changes until the page's button POSTs /unsubscribe.
+// @Tags         notifications
+// @Produce      html
+// @Param        token  query     string  true  "Unsubscribe token from the email"
+// @Success      200    {string}  string  "Confirmation page"
+// @Failure      400    {string}  string  "Invalid or expired link"
+// @Router       /unsubscribe [get]
+func (h *DigestHandler) UnsubscribePage(c *gin.Context) {
+	token := c.Query("token")
+	kind, err := h.service.CheckUnsubscribeToken(token)
+	if err != nil {
+		renderUnsubscribePage(c, http.StatusBadRequest, unsubscribeView{Error: "This unsubscribe link is invalid or has expired."})
+		return
+	}
+	renderUnsubscribePage(c, http.StatusOK, unsubscribeView{Kind: kind})
+}
+
+// @Summary      Unsubscribe from digest emails
+// @Description  Turns off the digest referenced by the signed token from an email link. No login required. Supports RFC 8058 one-click unsubscribe; browsers submitting the confirmation page get an HTML page. Tokens expire after 90 days.
+// @Tags         notifications
+// @Produce      json,html
+// @Param        token  query     string  true  "Unsubscribe token from the email"
+// @Success      200    {object}  map[string]string
+// @Failure      400    {object}  ErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Router       /unsubscribe [post]
+func (h *DigestHandler) Unsubscribe(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.Error(invalidField("token", "required", "is required"))
+		return
+	}
+
+	kind, err := h.service.Unsubscribe(c.Request.Context(), token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		renderUnsubscribePage(c, http.StatusOK, unsubscribeView{Done: true})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "You have been unsubscribed.", "digest": kind})
+}
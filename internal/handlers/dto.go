@@ -1,9 +1,26 @@
 package handlers
 
-// ErrorResponse represents a standard error payload
+import "time"
+
+// ErrorResponse represents an RFC 7807 problem+json error payload
 // swagger:model ErrorResponse
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Type      string          `json:"type" example:"about:blank"`
+	Title     string          `json:"title" example:"Bad Request"`
+	Status    int             `json:"status" example:"400"`
+	Detail    string          `json:"detail,omitempty" example:"request validation failed"`
+	Instance  string          `json:"instance,omitempty" example:"/api/v1/signup"`
+	Code      string          `json:"code" example:"validation_failed"`
+	RequestID string          `json:"request_id,omitempty" example:"4f1c2a9e8b7d6c5e4f3a2b1c0d9e8f7a"`
+	Errors    []FieldErrorDTO `json:"errors,omitempty"`
+}
+
+// FieldErrorDTO represents one invalid input field in ErrorResponse
+// swagger:model FieldError
+type FieldErrorDTO struct {
+	Field   string `json:"field" example:"email"`
+	Code    string `json:"code" example:"email"`
+	Message string `json:"message" example:"must be a valid email address"`
 }
 
 // SignupRequestDTO represents signup request
@@ -47,16 +64,41 @@ type ResetPasswordRequestDTO struct {
 // CreateTodoRequestDTO represents create todo request
 // swagger:model CreateTodoRequest
 type CreateTodoRequestDTO struct {
-	Title       string `json:"title" example:"Buy milk"`
-	Description string `json:"description" example:"2 liters of whole milk"`
+	Title           string         `json:"title" example:"Buy milk"`
+	Description     string         `json:"description" example:"2 liters of whole milk"`
+	DueAt           *time.Time     `json:"due_at" example:"2025-01-31T17:00:00Z"`
+	ProjectID       *string        `json:"project_id" example:"64f1c2a7e1b2c3d4e5f60718"`
+	AssigneeID      *string        `json:"assignee_id" example:"64f1c2a7e1b2c3d4e5f60719"`
+	Labels          []string       `json:"labels" example:"errands,home"`
+	Priority        string         `json:"priority,omitempty" example:"high" enums:"low,normal,high,urgent"`
+	Recurrence      *RecurrenceDTO `json:"recurrence,omitempty"`
+	EstimateMinutes *int           `json:"estimate_minutes" example:"30"`
+}
+
+// RecurrenceDTO represents how a todo repeats
+// swagger:model Recurrence
+type RecurrenceDTO struct {
+	Frequency string   `json:"frequency" example:"weekly" enums:"daily,weekly,monthly,yearly"`
+	Interval  int      `json:"interval,omitempty" example:"1"`
+	Weekdays  []string `json:"weekdays,omitempty" example:"mon,thu"`
+	MonthDay  int      `json:"month_day,omitempty" example:"1"`
 }
 
 // UpdateTodoRequestDTO represents update todo request
 // swagger:model UpdateTodoRequest
 type UpdateTodoRequestDTO struct {
-	Title       *string `json:"title" example:"Buy bread"`
-	Description *string `json:"description" example:"Whole grain"`
-	Completed   *bool   `json:"completed" example:"true"`
+	Title           *string        `json:"title" example:"Buy bread"`
+	Description     *string        `json:"description" example:"Whole grain"`
+	Completed       *bool          `json:"completed" example:"true"`
+	Status          *string        `json:"status" example:"in_progress"`
+	DueAt           *time.Time     `json:"due_at" example:"2025-02-01T09:00:00Z"`
+	ClearDueAt      bool           `json:"clear_due_at" example:"false"`
+	ProjectID       *string        `json:"project_id" example:"64f1c2a7e1b2c3d4e5f60718"`
+	AssigneeID      *string        `json:"assignee_id" example:"64f1c2a7e1b2c3d4e5f60719"`
+	Labels          []string       `json:"labels" example:"errands,home"`
+	Priority        *string        `json:"priority" example:"urgent" enums:"low,normal,high,urgent"`
+	Recurrence      *RecurrenceDTO `json:"recurrence"`
+	EstimateMinutes *int           `json:"estimate_minutes" example:"30"`
 }
 
 // AIChatMessageDTO represents a single AI chat message
@@ -79,3 +121,208 @@ type AIChatRequestDTO struct {
 type AIChatResponseDTO struct {
 	Answer string `json:"answer" example:"Clean Architecture in Go involves..."`
 }
+
+// EmailTemplateListDTO represents the available email templates
+// swagger:model EmailTemplateList
+type EmailTemplateListDTO struct {
+	Templates []string `json:"templates" example:"otp_signup,otp_forgot_password"`
+	Locales   []string `json:"locales" example:"en,es"`
+}
+
+// UpdatePreferencesRequestDTO represents a partial preferences update
+// swagger:model UpdatePreferencesRequest
+type UpdatePreferencesRequestDTO struct {
+	Timezone           *string                    `json:"timezone" example:"America/New_York"`
+	Locale             *string                    `json:"locale" example:"es"`
+	WeekStart          *string                    `json:"week_start" example:"sunday" enums:"monday,sunday,saturday"`
+	DefaultProjectID   *string                    `json:"default_project_id" example:"64f1c2a7e1b2c3d4e5f60718"`
+	DefaultSort        *string                    `json:"default_sort" example:"due_at" enums:"created_at,-created_at,updated_at,-updated_at,due_at,-due_at,title,-title"`
+	EmailNotifications *EmailNotificationPrefsDTO `json:"email_notifications"`
+	AutoArchiveDays    *int                       `json:"auto_archive_days" example:"14"`
+}
+
+// EmailNotificationPrefsDTO represents email opt-ins
+// swagger:model EmailNotificationPrefs
+type EmailNotificationPrefsDTO struct {
+	DailyDigest  *bool `json:"daily_digest" example:"true"`
+	WeeklyDigest *bool `json:"weekly_digest" example:"false"`
+	Mentions     *bool `json:"mentions" example:"true"`
+}
+
+// SyncPushRequestDTO represents a batch of offline changes
+// swagger:model SyncPushRequest
+type SyncPushRequestDTO struct {
+	Strategy string          `json:"strategy" example:"merge" enums:"merge,lww"`
+	Changes  []SyncChangeDTO `json:"changes"`
+}
+
+// SyncChangeDTO represents one offline change
+// swagger:model SyncChange
+type SyncChangeDTO struct {
+	ID              string     `json:"id" example:"66f0c2a1e4b0a1b2c3d4e5f6"`
+	Op              string     `json:"op" example:"update" enums:"create,update,delete"`
+	BaseVersion     int64      `json:"base_version" example:"3"`
+	ClientUpdatedAt time.Time  `json:"client_updated_at" example:"2025-01-31T16:58:00Z"`
+	Title           *string    `json:"title" example:"Buy oat milk"`
+	Description     *string    `json:"description" example:"2 cartons"`
+	Completed       *bool      `json:"completed" example:"false"`
+	DueAt           *time.Time `json:"due_at" example:"2025-02-01T18:00:00Z"`
+	ProjectID       *string    `json:"project_id" example:"64f1c2a7e1b2c3d4e5f60718"`
+}
+
+// CreateWebhookRequestDTO represents a webhook registration
+// swagger:model CreateWebhookRequest
+type CreateWebhookRequestDTO struct {
+	URL    string   `json:"url" example:"https://example.com/hooks/todos"`
+	Secret string   `json:"secret" example:"a-long-random-string"`
+	Events []string `json:"events" example:"todo.created,todo.completed"`
+}
+
+// UpdateWebhookRequestDTO represents a partial webhook update
+// swagger:model UpdateWebhookRequest
+type UpdateWebhookRequestDTO struct {
+	URL    *string   `json:"url" example:"https://example.com/hooks/todos"`
+	Secret *string   `json:"secret" example:"a-new-long-random-string"`
+	Events *[]string `json:"events" example:"todo.completed"`
+	Active *bool     `json:"active" example:"true"`
+}
+
+// CreateAppPasswordRequestDTO represents a new app password
+// swagger:model CreateAppPasswordRequest
+type CreateAppPasswordRequestDTO struct {
+	Name string `json:"name" example:"Thunderbird on laptop"`
+}
+
+// CreateShareRequestDTO represents an invitation to collaborate
+// swagger:model CreateShareRequest
+type CreateShareRequestDTO struct {
+	Email string `json:"email" example:"john@example.com"`
+	Role  string `json:"role" example:"editor" enums:"viewer,editor,owner"`
+}
+
+// WorkspaceRequestDTO represents a workspace name
+// swagger:model WorkspaceRequest
+type WorkspaceRequestDTO struct {
+	Name string `json:"name" example:"Acme Marketing"`
+}
+
+// InviteMemberRequestDTO represents a workspace invitation
+// swagger:model InviteMemberRequest
+type InviteMemberRequestDTO struct {
+	Email string `json:"email" example:"john@example.com"`
+	Role  string `json:"role" example:"member" enums:"admin,member,viewer"`
+}
+
+// SetMemberRoleRequestDTO represents a member role change
+// swagger:model SetMemberRoleRequest
+type SetMemberRoleRequestDTO struct {
+	Role string `json:"role" example:"admin" enums:"admin,member,viewer"`
+}
+
+// AddDependencyRequestDTO names the todo that blocks another
+// swagger:model AddDependencyRequest
+type AddDependencyRequestDTO struct {
+	BlockerID string `json:"blocker_id" example:"64f1c2a7e1b2c3d4e5f60718"`
+}
+
+// WorkflowColumnDTO represents a board column
+// swagger:model WorkflowColumn
+type WorkflowColumnDTO struct {
+	Key      string `json:"key" example:"in_progress"`
+	Name     string `json:"name" example:"In progress"`
+	WIPLimit int    `json:"wip_limit" example:"3"`
+	Done     bool   `json:"done" example:"false"`
+}
+
+// SetWorkflowRequestDTO represents a project's workflow
+// swagger:model SetWorkflowRequest
+type SetWorkflowRequestDTO struct {
+	Columns     []WorkflowColumnDTO `json:"columns"`
+	Transitions map[string][]string `json:"transitions,omitempty"`
+}
+
+// CreateCommentRequestDTO represents a new comment or reply
+// swagger:model CreateCommentRequest
+type CreateCommentRequestDTO struct {
+	Body     string `json:"body" example:"Draft is ready, @jane@example.com can you review?"`
+	ParentID string `json:"parent_id,omitempty" example:"64f1c2a9e4b0a1b2c3d4e5f6"`
+}
+
+// UpdateCommentRequestDTO represents an edited comment body
+// swagger:model UpdateCommentRequest
+type UpdateCommentRequestDTO struct {
+	Body string `json:"body" example:"Draft is ready for review."`
+}
+
+// StartTimerRequestDTO represents an optional note for a new timer
+// swagger:model StartTimerRequest
+type StartTimerRequestDTO struct {
+	Note string `json:"note,omitempty" example:"Pairing on the parser"`
+}
+
+// CreateTimeEntryRequestDTO represents time entered by hand
+// swagger:model CreateTimeEntryRequest
+type CreateTimeEntryRequestDTO struct {
+	StartedAt string `json:"started_at" example:"2025-01-15T09:00:00Z"`
+	EndedAt   string `json:"ended_at,omitempty" example:"2025-01-15T10:30:00Z"`
+	Minutes   int    `json:"minutes,omitempty" example:"90"`
+	Note      string `json:"note,omitempty" example:"Code review"`
+}
+
+// UpdateTimeEntryRequestDTO represents a partial time entry update
+// swagger:model UpdateTimeEntryRequest
+type UpdateTimeEntryRequestDTO struct {
+	StartedAt string `json:"started_at,omitempty" example:"2025-01-15T09:15:00Z"`
+	EndedAt   string `json:"ended_at,omitempty" example:"2025-01-15T10:30:00Z"`
+	Note      string `json:"note,omitempty" example:"Code review and fixes"`
+}
+
+// TodoTemplateRequestDTO represents a todo template
+// swagger:model TodoTemplateRequest
+type TodoTemplateRequestDTO struct {
+	Name          string               `json:"name" example:"Onboarding"`
+	Title         string               `json:"title" example:"Onboard {{name}}"`
+	Description   string               `json:"description,omitempty" example:"Started on {{date}}"`
+	Labels        []string             `json:"labels,omitempty" example:"onboarding"`
+	DueOffsetDays *int                 `json:"due_offset_days,omitempty" example:"14"`
+	Subtasks      []TemplateSubtaskDTO `json:"subtasks,omitempty"`
+}
+
+// TemplateSubtaskDTO represents a subtask of a template
+// swagger:model TemplateSubtask
+type TemplateSubtaskDTO struct {
+	Title         string   `json:"title" example:"Create accounts for {{name}}"`
+	Description   string   `json:"description,omitempty" example:"Email, chat and VPN"`
+	Labels        []string `json:"labels,omitempty" example:"it"`
+	DueOffsetDays *int     `json:"due_offset_days,omitempty" example:"1"`
+}
+
+// InstantiateTemplateRequestDTO represents the values for a template's variables
+// swagger:model InstantiateTemplateRequest
+type InstantiateTemplateRequestDTO struct {
+	Variables map[string]string `json:"variables,omitempty"`
+	Date      string            `json:"date,omitempty" example:"2025-02-03"`
+	ProjectID string            `json:"project_id,omitempty" example:"64f1c2a7e1b2c3d4e5f60718"`
+}
+
+// QuickAddRequestDTO represents a line of text to turn into a todo
+// swagger:model QuickAddRequest
+type QuickAddRequestDTO struct {
+	Text string `json:"text" example:"Pay rent every 1st of month at 9am #finance !high"`
+}
+
+// CreateSavedFilterRequestDTO represents a saved filter
+// swagger:model CreateSavedFilterRequest
+type CreateSavedFilterRequestDTO struct {
+	Name  string `json:"name" example:"Urgent work"`
+	Query string `json:"query" example:"label:work AND priority>=high AND completed:false"`
+	Sort  string `json:"sort,omitempty" example:"due_at"`
+}
+
+// UpdateSavedFilterRequestDTO represents the saved filter fields to change
+// swagger:model UpdateSavedFilterRequest
+type UpdateSavedFilterRequestDTO struct {
+	Name  string `json:"name,omitempty" example:"Due this week"`
+	Query string `json:"query,omitempty" example:"due:this_week -completed:true"`
+	Sort  string `json:"sort,omitempty" example:"-due_at"`
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/group14000/golang-todo/internal/models"
+)
+
+// todoETag renders a todo as a strong entity tag: its version, followed by the
+// comment count and blocked flag, which change without a new version (as in
+// listETag). If-Match only compares the version (see parseIfMatch).
+func todoETag(todo *models.Todo) string {
+	tag := strconv.FormatInt(todo.Version, 10)
+	if todo.CommentCount > 0 {
+		tag += ".c" + strconv.Itoa(todo.CommentCount)
+	}
+	if todo.Blocked {
+		tag += ".b"
+	}
+	return `"` + tag + `"`
+}
+
+// versionETag renders a bare todo version as a strong entity tag, for CalDAV
+// where only the iCalendar data matters.
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// listETag derives a weak entity tag from the ids and versions of a todo list.
+func listETag(todos []*models.Todo) string {
+	h := sha1.New()
+	for _, t := range todos {
+		h.Write([]byte(t.ID.Hex()))
+		h.Write([]byte{':'})
+		h.Write([]byte(strconv.FormatInt(t.Version, 10)))
+		h.Write([]byte{':'})
+		h.Write([]byte(strconv.Itoa(t.CommentCount)))
+		// blocked is computed from other todos, so it can change without a new version.
+		if t.Blocked {
+			h.Write([]byte{'b'})
+		}
+		h.Write([]byte{';'})
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:10]) + `"`
+}
+
+// parseIfMatch returns the versions listed in an If-Match header; anything
+// after the version in a todoETag is ignored. It returns nil for an absent
+// header or "*" (no version condition) and ok=false when a tag is not one this
+// API issued.
+func parseIfMatch(header string) (versions []int64, ok bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			// If-Match requires strong comparison; weak tags never match.
+			return nil, false
+		}
+		version, _, _ := strings.Cut(strings.Trim(tag, `"`), ".")
+		v, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		versions = append(versions, v)
+	}
+	return versions, true
+}
+
+// noneMatch reports whether an If-None-Match header matches etag (weak comparison).
+func noneMatch(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
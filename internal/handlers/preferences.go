@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PreferencesHandler struct {
+	service *services.PreferencesService
+}
+
+func NewPreferencesHandler(s *services.PreferencesService) *PreferencesHandler {
+	return &PreferencesHandler{service: s}
+}
+
+type UpdatePreferencesRequest struct {
+	Timezone           *string                          `json:"timezone" validate:"omitempty,timezone"`
+	Locale             *string                          `json:"locale" validate:"omitempty,bcp47_language_tag"`
+	WeekStart          *string                          `json:"week_start" validate:"omitempty,oneof=monday sunday saturday"`
+	DefaultProjectID   *string                          `json:"default_project_id"` // empty string clears it
+	DefaultSort        *string                          `json:"default_sort" validate:"omitempty,oneof=created_at -created_at updated_at -updated_at due_at -due_at title -title"`
+	EmailNotifications *UpdateEmailNotificationsRequest `json:"email_notifications"`
+	AutoArchiveDays    *int                             `json:"auto_archive_days" validate:"omitempty,min=0,max=365"`
+}
+
+type UpdateEmailNotificationsRequest struct {
+	DailyDigest  *bool `json:"daily_digest"`
+	WeeklyDigest *bool `json:"weekly_digest"`
+	Mentions     *bool `json:"mentions"`
+}
+
+// @Summary      Get preferences
+// @Description  Returns the authenticated user's preferences (timezone, locale, week start, defaults, email opt-ins, auto-archive policy).
+// @Tags         profile
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.UserPreferences
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /profile/preferences [get]
+func (h *PreferencesHandler) Get(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	prefs, err := h.service.Get(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// @Summary      Update preferences
+// @Description  Partially updates the authenticated user's preferences. Send default_project_id as "" to clear it. auto_archive_days archives personal todos that many days after completion; it is off until set (0 turns it off again).
+// @Tags         profile
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload  body      UpdatePreferencesRequestDTO  true  "Preferences patch"
+// @Success      200      {object}  models.UserPreferences
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /profile/preferences [patch]
+func (h *PreferencesHandler) Update(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req UpdatePreferencesRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	patch := services.PreferencesPatch{
+		Timezone:        req.Timezone,
+		Locale:          req.Locale,
+		WeekStart:       req.WeekStart,
+		DefaultSort:     req.DefaultSort,
+		AutoArchiveDays: req.AutoArchiveDays,
+	}
+	if req.DefaultProjectID != nil {
+		if *req.DefaultProjectID == "" {
+			patch.ClearDefaultProject = true
+		} else {
+			pid, err := primitive.ObjectIDFromHex(*req.DefaultProjectID)
+			if err != nil {
+				c.Error(invalidField("default_project_id", "objectid", "must be a project ID"))
+				return
+			}
+			patch.DefaultProjectID = &pid
+		}
+	}
+	if req.EmailNotifications != nil {
+		patch.DailyDigest = req.EmailNotifications.DailyDigest
+		patch.WeeklyDigest = req.EmailNotifications.WeeklyDigest
+		patch.Mentions = req.EmailNotifications.Mentions
+	}
+
+	prefs, err := h.service.Update(c.Request.Context(), uid, patch)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/net/websocket"
+)
+
+// realtimeHeartbeat keeps idle connections open through proxies.
+const realtimeHeartbeat = 25 * time.Second
+
+type RealtimeHandler struct {
+	service *services.RealtimeService
+}
+
+func NewRealtimeHandler(s *services.RealtimeService) *RealtimeHandler {
+	return &RealtimeHandler{service: s}
+}
+
+// realtimeFrame is the wire form of a RealtimeMessage, shared by SSE and WebSocket.
+type realtimeFrame struct {
+	ID    string          `json:"id,omitempty"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+func newRealtimeFrame(m services.RealtimeMessage) (realtimeFrame, error) {
+	if m.Resync {
+		return realtimeFrame{Event: "resync", Data: json.RawMessage(`{}`)}, nil
+	}
+	data, err := json.Marshal(m.Event)
+	if err != nil {
+		return realtimeFrame{}, err
+	}
+	return realtimeFrame{ID: m.Event.ID.Hex(), Event: m.Event.Type.Name(), Data: data}, nil
+}
+
+// lastEventID reads the resume position from the Last-Event-ID header or last_event_id query.
+func lastEventID(c *gin.Context) (primitive.ObjectID, error) {
+	v := c.GetHeader("Last-Event-ID")
+	if v == "" {
+		v = c.Query("last_event_id")
+	}
+	if v == "" {
+		return primitive.NilObjectID, nil
+	}
+	id, err := primitive.ObjectIDFromHex(v)
+	if err != nil {
+		return primitive.NilObjectID, invalidField("last_event_id", "objectid", "must be an event ID")
+	}
+	return id, nil
+}
+
+// @Summary      Todo event stream (SSE)
+// @Description  Server-Sent Events stream of the events of the user's todos and of todos shared with them (todo.created, todo.updated, todo.completed, todo.reopened, todo.deleted, todo.restored, todo.commented, todo.archived, todo.unarchived); data is the event as returned by /activity. Send Last-Event-ID (or last_event_id) to replay missed events; a resync event means too many were missed and todos should be refetched. With X-Workspace-ID (or workspace_id) the stream carries the workspace's events instead. Browsers may pass the token as access_token.
+// @Tags         realtime
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        Last-Event-ID  header  string  false  "ID of the last event received"
+// @Param        last_event_id  query   string  false  "Same as Last-Event-ID, for clients that cannot set headers"
+// @Param        access_token   query   string  false  "JWT, for EventSource clients that cannot set headers"
+// @Param        workspace_id   query   string  false  "Same as X-Workspace-ID, for clients that cannot set headers"
+// @Success      200  {string}  string  "event stream"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /events [get]
+func (h *RealtimeHandler) Events(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	lastID, err := lastEventID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	stream, err := h.service.Stream(ctx, uid, lastID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	w := c.Writer
+	fmt.Fprint(w, "retry: 3000\n\n")
+	w.Flush()
+
+	ticker := time.NewTicker(realtimeHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case m, ok := <-stream:
+			if !ok {
+				return
+			}
+			frame, err := newRealtimeFrame(m)
+			if err != nil {
+				continue
+			}
+			if frame.ID != "" {
+				fmt.Fprintf(w, "id: %s\n", frame.ID)
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", frame.Event, frame.Data)
+			w.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// @Summary      Todo event stream (WebSocket)
+// @Description  WebSocket carrying the same events as /events as JSON text frames {"id","event","data"}; {"event":"ping"} frames are heartbeats. Resume with Last-Event-ID or last_event_id. Messages sent by the client are ignored.
+// @Tags         realtime
+// @Security     BearerAuth
+// @Param        last_event_id  query  string  false  "ID of the last event received"
+// @Param        access_token   query  string  false  "JWT, for browser clients that cannot set headers"
+// @Param        workspace_id   query  string  false  "Same as X-Workspace-ID, for clients that cannot set headers"
+// @Success      101  "Switching Protocols"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /ws [get]
+func (h *RealtimeHandler) WebSocket(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	lastID, err := lastEventID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	stream, err := h.service.Stream(ctx, uid, lastID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Authentication is by bearer token rather than cookies, so any Origin is accepted.
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		go func() {
+			// Reads only detect the client going away.
+			var discard []byte
+			for websocket.Message.Receive(ws, &discard) == nil {
+			}
+			cancel()
+		}()
+
+		ticker := time.NewTicker(realtimeHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case m, ok := <-stream:
+				if !ok {
+					return
+				}
+				frame, err := newRealtimeFrame(m)
+				if err != nil {
+					continue
+				}
+				if websocket.JSON.Send(ws, frame) != nil {
+					return
+				}
+			case <-ticker.C:
+				if websocket.JSON.Send(ws, realtimeFrame{Event: "ping"}) != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
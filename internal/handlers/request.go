@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/group14000/golang-todo/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// bindJSON decodes the request body into req and runs its validate tags.
+// Decode and validation failures come back as domain validation errors with
+// per-field details, keyed by JSON field name.
+func bindJSON(c *gin.Context, req any) error {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return bindError(err)
+	}
+	return validate(req)
+}
+
+// validate runs struct validation, naming fields by their json tag.
+func validate(req any) error {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	err := v.Struct(req)
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+	fields := make([]domain.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		// Namespace is "Struct.field.sub"; drop the struct name.
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		fields = append(fields, domain.FieldError{Field: field, Code: fe.Tag(), Message: fieldMessage(fe)})
+	}
+	return domain.InvalidFields(fields)
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "len":
+		return fmt.Sprintf("must be exactly %s characters", fe.Param())
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "url":
+		return "must be an absolute URL"
+	case "mongodb":
+		return "must be a 24-character hex ObjectID"
+	case "timezone":
+		return "must be an IANA timezone such as Europe/Madrid"
+	case "bcp47_language_tag":
+		return "must be a language tag such as en or pt-BR"
+	}
+	return "failed " + fe.Tag() + " validation"
+}
+
+// bindError turns a JSON decode error into a validation error, pointing at the offending field when known.
+func bindError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var timeErr *time.ParseError
+	switch {
+	case errors.Is(err, io.EOF):
+		return domain.Validation("request body is required").WithCode("empty_body")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return domain.Wrap(domain.ErrValidation, err, "malformed JSON body").WithCode("malformed_json")
+	case errors.As(err, &typeErr):
+		return domain.InvalidFields([]domain.FieldError{{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: "must be a " + typeErr.Type.String(),
+		}})
+	case errors.As(err, &timeErr):
+		return domain.Validation("timestamps must be RFC 3339, e.g. 2025-01-31T17:00:00Z").WithCode("invalid_datetime")
+	}
+	return domain.Wrap(domain.ErrValidation, err, "invalid request body").WithCode("invalid_body")
+}
+
+// invalidField builds a validation error for a single field.
+func invalidField(field, code, message string) error {
+	return domain.InvalidFields([]domain.FieldError{{Field: field, Code: code, Message: message}})
+}
+
+// currentUserID returns the authenticated user's ID set by AuthMiddleware.
+func currentUserID(c *gin.Context) (primitive.ObjectID, error) {
+	uid, err := primitive.ObjectIDFromHex(c.GetString("user_id"))
+	if err != nil {
+		return primitive.NilObjectID, domain.Unauthorized("invalid user id").WithCode("invalid_token")
+	}
+	return uid, nil
+}
+
+// pathObjectID parses an ObjectID path parameter; what names it in the error (e.g. "todo").
+func pathObjectID(c *gin.Context, param, what string) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(c.Param(param))
+	if err != nil {
+		return primitive.NilObjectID, domain.Validation("invalid %s id", what).WithCode("invalid_" + strings.ReplaceAll(what, " ", "_") + "_id")
+	}
+	return id, nil
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type SavedFilterHandler struct {
+	service *services.SavedFilterService
+}
+
+func NewSavedFilterHandler(s *services.SavedFilterService) *SavedFilterHandler {
+	return &SavedFilterHandler{service: s}
+}
+
+type CreateSavedFilterRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Query string `json:"query" validate:"required,max=1000"`
+	Sort  string `json:"sort"`
+}
+
+type UpdateSavedFilterRequest struct {
+	Name  *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Query *string `json:"query" validate:"omitempty,min=1,max=1000"`
+	Sort  *string `json:"sort"`
+}
+
+// checkFilterSort accepts the list endpoint's sort values, or empty for the default sort.
+func checkFilterSort(sort string) error {
+	if sort != "" && !todoSortFields[sort] {
+		return invalidField("sort", "oneof", "must be a sortable field (created_at, updated_at, due_at, title), optionally prefixed with -")
+	}
+	return nil
+}
+
+// @Summary      Create saved filter
+// @Description  Saves a named todo query (a smart list). The query language combines label:, project:, priority:, due: and completed: terms and search words with AND, OR, NOT and parentheses (see the README). Invalid queries fail with invalid_filter. In a workspace it needs the member role.
+// @Tags         filters
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload  body      CreateSavedFilterRequestDTO  true  "Saved filter"
+// @Success      201      {object}  models.SavedFilter
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /filters [post]
+func (h *SavedFilterHandler) Create(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req CreateSavedFilterRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := checkFilterSort(req.Sort); err != nil {
+		c.Error(err)
+		return
+	}
+
+	f, err := h.service.Create(c.Request.Context(), uid, services.SavedFilterInput{Name: req.Name, Query: req.Query, Sort: req.Sort})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, f)
+}
+
+// @Summary      List saved filters
+// @Description  Lists the user's saved filters (or the workspace's), by name.
+// @Tags         filters
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.SavedFilter
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /filters [get]
+func (h *SavedFilterHandler) List(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filters, err := h.service.List(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, filters)
+}
+
+// @Summary      Get saved filter
+// @Tags         filters
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Filter ID"
+// @Success      200  {object}  models.SavedFilter
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /filters/{id} [get]
+func (h *SavedFilterHandler) Get(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	id, err := pathObjectID(c, "id", "filter")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	f, err := h.service.Get(c.Request.Context(), uid, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, f)
+}
+
+// @Summary      Update saved filter
+// @Description  Changes a saved filter's name, query or sort. An empty sort restores the user's default sort.
+// @Tags         filters
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                       true  "Filter ID"
+// @Param        payload  body      UpdateSavedFilterRequestDTO  true  "Fields to update"
+// @Success      200      {object}  models.SavedFilter
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /filters/{id} [patch]
+func (h *SavedFilterHandler) Update(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	id, err := pathObjectID(c, "id", "filter")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req UpdateSavedFilterRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	if req.Name == nil && req.Query == nil && req.Sort == nil {
+		c.Error(invalidField("body", "required", "at least one field is required"))
+		return
+	}
+	if req.Sort != nil {
+		if err := checkFilterSort(*req.Sort); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	f, err := h.service.Update(c.Request.Context(), uid, id, services.SavedFilterPatch{Name: req.Name, Query: req.Query, Sort: req.Sort})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, f)
+}
+
+// @Summary      Delete saved filter
+// @Tags         filters
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Filter ID"
+// @Success      204
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /filters/{id} [delete]
+func (h *SavedFilterHandler) Delete(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	id, err := pathObjectID(c, "id", "filter")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), uid, id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary      Evaluate saved filter
+// @Description  Returns the todos the saved filter matches right now. Relative dates (due:today, due<=+7d, ...) use the user's timezone and week start; without a sort on the filter the user's default sort applies.
+// @Tags         filters
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id             path    string  true   "Filter ID"
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
+// @Success      200  {array}   models.Todo
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /filters/{id}/todos [get]
+func (h *SavedFilterHandler) Todos(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	id, err := pathObjectID(c, "id", "filter")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	todos, err := h.service.Todos(c.Request.Context(), uid, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	etag := listETag(todos)
+	c.Header("ETag", etag)
+	if noneMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, todos)
+}
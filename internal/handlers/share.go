@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/models"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type ShareHandler struct {
+	service *services.SharingService
+}
+
+func NewShareHandler(s *services.SharingService) *ShareHandler {
+	return &ShareHandler{service: s}
+}
+
+type CreateShareRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=viewer editor owner"`
+}
+
+// @Summary      Share todo
+// @Description  Invites a user by email to a todo as viewer, editor or owner (co-owner: may delete and share). The caller must own or co-own the todo. The invitee is emailed and accepts with POST /shares/{id}/accept.
+// @Tags         sharing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                 true  "Todo ID"
+// @Param        payload  body      CreateShareRequestDTO  true  "Invitation"
+// @Success      201      {object}  models.Share
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /todos/{id}/shares [post]
+func (h *ShareHandler) ShareTodo(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	in, err := bindShareInput(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	share, err := h.service.ShareTodo(c.Request.Context(), uid, tid, in)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, share)
+}
+
+// @Summary      List todo shares
+// @Description  Lists the invitations and collaborators of a todo. Any collaborator may see them.
+// @Tags         sharing
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Todo ID"
+// @Success      200  {array}   models.Share
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /todos/{id}/shares [get]
+func (h *ShareHandler) ListTodoShares(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	shares, err := h.service.ListTodoShares(c.Request.Context(), uid, tid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, shares)
+}
+
+// @Summary      Share project
+// @Description  Invites a user by email to all of the caller's todos with this project_id, including ones added later.
+// @Tags         sharing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                 true  "Project ID"
+// @Param        payload  body      CreateShareRequestDTO  true  "Invitation"
+// @Success      201      {object}  models.Share
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /projects/{id}/shares [post]
+func (h *ShareHandler) ShareProject(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	pid, err := pathObjectID(c, "id", "project")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	in, err := bindShareInput(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	share, err := h.service.ShareProject(c.Request.Context(), uid, pid, in)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, share)
+}
+
+// @Summary      List project shares
+// @Description  Lists the invitations and collaborators of one of the caller's projects.
+// @Tags         sharing
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Project ID"
+// @Success      200  {array}   models.Share
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /projects/{id}/shares [get]
+func (h *ShareHandler) ListProjectShares(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	pid, err := pathObjectID(c, "id", "project")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	shares, err := h.service.ListProjectShares(c.Request.Context(), uid, pid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, shares)
+}
+
+// @Summary      List my invitations
+// @Description  Lists the pending invitations and accepted shares addressed to the authenticated user's email.
+// @Tags         sharing
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.Share
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /shares [get]
+func (h *ShareHandler) Incoming(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	shares, err := h.service.Incoming(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, shares)
+}
+
+// @Summary      Accept invitation
+// @Description  Accepts an invitation sent to the authenticated user's email, granting its role.
+// @Tags         sharing
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Share ID"
+// @Success      200  {object}  models.Share
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /shares/{id}/accept [post]
+func (h *ShareHandler) Accept(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	id, err := pathObjectID(c, "id", "share")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	share, err := h.service.Accept(c.Request.Context(), uid, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, share)
+}
+
+// @Summary      Revoke share
+// @Description  Deletes a share or invitation. Owners and co-owners revoke access; the invitee declines or leaves.
+// @Tags         sharing
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Share ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /shares/{id} [delete]
+func (h *ShareHandler) Revoke(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	id, err := pathObjectID(c, "id", "share")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), uid, id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "revoked"})
+}
+
+// @Summary      List todos shared with me
+// @Description  Lists other users' todos shared with the authenticated user, directly or through a project.
+// @Tags         sharing
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.Todo
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /todos/shared [get]
+func (h *ShareHandler) SharedTodos(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	todos, err := h.service.SharedTodos(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, todos)
+}
+
+func bindShareInput(c *gin.Context) (services.ShareInput, error) {
+	var req CreateShareRequest
+	if err := bindJSON(c, &req); err != nil {
+		return services.ShareInput{}, err
+	}
+	return services.ShareInput{Email: req.Email, Role: models.ShareRole(req.Role)}, nil
+}
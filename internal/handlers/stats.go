@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type StatsHandler struct {
+	service *services.StatsService
+}
+
+func NewStatsHandler(s *services.StatsService) *StatsHandler {
+	return &StatsHandler{service: s}
+}
+
+// @Summary      Productivity statistics
+// @Description  Aggregates the user's todos (or the workspace's, with X-Workspace-ID): totals, overdue count, average time from creation to completion, current and longest daily completion streaks, completions per day and per week over the last days days, and breakdowns by project and label. Days and weeks follow the user's timezone and week start.
+// @Tags         stats
+// @Produce      json
+// @Security     BearerAuth
+// @Param        days  query     int  false  "Days of daily/weekly completions (default 30, max 365)"
+// @Success      200   {object}  services.Stats
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /stats [get]
+func (h *StatsHandler) Stats(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var days int
+	if d := c.Query("days"); d != "" {
+		if days, err = strconv.Atoi(d); err != nil || days < 1 {
+			c.Error(invalidField("days", "min", "must be a positive integer"))
+			return
+		}
+	}
+
+	stats, err := h.service.Stats(c.Request.Context(), uid, days)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultSyncLimit = 200
+	maxSyncLimit     = 1000
+)
+
+type SyncHandler struct {
+	service *services.SyncService
+}
+
+func NewSyncHandler(s *services.SyncService) *SyncHandler {
+	return &SyncHandler{service: s}
+}
+
+type SyncPushRequest struct {
+	Strategy string              `json:"strategy" validate:"omitempty,oneof=merge lww"`
+	Changes  []SyncChangeRequest `json:"changes" validate:"required,min=1,max=500,dive"`
+}
+
+type SyncChangeRequest struct {
+	ID              string     `json:"id" validate:"required,mongodb"`
+	Op              string     `json:"op" validate:"required,oneof=create update delete"`
+	BaseVersion     int64      `json:"base_version" validate:"min=0"`
+	ClientUpdatedAt time.Time  `json:"client_updated_at" validate:"required"`
+	Title           *string    `json:"title"`
+	Description     *string    `json:"description"`
+	Completed       *bool      `json:"completed"`
+	DueAt           *time.Time `json:"due_at"`
+	ProjectID       *string    `json:"project_id" validate:"omitempty,mongodb"`
+}
+
+type SyncPushResponse struct {
+	Results []services.SyncResult `json:"results"`
+}
+
+// @Summary      Pull changes
+// @Description  Returns todos changed and deleted (tombstones) since a sync token, oldest first. Omit since for a full sync. Store the returned token and pass it next time; while has_more is true, pull again right away. Apply todos by version, as a page may repeat recent changes.
+// @Tags         sync
+// @Produce      json
+// @Security     BearerAuth
+// @Param        since  query     string  false  "Token from the previous pull"
+// @Param        limit  query     int     false  "Max todos per page (default 200, max 1000)"
+// @Success      200    {object}  services.SyncChanges
+// @Failure      400    {object}  ErrorResponse
+// @Failure      401    {object}  ErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Router       /sync [get]
+func (h *SyncHandler) Pull(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	limit := int64(defaultSyncLimit)
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.ParseInt(l, 10, 64)
+		if err != nil || n < 1 || n > maxSyncLimit {
+			c.Error(invalidField("limit", "range", "must be between 1 and 1000"))
+			return
+		}
+		limit = n
+	}
+
+	changes, err := h.service.Pull(c.Request.Context(), uid, c.Query("since"), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+// @Summary      Push changes
+// @Description  Applies a batch of offline changes in order. Creates use a client-generated ObjectID and are idempotent. Updates and deletes send the base_version they were made against; if the todo changed since, strategy merge (default) only conflicts on fields both sides changed, lww treats the whole record as conflicting, and the later of client_updated_at and the server change wins. Deleted todos are never resurrected. Each result reports applied, merged, rejected or error.
+// @Tags         sync
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload  body      SyncPushRequestDTO  true  "Client changes"
+// @Success      200      {object}  SyncPushResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /sync [post]
+func (h *SyncHandler) Push(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req SyncPushRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	changes := make([]services.SyncChange, len(req.Changes))
+	for i, r := range req.Changes {
+		id, _ := primitive.ObjectIDFromHex(r.ID)
+		projectID, _ := parseOptionalObjectID(r.ProjectID)
+		changes[i] = services.SyncChange{
+			ID:              id,
+			Op:              r.Op,
+			BaseVersion:     r.BaseVersion,
+			ClientUpdatedAt: r.ClientUpdatedAt,
+			Title:           r.Title,
+			Description:     r.Description,
+			Completed:       r.Completed,
+			DueAt:           r.DueAt,
+			ProjectID:       projectID,
+		}
+	}
+
+	results, err := h.service.Push(c.Request.Context(), uid, req.Strategy, changes)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, SyncPushResponse{Results: results})
+}
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type TimeHandler struct {
+	service *services.TimeTrackingService
+}
+
+func NewTimeHandler(s *services.TimeTrackingService) *TimeHandler {
+	return &TimeHandler{service: s}
+}
+
+type StartTimerRequest struct {
+	Note string `json:"note" validate:"max=500"`
+}
+
+type CreateTimeEntryRequest struct {
+	StartedAt time.Time  `json:"started_at" validate:"required"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Minutes   int        `json:"minutes" validate:"min=0,max=1440"`
+	Note      string     `json:"note" validate:"max=500"`
+}
+
+type UpdateTimeEntryRequest struct {
+	StartedAt *time.Time `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Note      *string    `json:"note" validate:"omitempty,max=500"`
+}
+
+// @Summary      Start timer
+// @Description  Starts the user's timer on a todo. A user has one running timer at a time; starting another fails with timer_running. Collaborators need the editor role.
+// @Tags         time
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                true   "Todo ID"
+// @Param        payload  body      StartTimerRequestDTO  false  "Optional note"
+// @Success      201      {object}  models.TimeEntry
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /todos/{id}/timer/start [post]
+func (h *TimeHandler) StartTimer(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req StartTimerRequest
+	if c.Request.ContentLength > 0 {
+		if err := bindJSON(c, &req); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	entry, err := h.service.StartTimer(c.Request.Context(), uid, tid, req.Note)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+}
+
+// @Summary      Stop timer
+// @Description  Stops the user's running timer on a todo and returns the finished entry.
+// @Tags         time
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Todo ID"
+// @Success      200  {object}  models.TimeEntry
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /todos/{id}/timer/stop [post]
+func (h *TimeHandler) StopTimer(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	entry, err := h.service.StopTimer(c.Request.Context(), uid, tid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// @Summary      Running timer
+// @Description  Returns the user's running timer, with seconds counted up to now, or 404 timer_not_running.
+// @Tags         time
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.TimeEntry
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /timer [get]
+func (h *TimeHandler) Running(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	entry, err := h.service.RunningTimer(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// @Summary      List time entries
+// @Description  Lists everyone's time entries on a todo, oldest first.
+// @Tags         time
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Todo ID"
+// @Success      200  {array}   models.TimeEntry
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /todos/{id}/time-entries [get]
+func (h *TimeHandler) ListEntries(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	entries, err := h.service.ListEntries(c.Request.Context(), uid, tid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// @Summary      Add time entry
+// @Description  Records time on a todo by hand: started_at plus either ended_at or minutes. Entries cannot end in the future or span more than 24 hours. Collaborators need the editor role.
+// @Tags         time
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                     true  "Todo ID"
+// @Param        payload  body      CreateTimeEntryRequestDTO  true  "Time entry"
+// @Success      201      {object}  models.TimeEntry
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /todos/{id}/time-entries [post]
+func (h *TimeHandler) CreateEntry(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req CreateTimeEntryRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	entry, err := h.service.CreateEntry(c.Request.Context(), uid, tid, services.TimeEntryInput{
+		StartedAt: req.StartedAt,
+		EndedAt:   req.EndedAt,
+		Minutes:   req.Minutes,
+		Note:      req.Note,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+}
+
+// @Summary      Update time entry
+// @Description  Edits one of the user's own time entries. A running timer's ended_at can only be set by stopping it.
+// @Tags         time
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                     true  "Time entry ID"
+// @Param        payload  body      UpdateTimeEntryRequestDTO  true  "Fields to update"
+// @Success      200      {object}  models.TimeEntry
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /time-entries/{id} [patch]
+func (h *TimeHandler) UpdateEntry(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	eid, err := pathObjectID(c, "id", "time entry")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req UpdateTimeEntryRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	if req.StartedAt == nil && req.EndedAt == nil && req.Note == nil {
+		c.Error(invalidField("body", "required", "at least one field is required"))
+		return
+	}
+
+	entry, err := h.service.UpdateEntry(c.Request.Context(), uid, eid, services.TimeEntryPatch{
+		StartedAt: req.StartedAt,
+		EndedAt:   req.EndedAt,
+		Note:      req.Note,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// @Summary      Delete time entry
+// @Description  Deletes one of the user's own time entries, including a running timer.
+// @Tags         time
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Time entry ID"
+// @Success      204
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /time-entries/{id} [delete]
+func (h *TimeHandler) DeleteEntry(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	eid, err := pathObjectID(c, "id", "time entry")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.DeleteEntry(c.Request.Context(), uid, eid); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary      Time report
+// @Description  Sums the user's tracked time between two dates (inclusive, in the user's timezone; the last 30 days by default, at most 366) grouped by day, project or label. Entries count on the day they started; with group_by=label an entry counts under each label of its todo. format=csv returns the rows as CSV.
+// @Tags         time
+// @Produce      json,text/csv
+// @Security     BearerAuth
+// @Param        from      query     string  false  "First day (YYYY-MM-DD)"
+// @Param        to        query     string  false  "Last day (YYYY-MM-DD)"
+// @Param        group_by  query     string  false  "Grouping"  Enums(day, project, label)  default(day)
+// @Param        format    query     string  false  "Output format"  Enums(json, csv)  default(json)
+// @Success      200       {object}  services.TimeReport
+// @Failure      400       {object}  ErrorResponse
+// @Failure      401       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
+// @Router       /time-report [get]
+func (h *TimeHandler) Report(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	groupBy := strings.ToLower(c.DefaultQuery("group_by", services.TimeByDay))
+	switch groupBy {
+	case services.TimeByDay, services.TimeByProject, services.TimeByLabel:
+	default:
+		c.Error(invalidField("group_by", "oneof", "must be day, project or label"))
+		return
+	}
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "csv" {
+		c.Error(invalidField("format", "oneof", "must be json or csv"))
+		return
+	}
+
+	report, err := h.service.Report(c.Request.Context(), uid, c.Query("from"), c.Query("to"), groupBy)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="time-report.csv"`)
+		if err := services.WriteTimeReportCSV(c.Writer, report); err != nil {
+			c.Error(err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
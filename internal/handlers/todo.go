@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
 	"github.com/group14000/golang-todo/internal/services"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -19,18 +22,66 @@ func NewTodoHandler(s *services.TodoService) *TodoHandler {
 }
 
 type CreateTodoRequest struct {
-	Title       string `json:"title" validate:"required"`
-	Description string `json:"description"`
+	Title       string             `json:"title" validate:"required"`
+	Description string             `json:"description"`
+	DueAt       *time.Time         `json:"due_at"`
+	ProjectID   *string            `json:"project_id"`
+	AssigneeID  *string            `json:"assignee_id"`
+	Labels      []string           `json:"labels" validate:"max=20"`
+	Priority    string             `json:"priority" validate:"omitempty,oneof=low normal high urgent"`
+	Recurrence  *RecurrenceRequest `json:"recurrence"`
+	// EstimateMinutes of 0 means no estimate.
+	EstimateMinutes *int `json:"estimate_minutes" validate:"omitempty,min=0,max=100000"`
 }
 
 type UpdateTodoRequest struct {
-	Title       *string `json:"title"`
-	Description *string `json:"description"`
-	Completed   *bool   `json:"completed"`
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	Completed   *bool      `json:"completed"`
+	Status      *string    `json:"status"`
+	DueAt       *time.Time `json:"due_at"`
+	// ClearDueAt removes the due date; ignored when due_at is set.
+	ClearDueAt bool    `json:"clear_due_at"`
+	ProjectID  *string `json:"project_id"`
+	// AssigneeID "" unassigns the todo.
+	AssigneeID *string   `json:"assignee_id"`
+	Labels     *[]string `json:"labels" validate:"omitempty,max=20"`
+	Priority   *string   `json:"priority" validate:"omitempty,oneof=low normal high urgent"`
+	// Recurrence with an empty frequency stops the todo repeating.
+	Recurrence *RecurrenceRequest `json:"recurrence"`
+	// EstimateMinutes 0 removes the estimate.
+	EstimateMinutes *int `json:"estimate_minutes" validate:"omitempty,min=0,max=100000"`
+}
+
+type RecurrenceRequest struct {
+	Frequency string   `json:"frequency" validate:"omitempty,oneof=daily weekly monthly yearly"`
+	Interval  int      `json:"interval" validate:"min=0,max=365"`
+	Weekdays  []string `json:"weekdays" validate:"max=7,dive,oneof=sun mon tue wed thu fri sat"`
+	MonthDay  int      `json:"month_day" validate:"min=0,max=31"`
+}
+
+// model returns nil for a missing recurrence or one without a frequency.
+func (r *RecurrenceRequest) model() *models.Recurrence {
+	if r == nil || r.Frequency == "" {
+		return nil
+	}
+	return &models.Recurrence{Frequency: r.Frequency, Interval: r.Interval, Weekdays: r.Weekdays, MonthDay: r.MonthDay}
+}
+
+// parseOptionalObjectID converts an optional hex string into an ObjectID pointer.
+func parseOptionalObjectID(s *string) (*primitive.ObjectID, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	id, err := primitive.ObjectIDFromHex(*s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
 }
 
 // @Summary      Create todo
-// @Description  Creates a new todo item for the authenticated user.
+// @Description  Creates a new todo item for the authenticated user. assignee_id must be the user or a collaborator the todo's project is shared with. A todo with a recurrence is copied, due at its next occurrence, when it is completed.
 // @Tags         todos
 // @Accept       json
 // @Produce      json
@@ -42,168 +93,386 @@ type UpdateTodoRequest struct {
 // @Failure      500      {object}  ErrorResponse
 // @Router       /todos [post]
 func (h *TodoHandler) Create(c *gin.Context) {
-	userIDStr := c.GetString("user_id")
-	uid, err := primitive.ObjectIDFromHex(userIDStr)
+	uid, err := currentUserID(c)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		c.Error(err)
 		return
 	}
 
 	var req CreateTodoRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	projectID, err := parseOptionalObjectID(req.ProjectID)
+	if err != nil {
+		c.Error(invalidField("project_id", "objectid", "must be a project ID"))
 		return
 	}
-	v := validator.New()
-	if err := v.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	assigneeID, err := parseOptionalObjectID(req.AssigneeID)
+	if err != nil {
+		c.Error(invalidField("assignee_id", "objectid", "must be a user ID"))
 		return
 	}
 
-	todo, err := h.service.Create(c.Request.Context(), uid, req.Title, req.Description)
+	todo, err := h.service.Create(c.Request.Context(), uid, services.TodoInput{
+		Title:           req.Title,
+		Description:     req.Description,
+		DueAt:           req.DueAt,
+		ProjectID:       projectID,
+		AssigneeID:      assigneeID,
+		Labels:          req.Labels,
+		Priority:        req.Priority,
+		Recurrence:      req.Recurrence.model(),
+		EstimateMinutes: req.EstimateMinutes,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create todo"})
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", todoETag(todo))
 	c.JSON(http.StatusCreated, todo)
 }
 
 // @Summary      List todos
-// @Description  Lists all todos for the authenticated user.
+// @Description  Lists todos for the authenticated user. Due windows (today, this_week, ...) are computed in the user's timezone and week start; without sort the user's default sort applies. Archived todos are left out (see /todos/archive).
 // @Tags         todos
 // @Produce      json
 // @Security     BearerAuth
+// @Param        due         query     string  false  "Due window"  Enums(today, tomorrow, this_week, next_week, overdue)
+// @Param        project_id  query     string  false  "Filter by project ID"
+// @Param        completed   query     bool    false  "Filter by completion"
+// @Param        label       query     string  false  "Filter by label"
+// @Param        sort        query     string  false  "Sort field, prefix with - for descending"  Enums(created_at, -created_at, updated_at, -updated_at, due_at, -due_at, title, -title)
+// @Param        If-None-Match  header  string  false  "ETag from a previous list response"
 // @Success      200  {array}   models.Todo
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  ErrorResponse
 // @Failure      401  {object}  ErrorResponse
 // @Failure      500  {object}  ErrorResponse
 // @Router       /todos [get]
 func (h *TodoHandler) List(c *gin.Context) {
-	userIDStr := c.GetString("user_id")
-	uid, err := primitive.ObjectIDFromHex(userIDStr)
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	q, err := parseTodoListQuery(c)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		c.Error(err)
 		return
 	}
 
-	todos, err := h.service.List(c.Request.Context(), uid)
+	todos, err := h.service.List(c.Request.Context(), uid, q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list todos"})
+		c.Error(err)
+		return
+	}
+
+	etag := listETag(todos)
+	c.Header("ETag", etag)
+	if noneMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, todos)
+}
+
+// @Summary      List todos assigned to me
+// @Description  Lists todos assigned to the authenticated user, their own and those shared with them. Accepts the same filters as GET /todos.
+// @Tags         todos
+// @Produce      json
+// @Security     BearerAuth
+// @Param        due         query     string  false  "Due window"  Enums(today, tomorrow, this_week, next_week, overdue)
+// @Param        project_id  query     string  false  "Filter by project ID"
+// @Param        completed   query     bool    false  "Filter by completion"
+// @Param        label       query     string  false  "Filter by label"
+// @Param        sort        query     string  false  "Sort field, prefix with - for descending"  Enums(created_at, -created_at, updated_at, -updated_at, due_at, -due_at, title, -title)
+// @Success      200  {array}   models.Todo
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /todos/assigned [get]
+func (h *TodoHandler) Assigned(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	q, err := parseTodoListQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	todos, err := h.service.ListAssigned(c.Request.Context(), uid, q)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, todos)
 }
 
 // @Summary      Get todo
-// @Description  Retrieves a single todo by ID.
+// @Description  Retrieves a single todo by ID, including todos shared with the user. The ETag header carries the todo version for use with If-Match / If-None-Match.
 // @Tags         todos
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id   path      string  true  "Todo ID"
+// @Param        id             path    string  true   "Todo ID"
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
 // @Success      200  {object}  models.Todo
+// @Success      304  "Not Modified"
 // @Failure      400  {object}  ErrorResponse
 // @Failure      401  {object}  ErrorResponse
 // @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
 // @Router       /todos/{id} [get]
 func (h *TodoHandler) Get(c *gin.Context) {
-	userIDStr := c.GetString("user_id")
-	uid, err := primitive.ObjectIDFromHex(userIDStr)
+	uid, err := currentUserID(c)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		c.Error(err)
 		return
 	}
-	id := c.Param("id")
-	tid, err := primitive.ObjectIDFromHex(id)
+	tid, err := pathObjectID(c, "id", "todo")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid todo id"})
+		c.Error(err)
 		return
 	}
 
 	todo, err := h.service.Get(c.Request.Context(), uid, tid)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+		c.Error(err)
+		return
+	}
+
+	etag := todoETag(todo)
+	c.Header("ETag", etag)
+	if noneMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 	c.JSON(http.StatusOK, todo)
 }
 
 // @Summary      Update todo
-// @Description  Partially updates a todo.
+// @Description  Partially updates a todo. Send If-Match with the ETag from GET to avoid overwriting concurrent edits. Collaborators need the editor role; assignee_id "" unassigns, clear_due_at=true removes the due date and a recurrence without frequency stops repeating. status moves the todo to a column of its project's workflow and sets completed to match (and vice versa). Completing a todo whose blockers are still open (todo_blocked), a move the workflow's transitions forbid (status_transition_not_allowed) or one into a full column (wip_limit_reached) fails unless force=true.
 // @Tags         todos
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id       path      string                 true  "Todo ID"
-// @Param        payload  body      UpdateTodoRequestDTO   true  "Update todo"
-// @Success      200      {object}  map[string]string
+// @Param        id        path      string                 true   "Todo ID"
+// @Param        If-Match  header    string                 false  "ETag the update is based on"
+// @Param        force     query     bool                   false  "Skip the blocker, transition and WIP limit checks"
+// @Param        payload   body      UpdateTodoRequestDTO   true   "Update todo"
+// @Success      200      {object}  models.Todo
 // @Failure      400      {object}  ErrorResponse
 // @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      412      {object}  ErrorResponse
 // @Failure      500      {object}  ErrorResponse
 // @Router       /todos/{id} [patch]
 func (h *TodoHandler) Update(c *gin.Context) {
-	userIDStr := c.GetString("user_id")
-	uid, err := primitive.ObjectIDFromHex(userIDStr)
+	uid, err := currentUserID(c)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		c.Error(err)
 		return
 	}
-	id := c.Param("id")
-	tid, err := primitive.ObjectIDFromHex(id)
+	tid, err := pathObjectID(c, "id", "todo")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid todo id"})
+		c.Error(err)
 		return
 	}
 
 	var req UpdateTodoRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if req.Title == nil && req.Description == nil && req.Completed == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+	if req.Title == nil && req.Description == nil && req.Completed == nil && req.Status == nil && req.DueAt == nil && !req.ClearDueAt && req.ProjectID == nil && req.AssigneeID == nil && req.Labels == nil && req.Priority == nil && req.Recurrence == nil && req.EstimateMinutes == nil {
+		c.Error(domain.Validation("no fields to update").WithCode("empty_update"))
 		return
 	}
 
-	if err := h.service.Update(c.Request.Context(), uid, tid, req.Title, req.Description, req.Completed); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update todo"})
+	projectID, err := parseOptionalObjectID(req.ProjectID)
+	if err != nil {
+		c.Error(invalidField("project_id", "objectid", "must be a project ID"))
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+	assigneeID, err := parseOptionalObjectID(req.AssigneeID)
+	if err != nil {
+		c.Error(invalidField("assignee_id", "objectid", "must be a user ID"))
+		return
+	}
+
+	force := false
+	if v := c.Query("force"); v != "" {
+		if force, err = strconv.ParseBool(v); err != nil {
+			c.Error(invalidField("force", "type", "must be true or false"))
+			return
+		}
+	}
+
+	ifVersions, ok := parseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		c.Error(domain.PreconditionFailed("If-Match does not match the current version").WithCode("version_mismatch"))
+		return
+	}
+
+	update := services.TodoUpdate{
+		Title:       req.Title,
+		Description: req.Description,
+		Completed:   req.Completed,
+		Status:      req.Status,
+		DueAt:       req.DueAt,
+		ClearDueAt:  req.ClearDueAt,
+		ProjectID:   projectID,
+		AssigneeID:  assigneeID,
+		Labels:      req.Labels,
+		Priority:    req.Priority,
+		Recurrence:  req.Recurrence.model(),
+		// parseOptionalObjectID maps "" to nil, so a non-nil empty string means unassign.
+		ClearAssignee:   req.AssigneeID != nil && assigneeID == nil,
+		ClearRecurrence: req.Recurrence != nil && req.Recurrence.model() == nil,
+		EstimateMinutes: req.EstimateMinutes,
+		Force:           force,
+		IfVersions:      ifVersions,
+	}
+	todo, err := h.service.Update(c.Request.Context(), uid, tid, update)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("ETag", todoETag(todo))
+	c.JSON(http.StatusOK, todo)
 }
 
 // @Summary      Delete todo
-// @Description  Deletes a todo by ID.
+// @Description  Soft-deletes a todo by ID. It can be brought back with POST /todos/{id}/restore. Honors If-Match. Collaborators need the owner role.
 // @Tags         todos
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id   path      string  true  "Todo ID"
+// @Param        id        path    string  true   "Todo ID"
+// @Param        If-Match  header  string  false  "ETag the delete is based on"
 // @Success      200  {object}  map[string]string
 // @Failure      400  {object}  ErrorResponse
 // @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      412  {object}  ErrorResponse
 // @Failure      500  {object}  ErrorResponse
 // @Router       /todos/{id} [delete]
 func (h *TodoHandler) Delete(c *gin.Context) {
-	userIDStr := c.GetString("user_id")
-	uid, err := primitive.ObjectIDFromHex(userIDStr)
+	uid, err := currentUserID(c)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		c.Error(err)
 		return
 	}
-	id := c.Param("id")
-	tid, err := primitive.ObjectIDFromHex(id)
+	tid, err := pathObjectID(c, "id", "todo")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid todo id"})
+		c.Error(err)
+		return
+	}
+
+	ifVersions, ok := parseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		c.Error(domain.PreconditionFailed("If-Match does not match the current version").WithCode("version_mismatch"))
 		return
 	}
 
-	if err := h.service.Delete(c.Request.Context(), uid, tid); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete todo"})
+	if err := h.service.Delete(c.Request.Context(), uid, tid, ifVersions); err != nil {
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
+// @Summary      Restore todo
+// @Description  Restores a previously deleted todo. Collaborators need the owner role.
+// @Tags         todos
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Todo ID"
+// @Success      200  {object}  models.Todo
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /todos/{id}/restore [post]
+func (h *TodoHandler) Restore(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	todo, err := h.service.Restore(c.Request.Context(), uid, tid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("ETag", todoETag(todo))
+	c.JSON(http.StatusOK, todo)
+}
+
+var todoSortFields = map[string]bool{
+	"created_at": true, "-created_at": true,
+	"updated_at": true, "-updated_at": true,
+	"due_at": true, "-due_at": true,
+	"title": true, "-title": true,
+}
+
+var todoDueWindows = map[string]bool{
+	services.DueToday: true, services.DueTomorrow: true,
+	services.DueThisWeek: true, services.DueNextWeek: true,
+	services.DueOverdue: true,
+}
+
+// parseTodoListQuery validates the list query string.
+func parseTodoListQuery(c *gin.Context) (services.TodoListQuery, error) {
+	var q services.TodoListQuery
+	if due := c.Query("due"); due != "" {
+		if !todoDueWindows[due] {
+			return q, invalidField("due", "oneof", "must be one of: today, tomorrow, this_week, next_week, overdue")
+		}
+		q.Due = due
+	}
+	if sort := c.Query("sort"); sort != "" {
+		if !todoSortFields[sort] {
+			return q, invalidField("sort", "oneof", "must be a sortable field (created_at, updated_at, due_at, title), optionally prefixed with -")
+		}
+		q.Sort = sort
+	}
+	if pid := c.Query("project_id"); pid != "" {
+		id, err := primitive.ObjectIDFromHex(pid)
+		if err != nil {
+			return q, invalidField("project_id", "objectid", "must be a project ID")
+		}
+		q.ProjectID = &id
+	}
+	q.Label = strings.ToLower(strings.TrimSpace(c.Query("label")))
+	if completed := c.Query("completed"); completed != "" {
+		b, err := strconv.ParseBool(completed)
+		if err != nil {
+			return q, invalidField("completed", "boolean", "must be true or false")
+		}
+		q.Completed = &b
+	}
+	return q, nil
+}
+
 // Simple health handler if needed
 func (h *TodoHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok", "time": time.Now()})
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/domain"
+)
+
+// @Summary      List archived todos
+// @Description  Lists archived todos, most recently archived first. Page with cursor=<next_cursor of the previous page>; next_cursor is omitted on the last page.
+// @Tags         todos
+// @Produce      json
+// @Security     BearerAuth
+// @Param        cursor  query     string  false  "next_cursor from the previous page"
+// @Param        limit   query     int     false  "Max todos (default 50, max 200)"
+// @Success      200     {object}  services.ArchivePage
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /todos/archive [get]
+func (h *TodoHandler) Archived(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var limit int64
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.ParseInt(l, 10, 64)
+		if err != nil || n < 1 {
+			c.Error(invalidField("limit", "min", "must be a positive integer"))
+			return
+		}
+		limit = n
+	}
+
+	page, err := h.service.ListArchived(c.Request.Context(), uid, c.Query("cursor"), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// @Summary      Archive todo
+// @Description  Archives a todo: it is kept as is but left out of the default lists until unarchived. Completed todos are also archived automatically after the owner's auto_archive_days, if they set one. Collaborators need the editor role.
+// @Tags         todos
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string  true   "Todo ID"
+// @Param        If-Match  header    string  false  "ETag of the version being archived"
+// @Success      200       {object}  models.Todo
+// @Failure      400       {object}  ErrorResponse
+// @Failure      401       {object}  ErrorResponse
+// @Failure      403       {object}  ErrorResponse
+// @Failure      404       {object}  ErrorResponse
+// @Failure      412       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
+// @Router       /todos/{id}/archive [post]
+func (h *TodoHandler) Archive(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+// @Summary      Unarchive todo
+// @Description  Brings an archived todo back to the default lists. Reopening an archived todo unarchives it too. Collaborators need the editor role.
+// @Tags         todos
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string  true   "Todo ID"
+// @Param        If-Match  header    string  false  "ETag of the version being unarchived"
+// @Success      200       {object}  models.Todo
+// @Failure      400       {object}  ErrorResponse
+// @Failure      401       {object}  ErrorResponse
+// @Failure      403       {object}  ErrorResponse
+// @Failure      404       {object}  ErrorResponse
+// @Failure      412       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
+// @Router       /todos/{id}/unarchive [post]
+func (h *TodoHandler) Unarchive(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+func (h *TodoHandler) setArchived(c *gin.Context, archived bool) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ifVersions, ok := parseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		c.Error(domain.PreconditionFailed("If-Match does not match the current version").WithCode("version_mismatch"))
+		return
+	}
+
+	todo, err := h.service.SetArchived(c.Request.Context(), uid, tid, archived, ifVersions)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("ETag", todoETag(todo))
+	c.JSON(http.StatusOK, todo)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AddDependencyRequest struct {
+	BlockerID string `json:"blocker_id" validate:"required"`
+}
+
+// @Summary      Add dependency
+// @Description  Marks a todo as blocked by another todo of the same owner or workspace. Fails with dependency_cycle if the blocker already waits on the todo. Adding an existing dependency is a no-op. Collaborators need the editor role.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                   true  "Todo ID"
+// @Param        payload  body      AddDependencyRequestDTO  true  "Blocking todo"
+// @Success      200      {object}  models.Todo
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      412      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /todos/{id}/dependencies [post]
+func (h *TodoHandler) AddDependency(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req AddDependencyRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	blockerID, err := primitive.ObjectIDFromHex(req.BlockerID)
+	if err != nil {
+		c.Error(invalidField("blocker_id", "objectid", "must be a todo ID"))
+		return
+	}
+
+	todo, err := h.service.AddBlocker(c.Request.Context(), uid, tid, blockerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("ETag", todoETag(todo))
+	c.JSON(http.StatusOK, todo)
+}
+
+// @Summary      Remove dependency
+// @Description  Removes a todo's dependency on a blocking todo. Collaborators need the editor role.
+// @Tags         todos
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      string  true  "Todo ID"
+// @Param        blockerId  path      string  true  "Blocking todo ID"
+// @Success      200        {object}  models.Todo
+// @Failure      400        {object}  ErrorResponse
+// @Failure      401        {object}  ErrorResponse
+// @Failure      403        {object}  ErrorResponse
+// @Failure      404        {object}  ErrorResponse
+// @Failure      412        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /todos/{id}/dependencies/{blockerId} [delete]
+func (h *TodoHandler) RemoveDependency(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	blockerID, err := pathObjectID(c, "blockerId", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	todo, err := h.service.RemoveBlocker(c.Request.Context(), uid, tid, blockerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("ETag", todoETag(todo))
+	c.JSON(http.StatusOK, todo)
+}
+
+// @Summary      Dependency graph
+// @Description  Returns the dependency DAG around a todo: every todo it transitively waits on and every todo transitively waiting on it, up to 500 nodes. Edges point from the blocker to the todo it blocks. Collaborators only see the todos shared with them.
+// @Tags         todos
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Todo ID"
+// @Success      200  {object}  services.TodoGraph
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /todos/{id}/graph [get]
+func (h *TodoHandler) Graph(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tid, err := pathObjectID(c, "id", "todo")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	graph, err := h.service.Graph(c.Request.Context(), uid, tid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, graph)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuickAddRequest struct {
+	Text string `json:"text" validate:"required,max=1000"`
+}
+
+// @Summary      Quick add todo
+// @Description  Creates a todo from one line of text such as "Pay rent every 1st of month at 9am #finance !high @<project id>". Dates (today, tomorrow, friday, next week, march 5, 2025-03-05, in 3 days), times (9am, 17:30, at 9, noon), recurrences (every day, every 2 weeks, every mon and thu, every weekday, every 1st of the month), #labels, !priority (low, normal, high, urgent or !1-!4) and @project are taken out of the title; dates are read in the user's timezone. The parser is deterministic and runs in-process. dry_run=true only returns the parse.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        dry_run  query     bool                 false  "Return the parse without creating the todo"
+// @Param        payload  body      QuickAddRequestDTO  true   "Text to parse"
+// @Success      200      {object}  services.QuickAddResult  "Dry run"
+// @Success      201      {object}  services.QuickAddResult
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /todos/quick [post]
+func (h *TodoHandler) QuickAdd(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	dryRun := false
+	if v := c.Query("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			c.Error(invalidField("dry_run", "type", "must be true or false"))
+			return
+		}
+	}
+	var req QuickAddRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.service.QuickAdd(c.Request.Context(), uid, req.Text, dryRun)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	c.Header("ETag", todoETag(result.Todo))
+	c.JSON(http.StatusCreated, result)
+}
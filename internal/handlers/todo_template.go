@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/models"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type TodoTemplateHandler struct {
+	service *services.TodoTemplateService
+}
+
+func NewTodoTemplateHandler(s *services.TodoTemplateService) *TodoTemplateHandler {
+	return &TodoTemplateHandler{service: s}
+}
+
+type TodoTemplateRequest struct {
+	Name          string                   `json:"name" validate:"required,max=100"`
+	Title         string                   `json:"title" validate:"required,max=500"`
+	Description   string                   `json:"description" validate:"max=10000"`
+	Labels        []string                 `json:"labels" validate:"max=20"`
+	DueOffsetDays *int                     `json:"due_offset_days" validate:"omitempty,min=0,max=3650"`
+	Subtasks      []TemplateSubtaskRequest `json:"subtasks" validate:"max=50,dive"`
+}
+
+type TemplateSubtaskRequest struct {
+	Title         string   `json:"title" validate:"required,max=500"`
+	Description   string   `json:"description" validate:"max=10000"`
+	Labels        []string `json:"labels" validate:"max=20"`
+	DueOffsetDays *int     `json:"due_offset_days" validate:"omitempty,min=0,max=3650"`
+}
+
+type InstantiateTemplateRequest struct {
+	Variables map[string]string `json:"variables"`
+	Date      string            `json:"date" validate:"omitempty,datetime=2006-01-02"`
+	ProjectID *string           `json:"project_id"`
+}
+
+func (r TodoTemplateRequest) input() services.TodoTemplateInput {
+	in := services.TodoTemplateInput{
+		Name:          r.Name,
+		Title:         r.Title,
+		Description:   r.Description,
+		Labels:        r.Labels,
+		DueOffsetDays: r.DueOffsetDays,
+	}
+	for _, st := range r.Subtasks {
+		in.Subtasks = append(in.Subtasks, models.TemplateSubtask{
+			Title:         st.Title,
+			Description:   st.Description,
+			Labels:        st.Labels,
+			DueOffsetDays: st.DueOffsetDays,
+		})
+	}
+	return in
+}
+
+// @Summary      Create template
+// @Description  Saves a reusable checklist: a main todo with optional subtasks, labels and due dates relative to the day it is instantiated (due_offset_days). Text may contain {{variables}} such as {{name}}; {{date}} is built in. In a workspace it needs the member role.
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload  body      TodoTemplateRequestDTO  true  "Template"
+// @Success      201      {object}  services.TodoTemplateView
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /templates [post]
+func (h *TodoTemplateHandler) Create(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req TodoTemplateRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	tmpl, err := h.service.Create(c.Request.Context(), uid, req.input())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, tmpl)
+}
+
+// @Summary      List templates
+// @Description  Lists the user's templates (or the workspace's), by name, with the variables each one needs.
+// @Tags         templates
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   services.TodoTemplateView
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /templates [get]
+func (h *TodoTemplateHandler) List(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	templates, err := h.service.List(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// @Summary      Get template
+// @Tags         templates
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Template ID"
+// @Success      200  {object}  services.TodoTemplateView
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /templates/{id} [get]
+func (h *TodoTemplateHandler) Get(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	id, err := pathObjectID(c, "id", "template")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	tmpl, err := h.service.Get(c.Request.Context(), uid, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// @Summary      Replace template
+// @Description  Replaces every field of a template. Todos already created from it are not changed.
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                  true  "Template ID"
+// @Param        payload  body      TodoTemplateRequestDTO  true  "Template"
+// @Success      200      {object}  services.TodoTemplateView
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /templates/{id} [put]
+func (h *TodoTemplateHandler) Update(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	id, err := pathObjectID(c, "id", "template")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req TodoTemplateRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	tmpl, err := h.service.Update(c.Request.Context(), uid, id, req.input())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// @Summary      Delete template
+// @Tags         templates
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Template ID"
+// @Success      204
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /templates/{id} [delete]
+func (h *TodoTemplateHandler) Delete(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	id, err := pathObjectID(c, "id", "template")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), uid, id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary      Instantiate template
+// @Description  Creates the template's todo and its subtasks in one transaction, main todo first; each subtask blocks the main todo. {{variables}} are replaced from variables, and {{date}} and due offsets count from date (default today in the user's timezone). Missing values fail with missing_variables and nothing is created.
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                          true   "Template ID"
+// @Param        payload  body      InstantiateTemplateRequestDTO  false  "Variables"
+// @Success      201      {array}   models.Todo
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /templates/{id}/instantiate [post]
+func (h *TodoTemplateHandler) Instantiate(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	id, err := pathObjectID(c, "id", "template")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req InstantiateTemplateRequest
+	if c.Request.ContentLength > 0 {
+		if err := bindJSON(c, &req); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	projectID, err := parseOptionalObjectID(req.ProjectID)
+	if err != nil {
+		c.Error(invalidField("project_id", "objectid", "must be a project ID"))
+		return
+	}
+
+	todos, err := h.service.Instantiate(c.Request.Context(), uid, id, services.InstantiateInput{
+		Variables: req.Variables,
+		Date:      req.Date,
+		ProjectID: projectID,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, todos)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/middleware"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+// maxImportBytes caps the size of an uploaded import file.
+const maxImportBytes = 5 << 20
+
+// @Summary      Export todos
+// @Description  Downloads all of the user's todos, oldest first, as csv, json (same objects as GET /todos), todotxt, markdown (a task list) or ical (VTODOs). Todo.txt and Markdown keep title, completion and due date (due:YYYY-MM-DD in the user's timezone); Markdown also keeps the description. Every format can be imported again.
+// @Tags         todos
+// @Produce      text/csv,application/json,text/plain,text/markdown
+// @Security     BearerAuth
+// @Param        format  query     string  false  "Export format (default json)"  Enums(csv, json, todotxt, markdown, ical)
+// @Success      200     {file}    file
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /todos/export [get]
+func (h *TodoHandler) Export(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	format, ok := services.ParseTransferFormat(c.DefaultQuery("format", string(services.FormatJSON)), "")
+	if !ok {
+		c.Error(invalidField("format", "oneof", "must be one of: csv, json, todotxt, markdown, ical"))
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="`+format.FileName()+`"`)
+	c.Status(http.StatusOK)
+	if err := h.service.Export(c.Request.Context(), uid, format, c.Writer); err != nil {
+		if c.Writer.Written() {
+			// Too late for an error response; the client sees a truncated file.
+			log.Printf("[%s] todo export: %v", middleware.RequestIDFrom(c), err)
+			return
+		}
+		c.Writer.Header().Del("Content-Disposition")
+		c.Error(err)
+	}
+}
+
+// @Summary      Import todos
+// @Description  Creates todos from an uploaded csv, json, todotxt, markdown or ical (VTODO) file (up to 5 MB and 5000 rows); the format defaults to the file extension. CSV needs a header with a title column and may have description, completed, due_at and project_id. Rows matching an existing todo or an earlier row by title and due date are skipped as duplicates unless duplicates=allow. Bad rows are reported with a code and do not stop the import. Use dry_run=true to preview.
+// @Tags         todos
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file        formData  file    true   "File to import"
+// @Param        format      query     string  false  "File format (default from the file extension)"  Enums(csv, json, todotxt, markdown, ical)
+// @Param        dry_run     query     bool    false  "Report what would be imported without creating anything"
+// @Param        duplicates  query     string  false  "skip (default) or allow"  Enums(skip, allow)
+// @Success      200         {object}  services.ImportReport
+// @Failure      400         {object}  ErrorResponse
+// @Failure      401         {object}  ErrorResponse
+// @Failure      500         {object}  ErrorResponse
+// @Router       /todos/import [post]
+func (h *TodoHandler) Import(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	opts := services.ImportOptions{}
+	if v := c.Query("dry_run"); v != "" {
+		if opts.DryRun, err = strconv.ParseBool(v); err != nil {
+			c.Error(invalidField("dry_run", "type", "must be true or false"))
+			return
+		}
+	}
+	switch c.DefaultQuery("duplicates", "skip") {
+	case "skip":
+	case "allow":
+		opts.AllowDuplicates = true
+	default:
+		c.Error(invalidField("duplicates", "oneof", "must be one of: skip, allow"))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes+64<<10)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.Error(domain.Validation("import files are limited to 5 MB").WithCode("file_too_large"))
+		default:
+			c.Error(invalidField("file", "required", "is required"))
+		}
+		return
+	}
+	defer file.Close()
+	if header.Size > maxImportBytes {
+		c.Error(domain.Validation("import files are limited to 5 MB").WithCode("file_too_large"))
+		return
+	}
+
+	format, ok := services.ParseTransferFormat(c.Query("format"), header.Filename)
+	if !ok {
+		c.Error(invalidField("format", "oneof", "must be one of: csv, json, todotxt, markdown, ical (or use a .csv, .json, .txt, .md or .ics file)"))
+		return
+	}
+	opts.Format = format
+
+	report, err := h.service.Import(c.Request.Context(), uid, file, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/models"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type WebhookHandler struct {
+	service *services.WebhookService
+}
+
+func NewWebhookHandler(s *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: s}
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=256"`
+	Events []string `json:"events" validate:"max=20"`
+}
+
+type UpdateWebhookRequest struct {
+	URL    *string   `json:"url" validate:"omitempty,url,max=2048"`
+	Secret *string   `json:"secret" validate:"omitempty,min=16,max=256"`
+	Events *[]string `json:"events" validate:"omitempty,max=20"`
+	Active *bool     `json:"active"`
+}
+
+// WebhookCreatedResponse is returned once on creation; the secret is not shown again.
+type WebhookCreatedResponse struct {
+	*models.Webhook
+	Secret string `json:"secret" example:"whsec_5f2b..."`
+}
+
+// @Summary      Register webhook
+// @Description  Registers a URL that receives todo events (todo.created, todo.updated, todo.completed, todo.reopened, todo.deleted, todo.restored, todo.commented, todo.archived, todo.unarchived) as signed JSON POSTs. Leave events empty for all of them. The signing secret is generated unless given and is only returned here. Each request carries X-Webhook-Timestamp and X-Webhook-Signature: "sha256=" + hex HMAC-SHA256 of "<timestamp>.<body>". Failed deliveries are retried with backoff; the webhook is disabled after 10 consecutive failures. With X-Workspace-ID the webhook receives the workspace's events instead of personal ones; only workspace owners and admins can manage workspace webhooks.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload  body      CreateWebhookRequestDTO  true  "Webhook"
+// @Success      201      {object}  WebhookCreatedResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req CreateWebhookRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	hook, secret, err := h.service.Create(c.Request.Context(), uid, services.WebhookInput{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, WebhookCreatedResponse{Webhook: hook, Secret: secret})
+}
+
+// @Summary      List webhooks
+// @Description  Lists the authenticated user's webhooks (or the workspace's, with X-Workspace-ID), including disabled ones.
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.Webhook
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /webhooks [get]
+func (h *WebhookHandler) List(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	hooks, err := h.service.List(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// @Summary      Get webhook
+// @Description  Returns a webhook; disabled_at and disabled_reason are set if it was turned off after repeated failures.
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Webhook ID"
+// @Success      200  {object}  models.Webhook
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /webhooks/{id} [get]
+func (h *WebhookHandler) Get(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	hid, err := pathObjectID(c, "id", "webhook")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	hook, err := h.service.Get(c.Request.Context(), uid, hid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, hook)
+}
+
+// @Summary      Update webhook
+// @Description  Partially updates a webhook. Set active to true to re-enable a webhook that was disabled after repeated failures; this also resets its failure count.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                   true  "Webhook ID"
+// @Param        payload  body      UpdateWebhookRequestDTO  true  "Webhook patch"
+// @Success      200      {object}  models.Webhook
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /webhooks/{id} [patch]
+func (h *WebhookHandler) Update(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	hid, err := pathObjectID(c, "id", "webhook")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req UpdateWebhookRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	hook, err := h.service.Update(c.Request.Context(), uid, hid, services.WebhookPatch{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+		Active: req.Active,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, hook)
+}
+
+// @Summary      Delete webhook
+// @Description  Deletes a webhook. Deliveries still queued for it are marked failed.
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Webhook ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	hid, err := pathObjectID(c, "id", "webhook")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), uid, hid); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// @Summary      Webhook deliveries
+// @Description  Lists delivery attempts for a webhook, newest first, with status (pending, succeeded, failed), attempt count, response code and the start of the response body. Page with before=<id of the last delivery received>.
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      string  true   "Webhook ID"
+// @Param        before  query     string  false  "Return deliveries older than this delivery ID"
+// @Param        limit   query     int     false  "Max deliveries (default 50, max 200)"
+// @Success      200     {array}   models.WebhookDelivery
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      404     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	hid, err := pathObjectID(c, "id", "webhook")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	before, limit, err := parseEventPage(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	deliveries, err := h.service.Deliveries(c.Request.Context(), uid, hid, before, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// @Summary      Send test event
+// @Description  Sends a signed "ping" event to the webhook right away, even if it is disabled, and returns the logged delivery. Test deliveries are not retried and do not count towards auto-disable.
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Webhook ID"
+// @Success      200  {object}  models.WebhookDelivery
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /webhooks/{id}/test [post]
+func (h *WebhookHandler) Test(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	hid, err := pathObjectID(c, "id", "webhook")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	delivery, err := h.service.Test(c.Request.Context(), uid, hid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/models"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+type WorkspaceHandler struct {
+	service *services.WorkspaceService
+}
+
+func NewWorkspaceHandler(s *services.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{service: s}
+}
+
+type WorkspaceRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=admin member viewer"`
+}
+
+type SetMemberRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin member viewer"`
+}
+
+// @Summary      Create workspace
+// @Description  Creates a workspace owned by the authenticated user. Send its ID as X-Workspace-ID to work with its todos.
+// @Tags         workspaces
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload  body      WorkspaceRequestDTO  true  "Workspace"
+// @Success      201      {object}  models.Workspace
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /workspaces [post]
+func (h *WorkspaceHandler) Create(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req WorkspaceRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	ws, err := h.service.Create(c.Request.Context(), uid, req.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, ws)
+}
+
+// @Summary      List workspaces
+// @Description  Lists the workspaces the authenticated user is a member of.
+// @Tags         workspaces
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.Workspace
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /workspaces [get]
+func (h *WorkspaceHandler) List(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	workspaces, err := h.service.List(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, workspaces)
+}
+
+// @Summary      Get workspace
+// @Description  Returns a workspace with its members and pending invitations.
+// @Tags         workspaces
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Workspace ID"
+// @Success      200  {object}  models.Workspace
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /workspaces/{id} [get]
+func (h *WorkspaceHandler) Get(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	wsID, err := pathObjectID(c, "id", "workspace")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ws, err := h.service.Get(c.Request.Context(), uid, wsID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, ws)
+}
+
+// @Summary      Rename workspace
+// @Description  Renames a workspace. Requires the admin or owner role.
+// @Tags         workspaces
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string               true  "Workspace ID"
+// @Param        payload  body      WorkspaceRequestDTO  true  "Workspace"
+// @Success      200      {object}  models.Workspace
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /workspaces/{id} [patch]
+func (h *WorkspaceHandler) Update(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	wsID, err := pathObjectID(c, "id", "workspace")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req WorkspaceRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	ws, err := h.service.Rename(c.Request.Context(), uid, wsID, req.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, ws)
+}
+
+// @Summary      Invite member
+// @Description  Emails an invitation to join the workspace as admin, member or viewer. Requires the admin or owner role.
+// @Tags         workspaces
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                  true  "Workspace ID"
+// @Param        payload  body      InviteMemberRequestDTO  true  "Invitation"
+// @Success      201      {object}  models.WorkspaceInvitation
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /workspaces/{id}/invitations [post]
+func (h *WorkspaceHandler) Invite(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	wsID, err := pathObjectID(c, "id", "workspace")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req InviteMemberRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	inv, err := h.service.Invite(c.Request.Context(), uid, wsID, req.Email, models.WorkspaceRole(req.Role))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, inv)
+}
+
+// @Summary      Revoke invitation
+// @Description  Cancels a pending invitation. Requires the admin or owner role.
+// @Tags         workspaces
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id            path      string  true  "Workspace ID"
+// @Param        invitationId  path      string  true  "Invitation ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /workspaces/{id}/invitations/{invitationId} [delete]
+func (h *WorkspaceHandler) RevokeInvitation(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	wsID, err := pathObjectID(c, "id", "workspace")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invID, err := pathObjectID(c, "invitationId", "invitation")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.RevokeInvitation(c.Request.Context(), uid, wsID, invID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "revoked"})
+}
+
+// @Summary      Accept invitation
+// @Description  Joins a workspace through an invitation sent to the authenticated user's email.
+// @Tags         workspaces
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id            path      string  true  "Workspace ID"
+// @Param        invitationId  path      string  true  "Invitation ID"
+// @Success      200  {object}  models.Workspace
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /workspaces/{id}/invitations/{invitationId}/accept [post]
+func (h *WorkspaceHandler) Accept(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	wsID, err := pathObjectID(c, "id", "workspace")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invID, err := pathObjectID(c, "invitationId", "invitation")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ws, err := h.service.Accept(c.Request.Context(), uid, wsID, invID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, ws)
+}
+
+// @Summary      List my workspace invitations
+// @Description  Lists pending workspace invitations sent to the authenticated user's email.
+// @Tags         workspaces
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   services.WorkspaceInvite
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /workspaces/invitations [get]
+func (h *WorkspaceHandler) Invitations(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	invites, err := h.service.Invitations(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, invites)
+}
+
+// @Summary      Change member role
+// @Description  Changes a member's role. Requires the admin or owner role; the owner's role cannot change.
+// @Tags         workspaces
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                   true  "Workspace ID"
+// @Param        userId   path      string                   true  "Member user ID"
+// @Param        payload  body      SetMemberRoleRequestDTO  true  "Role"
+// @Success      200      {object}  models.Workspace
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /workspaces/{id}/members/{userId} [patch]
+func (h *WorkspaceHandler) SetRole(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	wsID, err := pathObjectID(c, "id", "workspace")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	memberID, err := pathObjectID(c, "userId", "user")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req SetMemberRoleRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	ws, err := h.service.SetRole(c.Request.Context(), uid, wsID, memberID, models.WorkspaceRole(req.Role))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, ws)
+}
+
+// @Summary      Remove member
+// @Description  Removes a member (admin or owner role required), or leaves the workspace when userId is the caller. The owner cannot be removed.
+// @Tags         workspaces
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      string  true  "Workspace ID"
+// @Param        userId  path      string  true  "Member user ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /workspaces/{id}/members/{userId} [delete]
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	wsID, err := pathObjectID(c, "id", "workspace")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	memberID, err := pathObjectID(c, "userId", "user")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.RemoveMember(c.Request.Context(), uid, wsID, memberID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "removed"})
+}
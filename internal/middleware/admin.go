@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AdminMiddleware restricts a route group to users flagged as admins.
+// It must run after AuthMiddleware so that user_id is present in the context.
+type AdminMiddleware struct {
+	userRepo database.UserRepository
+}
+
+func NewAdminMiddleware(userRepo database.UserRepository) *AdminMiddleware {
+	return &AdminMiddleware{userRepo: userRepo}
+}
+
+func (m *AdminMiddleware) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := primitive.ObjectIDFromHex(c.GetString("user_id"))
+		if err != nil {
+			c.Error(domain.Unauthorized("invalid user id").WithCode("invalid_token"))
+			c.Abort()
+			return
+		}
+
+		user, err := m.userRepo.FindUserByID(c.Request.Context(), uid)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if err != nil || !user.IsAdmin {
+			c.Error(domain.Forbidden("admin access required").WithCode("admin_required"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
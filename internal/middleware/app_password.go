@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/services"
+)
+
+// AppPasswordMiddleware authenticates protocol clients (CalDAV) with app
+// passwords, sent as the Basic auth password or as a Bearer token. It sets
+// user_id like AuthMiddleware, plus user_email.
+type AppPasswordMiddleware struct {
+	service *services.AppPasswordService
+	realm   string
+}
+
+func NewAppPasswordMiddleware(s *services.AppPasswordService, realm string) *AppPasswordMiddleware {
+	return &AppPasswordMiddleware{service: s, realm: realm}
+}
+
+func (m *AppPasswordMiddleware) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, token, ok := c.Request.BasicAuth()
+		if !ok {
+			token, _ = strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+		if token == "" {
+			m.challenge(c, domain.Unauthorized("authentication required").WithCode("missing_credentials"))
+			return
+		}
+
+		user, err := m.service.Authenticate(c.Request.Context(), username, token)
+		if err != nil {
+			m.challenge(c, err)
+			return
+		}
+		c.Set("user_id", user.ID.Hex())
+		c.Set("user_email", user.Email)
+		c.Next()
+	}
+}
+
+func (m *AppPasswordMiddleware) challenge(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrUnauthorized) {
+		c.Header("WWW-Authenticate", `Basic realm="`+m.realm+`", charset="UTF-8"`)
+	}
+	c.Error(err)
+	c.Abort()
+}
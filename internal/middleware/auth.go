@@ -2,11 +2,11 @@ package middleware
 
 import (
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/group14000/golang-todo/internal/domain"
 )
 
 type AuthMiddleware struct {
@@ -21,14 +21,14 @@ func (m *AuthMiddleware) Handler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+			c.Error(domain.Unauthorized("missing authorization header").WithCode("missing_token"))
 			c.Abort()
 			return
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+			c.Error(domain.Unauthorized("invalid authorization header").WithCode("invalid_authorization_header"))
 			c.Abort()
 			return
 		}
@@ -42,20 +42,40 @@ func (m *AuthMiddleware) Handler() gin.HandlerFunc {
 			return []byte(m.jwtSecret), nil
 		})
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			c.Error(domain.Unauthorized("invalid token").WithCode("invalid_token"))
 			c.Abort()
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			c.Error(domain.Unauthorized("invalid token claims").WithCode("invalid_token"))
 			c.Abort()
 			return
 		}
 
+		// Tokens minted for other purposes (e.g. unsubscribe links) carry no user_id
 		userID, _ := claims["user_id"].(string)
+		if userID == "" {
+			c.Error(domain.Unauthorized("invalid token claims").WithCode("invalid_token"))
+			c.Abort()
+			return
+		}
 		c.Set("user_id", userID)
 		c.Next()
 	}
 }
+
+// StreamHandler is Handler for EventSource and WebSocket routes, where browser
+// clients cannot set headers: the token may also be sent as ?access_token=.
+func (m *AuthMiddleware) StreamHandler() gin.HandlerFunc {
+	authenticate := m.Handler()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		authenticate(c)
+	}
+}
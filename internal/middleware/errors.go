@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/domain"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+// Problem is the RFC 7807 error body returned by every endpoint. Code is a
+// stable machine-readable identifier clients should branch on; Detail is a
+// human-readable message that may change.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+// ErrorHandler renders the last error a handler attached with c.Error as a
+// problem+json body, mapping domain error kinds to HTTP statuses. Unknown
+// errors become a generic 500 so driver or upstream messages never reach the
+// client.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		status := StatusFor(err)
+
+		msg := domain.Message(err)
+		if status == http.StatusInternalServerError {
+			log.Printf("[%s] %s %s: %v", RequestIDFrom(c), c.Request.Method, c.Request.URL.Path, err)
+			msg = "internal server error"
+		} else if msg == "" {
+			msg = err.Error()
+		}
+		WriteProblem(c, Problem{
+			Status: status,
+			Detail: msg,
+			Code:   domain.Code(err),
+			Errors: domain.Fields(err),
+		})
+	}
+}
+
+// WriteProblem writes p as application/problem+json, filling in the type,
+// title, instance and request ID when they are empty.
+func WriteProblem(c *gin.Context, p Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = RequestIDFrom(c)
+	}
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(p.Status, p)
+}
+
+// NoRoute reports unknown paths through the error handler so they share the problem format.
+func NoRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Error(domain.NotFound("no route for %s %s", c.Request.Method, c.Request.URL.Path).WithCode("route_not_found"))
+	}
+}
+
+// StatusFor maps an error to its HTTP status code.
+func StatusFor(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrUpstream):
+		return http.StatusBadGateway
+	case errors.Is(err, domain.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkspaceHeader selects the workspace a request acts in; without it requests
+// act on the user's personal todos.
+const WorkspaceHeader = "X-Workspace-ID"
+
+// WorkspaceMiddleware scopes a request to the workspace named by X-Workspace-ID
+// after checking the user is a member. Repositories read the scope from the
+// request context. It must run after AuthMiddleware so that user_id is present.
+type WorkspaceMiddleware struct {
+	service *services.WorkspaceService
+}
+
+func NewWorkspaceMiddleware(s *services.WorkspaceService) *WorkspaceMiddleware {
+	return &WorkspaceMiddleware{service: s}
+}
+
+func (m *WorkspaceMiddleware) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(WorkspaceHeader)
+		if header == "" {
+			c.Next()
+			return
+		}
+		wsID, err := primitive.ObjectIDFromHex(header)
+		if err != nil {
+			c.Error(domain.Validation("%s must be a workspace ID", WorkspaceHeader).WithCode("invalid_workspace_id"))
+			c.Abort()
+			return
+		}
+		uid, err := primitive.ObjectIDFromHex(c.GetString("user_id"))
+		if err != nil {
+			c.Error(domain.Unauthorized("invalid user id").WithCode("invalid_token"))
+			c.Abort()
+			return
+		}
+
+		// Non-members get workspace_not_found, so IDs of other workspaces are not confirmed.
+		role, err := m.service.Role(c.Request.Context(), uid, wsID)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		scope := database.WorkspaceScope{ID: wsID, Role: role}
+		c.Request = c.Request.WithContext(database.WithWorkspace(c.Request.Context(), scope))
+		c.Set("workspace_id", wsID.Hex())
+		c.Next()
+	}
+}
+
+// StreamHandler is Handler for EventSource and WebSocket routes, where browser
+// clients cannot set headers: the workspace may also be sent as ?workspace_id=.
+func (m *WorkspaceMiddleware) StreamHandler() gin.HandlerFunc {
+	scope := m.Handler()
+	return func(c *gin.Context) {
+		if c.GetHeader(WorkspaceHeader) == "" {
+			if id := c.Query("workspace_id"); id != "" {
+				c.Request.Header.Set(WorkspaceHeader, id)
+			}
+		}
+		scope(c)
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment describes a file attached to a todo. The bytes live in the blob
+// store under StorageKey; ContentType is sniffed from them, not taken from the client.
+type Attachment struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	TodoID      primitive.ObjectID  `bson:"todo_id" json:"todo_id"`
+	WorkspaceID *primitive.ObjectID `bson:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	UploaderID  primitive.ObjectID  `bson:"uploader_id" json:"uploader_id"`
+	Filename    string              `bson:"filename" json:"filename" example:"receipt.pdf"`
+	ContentType string              `bson:"content_type" json:"content_type" example:"application/pdf"`
+	Size        int64               `bson:"size" json:"size" example:"48213"`
+	SHA256      string              `bson:"sha256" json:"sha256"`
+	// Backend and StorageKey locate the blob; Backend is kept so blobs written
+	// before a storage change can be told apart.
+	Backend    string    `bson:"backend" json:"-"`
+	StorageKey string    `bson:"storage_key" json:"-"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+}
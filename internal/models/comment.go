@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Comment is a Markdown note on a todo. Threads are one level deep: ParentID,
+// when set, is always a top-level comment.
+type Comment struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	TodoID      primitive.ObjectID   `bson:"todo_id" json:"todo_id"`
+	WorkspaceID *primitive.ObjectID  `bson:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	ParentID    *primitive.ObjectID  `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+	AuthorID    primitive.ObjectID   `bson:"author_id" json:"author_id"`
+	Body        string               `bson:"body" json:"body"` // Markdown, rendered by clients
+	Mentions    []primitive.ObjectID `bson:"mentions,omitempty" json:"mentions,omitempty"`
+	CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+	EditedAt    *time.Time           `bson:"edited_at,omitempty" json:"edited_at,omitempty"`
+	// DeletedAt marks a removed comment. Its body is cleared; it is still listed
+	// while it has replies so the thread keeps its shape.
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	// Replies is filled on top-level comments when listing a thread.
+	Replies []*Comment `bson:"-" json:"replies,omitempty"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserPreferences is embedded in User as the "preferences" subdocument.
+type UserPreferences struct {
+	Timezone           string                 `bson:"timezone" json:"timezone" example:"Europe/Berlin"`
+	Locale             string                 `bson:"locale" json:"locale" example:"en"`
+	WeekStart          string                 `bson:"week_start" json:"week_start" example:"monday"`
+	DefaultProjectID   *primitive.ObjectID    `bson:"default_project_id,omitempty" json:"default_project_id,omitempty"`
+	DefaultSort        string                 `bson:"default_sort" json:"default_sort" example:"-created_at"`
+	EmailNotifications EmailNotificationPrefs `bson:"email_notifications" json:"email_notifications"`
+	// AutoArchiveDays archives personal todos this many days after they were
+	// completed. It is opt-in: 0 (or never set) leaves todos alone.
+	AutoArchiveDays int `bson:"auto_archive_days,omitempty" json:"auto_archive_days" example:"30"`
+}
+
+// EmailNotificationPrefs holds per-user opt-ins for non-transactional email.
+type EmailNotificationPrefs struct {
+	DailyDigest  bool `bson:"daily_digest" json:"daily_digest"`
+	WeeklyDigest bool `bson:"weekly_digest" json:"weekly_digest"`
+	// Mentions emails the user when someone @mentions them in a comment. It is
+	// on unless turned off; nil (never set) reads as true.
+	Mentions *bool `bson:"mentions,omitempty" json:"mentions"`
+}
+
+// DefaultPreferences returns the preferences a new user starts with.
+func DefaultPreferences() UserPreferences {
+	return UserPreferences{
+		Timezone:    "UTC",
+		Locale:      "en",
+		WeekStart:   "monday",
+		DefaultSort: "-created_at",
+	}
+}
+
+// WithDefaults fills unset fields (e.g. users created before preferences existed).
+func (p UserPreferences) WithDefaults() UserPreferences {
+	d := DefaultPreferences()
+	if p.Timezone == "" {
+		p.Timezone = d.Timezone
+	}
+	if p.Locale == "" {
+		p.Locale = d.Locale
+	}
+	if p.WeekStart == "" {
+		p.WeekStart = d.WeekStart
+	}
+	if p.DefaultSort == "" {
+		p.DefaultSort = d.DefaultSort
+	}
+	if p.EmailNotifications.Mentions == nil {
+		on := true
+		p.EmailNotifications.Mentions = &on
+	}
+	return p
+}
+
+// Location resolves the IANA timezone, falling back to UTC when it is invalid.
+func (p UserPreferences) Location() *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// FirstWeekday maps WeekStart to a time.Weekday (Monday by default).
+func (p UserPreferences) FirstWeekday() time.Weekday {
+	switch p.WeekStart {
+	case "sunday":
+		return time.Sunday
+	case "saturday":
+		return time.Saturday
+	default:
+		return time.Monday
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Todo priorities; an empty priority means normal.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// Recurrence frequencies.
+const (
+	RecurDaily   = "daily"
+	RecurWeekly  = "weekly"
+	RecurMonthly = "monthly"
+	RecurYearly  = "yearly"
+)
+
+// Weekdays are the names Recurrence.Weekdays uses, indexed by time.Weekday.
+var Weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Recurrence repeats a todo: completing it creates the next occurrence.
+type Recurrence struct {
+	Frequency string `bson:"frequency" json:"frequency" example:"monthly" enums:"daily,weekly,monthly,yearly"`
+	// Interval repeats every N periods; 0 means 1.
+	Interval int `bson:"interval,omitempty" json:"interval,omitempty" example:"1"`
+	// Weekdays limits a weekly recurrence to these days (sun, mon, ... sat).
+	Weekdays []string `bson:"weekdays,omitempty" json:"weekdays,omitempty" example:"mon"`
+	// MonthDay pins a monthly recurrence to a day, moved to the last day in shorter months.
+	MonthDay int `bson:"month_day,omitempty" json:"month_day,omitempty" example:"1"`
+}
+
+// Next returns the first occurrence strictly after t, at t's wall-clock time
+// in t's location.
+func (r *Recurrence) Next(t time.Time) time.Time {
+	n := max(r.Interval, 1)
+	switch r.Frequency {
+	case RecurWeekly:
+		if len(r.Weekdays) == 0 {
+			return t.AddDate(0, 0, 7*n)
+		}
+		// Finish the current week (weeks start on Monday), then skip n-1 weeks.
+		d := t.AddDate(0, 0, 1)
+		for ; d.Weekday() != time.Monday; d = d.AddDate(0, 0, 1) {
+			if r.onWeekday(d) {
+				return d
+			}
+		}
+		d = d.AddDate(0, 0, 7*(n-1))
+		for !r.onWeekday(d) {
+			d = d.AddDate(0, 0, 1)
+		}
+		return d
+	case RecurMonthly:
+		day := r.MonthDay
+		if day == 0 {
+			day = t.Day()
+		}
+		if d := monthDay(t, 0, day); d.After(t) {
+			return d
+		}
+		return monthDay(t, n, day)
+	case RecurYearly:
+		return t.AddDate(n, 0, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
+
+func (r *Recurrence) onWeekday(t time.Time) bool {
+	return slices.Contains(r.Weekdays, Weekdays[t.Weekday()])
+}
+
+// monthDay returns day (clamped to the month's length) of the month months after t's.
+func monthDay(t time.Time, months, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, last)-1)
+}
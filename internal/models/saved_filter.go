@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SavedFilter is a named todo query (a smart list). Query is written in the
+// filter language described in the README and is evaluated on every read, so
+// relative dates such as due:today always mean the current day.
+type SavedFilter struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	WorkspaceID *primitive.ObjectID `bson:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	Name        string              `bson:"name" json:"name" example:"Urgent work"`
+	Query       string              `bson:"query" json:"query" example:"label:work AND priority>=high AND NOT completed:true"`
+	// Sort orders the todos like the list's sort parameter; empty uses the user's default sort.
+	Sort      string    `bson:"sort,omitempty" json:"sort,omitempty" example:"due_at"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
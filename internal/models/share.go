@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ShareRole is what a collaborator may do: viewers read, editors also update,
+// owners also delete, restore and share.
+type ShareRole string
+
+const (
+	ShareRoleViewer ShareRole = "viewer"
+	ShareRoleEditor ShareRole = "editor"
+	ShareRoleOwner  ShareRole = "owner"
+)
+
+// Allows reports whether r grants at least need.
+func (r ShareRole) Allows(need ShareRole) bool {
+	return r.rank() >= need.rank()
+}
+
+func (r ShareRole) rank() int {
+	switch r {
+	case ShareRoleViewer:
+		return 1
+	case ShareRoleEditor:
+		return 2
+	case ShareRoleOwner:
+		return 3
+	}
+	return 0
+}
+
+// ShareResource is what a share covers: one todo, or every todo the owner
+// files under a project ID.
+type ShareResource string
+
+const (
+	ShareResourceTodo    ShareResource = "todo"
+	ShareResourceProject ShareResource = "project"
+)
+
+type ShareStatus string
+
+const (
+	ShareStatusPending  ShareStatus = "pending"
+	ShareStatusAccepted ShareStatus = "accepted"
+)
+
+// Share grants the user invited by email access to another user's todo or
+// project once they accept. Revoking or declining deletes it.
+type Share struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	OwnerID    primitive.ObjectID  `bson:"owner_id" json:"owner_id"`
+	Resource   ShareResource       `bson:"resource" json:"resource"`
+	ResourceID primitive.ObjectID  `bson:"resource_id" json:"resource_id"`
+	Email      string              `bson:"email" json:"email"`
+	InviteeID  *primitive.ObjectID `bson:"invitee_id,omitempty" json:"invitee_id,omitempty"`
+	Role       ShareRole           `bson:"role" json:"role"`
+	Status     ShareStatus         `bson:"status" json:"status"`
+	InvitedBy  primitive.ObjectID  `bson:"invited_by" json:"invited_by"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+	AcceptedAt *time.Time          `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TimeEntry is time a user spent on a todo, recorded by the timer or entered by hand.
+type TimeEntry struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	TodoID      primitive.ObjectID  `bson:"todo_id" json:"todo_id"`
+	WorkspaceID *primitive.ObjectID `bson:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	StartedAt   time.Time           `bson:"started_at" json:"started_at"`
+	// EndedAt is nil while the timer is running.
+	EndedAt *time.Time `bson:"ended_at" json:"ended_at"`
+	Note    string     `bson:"note,omitempty" json:"note,omitempty"`
+	// Manual is set for entries entered by hand rather than timed.
+	Manual    bool      `bson:"manual" json:"manual"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+	// Seconds is computed on read; a running timer counts up to now.
+	Seconds int64 `bson:"-" json:"seconds"`
+}
+
+// Duration is the tracked time, up to now for a running timer.
+func (e *TimeEntry) Duration(now time.Time) time.Duration {
+	end := now
+	if e.EndedAt != nil {
+		end = *e.EndedAt
+	}
+	if end.Before(e.StartedAt) {
+		return 0
+	}
+	return end.Sub(e.StartedAt)
+}
@@ -7,11 +7,48 @@ import (
 )
 
 type Todo struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Title       string             `bson:"title" json:"title" validate:"required"`
-	Description string             `bson:"description" json:"description"`
-	Completed   bool               `bson:"completed" json:"completed"`
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID primitive.ObjectID `bson:"user_id" json:"user_id"`
+	// WorkspaceID is set for todos owned by a workspace; UserID is then the creator.
+	WorkspaceID *primitive.ObjectID `bson:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	Title       string              `bson:"title" json:"title" validate:"required"`
+	Description string              `bson:"description" json:"description"`
+	Completed   bool                `bson:"completed" json:"completed"`
+	CompletedAt *time.Time          `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	// Status is the key of the todo's workflow column; Completed follows it.
+	Status     string              `bson:"status,omitempty" json:"status"`
+	DueAt      *time.Time          `bson:"due_at,omitempty" json:"due_at,omitempty"`
+	ProjectID  *primitive.ObjectID `bson:"project_id,omitempty" json:"project_id,omitempty"`
+	AssigneeID *primitive.ObjectID `bson:"assignee_id,omitempty" json:"assignee_id,omitempty"`
+	Labels     []string            `bson:"labels,omitempty" json:"labels,omitempty"`
+	// Priority is low, high or urgent; empty means normal.
+	Priority   string      `bson:"priority,omitempty" json:"priority,omitempty"`
+	Recurrence *Recurrence `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+	// NextOccurrenceID is the todo created when this recurring todo was
+	// completed; completing it again does not create another. It is maintained
+	// outside the version.
+	NextOccurrenceID *primitive.ObjectID `bson:"next_occurrence_id,omitempty" json:"next_occurrence_id,omitempty"`
+	// EstimateMinutes is how long the todo is expected to take.
+	EstimateMinutes *int `bson:"estimate_minutes,omitempty" json:"estimate_minutes,omitempty"`
+	// BlockedBy lists the todos that must be completed before this one.
+	BlockedBy []primitive.ObjectID `bson:"blocked_by,omitempty" json:"blocked_by,omitempty"`
+	// Blocked is computed on read: some todo in BlockedBy is still open.
+	Blocked   bool       `bson:"-" json:"blocked"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `bson:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	// ArchivedAt is set while the todo is archived: kept, but left out of the default lists.
+	ArchivedAt *time.Time `bson:"archived_at,omitempty" json:"archived_at,omitempty"`
+	// CommentCount counts live comments; it is maintained outside the version.
+	CommentCount int `bson:"comment_count" json:"comment_count"`
+	// Version increments on every write; exposed as the ETag for optimistic concurrency.
+	Version int64 `bson:"version" json:"version"`
+	// CalDAV is set for todos created by a CalDAV client, which picks its own names.
+	CalDAV *TodoCalDAV `bson:"caldav,omitempty" json:"-"`
+}
+
+// TodoCalDAV keeps the resource name and iCalendar UID a CalDAV client chose.
+type TodoCalDAV struct {
+	Name string `bson:"name"`
+	UID  string `bson:"uid"`
 }
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TodoEventType string
+
+const (
+	TodoEventCreated    TodoEventType = "created"
+	TodoEventUpdated    TodoEventType = "updated"
+	TodoEventCompleted  TodoEventType = "completed"
+	TodoEventReopened   TodoEventType = "reopened"
+	TodoEventDeleted    TodoEventType = "deleted"
+	TodoEventRestored   TodoEventType = "restored"
+	TodoEventCommented  TodoEventType = "commented"
+	TodoEventArchived   TodoEventType = "archived"
+	TodoEventUnarchived TodoEventType = "unarchived"
+)
+
+// TodoEventTypes lists every event type, in lifecycle order.
+var TodoEventTypes = []TodoEventType{
+	TodoEventCreated, TodoEventUpdated, TodoEventCompleted,
+	TodoEventReopened, TodoEventDeleted, TodoEventRestored,
+	TodoEventCommented, TodoEventArchived, TodoEventUnarchived,
+}
+
+// Name is the external event name used by streams and webhooks, e.g. "todo.completed".
+func (t TodoEventType) Name() string {
+	return "todo." + string(t)
+}
+
+// TodoEvent is an immutable audit record of a change to a todo.
+// UserID is the todo owner (used for scoping, with WorkspaceID for workspace
+// todos); ActorID is who made the change.
+type TodoEvent struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	TodoID      primitive.ObjectID  `bson:"todo_id" json:"todo_id"`
+	UserID      primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	WorkspaceID *primitive.ObjectID `bson:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	ProjectID   *primitive.ObjectID `bson:"project_id,omitempty" json:"project_id,omitempty"` // todo's project at the time, for project collaborators
+	ActorID     primitive.ObjectID  `bson:"actor_id" json:"actor_id"`
+	Type        TodoEventType       `bson:"type" json:"type"`
+	Title       string              `bson:"title" json:"title"` // todo title at the time of the event
+	Changes     []FieldChange       `bson:"changes,omitempty" json:"changes,omitempty"`
+	Version     int64               `bson:"version,omitempty" json:"version,omitempty"`       // todo version this change produced
+	CommentID   *primitive.ObjectID `bson:"comment_id,omitempty" json:"comment_id,omitempty"` // set on commented events
+	CreatedAt   time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// FieldChange captures one field's value before and after an update.
+type FieldChange struct {
+	Field  string `bson:"field" json:"field"`
+	Before any    `bson:"before" json:"before"`
+	After  any    `bson:"after" json:"after"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TodoTemplate is a reusable checklist: instantiating it creates the main todo
+// plus one todo per subtask, which blocks the main todo until it is done.
+// Text fields may contain {{variables}} that are filled in on instantiation.
+type TodoTemplate struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	WorkspaceID *primitive.ObjectID `bson:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	// Name identifies the template in lists; it is not copied to the todos.
+	Name        string   `bson:"name" json:"name"`
+	Title       string   `bson:"title" json:"title"`
+	Description string   `bson:"description,omitempty" json:"description,omitempty"`
+	Labels      []string `bson:"labels,omitempty" json:"labels,omitempty"`
+	// DueOffsetDays sets the due date this many days after the instantiation date.
+	DueOffsetDays *int              `bson:"due_offset_days,omitempty" json:"due_offset_days,omitempty"`
+	Subtasks      []TemplateSubtask `bson:"subtasks,omitempty" json:"subtasks"`
+	CreatedAt     time.Time         `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time         `bson:"updated_at" json:"updated_at"`
+}
+
+// TemplateSubtask is a todo created alongside the template's main todo.
+type TemplateSubtask struct {
+	Title         string   `bson:"title" json:"title"`
+	Description   string   `bson:"description,omitempty" json:"description,omitempty"`
+	Labels        []string `bson:"labels,omitempty" json:"labels,omitempty"`
+	DueOffsetDays *int     `bson:"due_offset_days,omitempty" json:"due_offset_days,omitempty"`
+}
@@ -7,10 +7,27 @@ import (
 )
 
 type User struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Name       string             `bson:"name" json:"name" validate:"required"`
-	Email      string             `bson:"email" json:"email" validate:"required,email"`
-	Password   string             `bson:"password" json:"-" validate:"required,min=6"` // json:"-" to not expose in API
-	IsVerified bool               `bson:"is_verified" json:"is_verified"`
-	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name        string             `bson:"name" json:"name" validate:"required"`
+	Email       string             `bson:"email" json:"email" validate:"required,email"`
+	Password    string             `bson:"password" json:"-" validate:"required,min=6"` // json:"-" to not expose in API
+	IsVerified  bool               `bson:"is_verified" json:"is_verified"`
+	IsAdmin     bool               `bson:"is_admin" json:"is_admin"` // granted manually in the database
+	Preferences UserPreferences    `bson:"preferences" json:"preferences"`
+	Digest      DigestState        `bson:"digest" json:"-"`
+	Calendar    *CalendarFeed      `bson:"calendar,omitempty" json:"-"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// DigestState records the local date (YYYY-MM-DD in the user's timezone) each digest was last sent.
+type DigestState struct {
+	LastDaily  string `bson:"last_daily"`
+	LastWeekly string `bson:"last_weekly"`
+}
+
+// CalendarFeed is the user's secret iCalendar subscription. Only a SHA-256 hash
+// of the token is stored, so the URL is shown once when it is generated.
+type CalendarFeed struct {
+	TokenHash string    `bson:"token_hash"`
+	CreatedAt time.Time `bson:"created_at"`
 }
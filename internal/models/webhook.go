@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook is a user-registered endpoint that receives todo events: those of
+// the user's personal todos, or of the workspace it was created in.
+// Events filters by event name (e.g. "todo.completed"); empty means all.
+type Webhook struct {
+	ID                  primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID              primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	WorkspaceID         *primitive.ObjectID `bson:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	URL                 string              `bson:"url" json:"url"`
+	Secret              string              `bson:"secret" json:"-"`
+	Events              []string            `bson:"events" json:"events"`
+	Active              bool                `bson:"active" json:"active"`
+	ConsecutiveFailures int                 `bson:"consecutive_failures" json:"consecutive_failures"`
+	DisabledAt          *time.Time          `bson:"disabled_at,omitempty" json:"disabled_at,omitempty"`
+	DisabledReason      string              `bson:"disabled_reason,omitempty" json:"disabled_reason,omitempty"`
+	CreatedAt           time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt           time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// Wants reports whether the webhook subscribes to the named event.
+func (w *Webhook) Wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one payload queued for a webhook, plus the outcome of its
+// latest attempt. Pending deliveries double as the retry queue.
+type WebhookDelivery struct {
+	ID            primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	WebhookID     primitive.ObjectID    `bson:"webhook_id" json:"webhook_id"`
+	UserID        primitive.ObjectID    `bson:"user_id" json:"user_id"`
+	Event         string                `bson:"event" json:"event"`
+	Payload       string                `bson:"payload" json:"payload"`
+	Status        WebhookDeliveryStatus `bson:"status" json:"status"`
+	Attempts      int                   `bson:"attempts" json:"attempts"`
+	ResponseCode  int                   `bson:"response_code,omitempty" json:"response_code,omitempty"`
+	ResponseBody  string                `bson:"response_body,omitempty" json:"response_body,omitempty"`
+	Error         string                `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMS    int64                 `bson:"duration_ms,omitempty" json:"duration_ms,omitempty"`
+	NextAttemptAt *time.Time            `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"`
+	LastAttemptAt *time.Time            `bson:"last_attempt_at,omitempty" json:"last_attempt_at,omitempty"`
+	LockedUntil   *time.Time            `bson:"locked_until,omitempty" json:"-"`
+	CreatedAt     time.Time             `bson:"created_at" json:"created_at"`
+}
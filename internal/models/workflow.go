@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Statuses of the default workflow, used by projects without their own.
+const (
+	StatusBacklog    = "backlog"
+	StatusInProgress = "in_progress"
+	StatusReview     = "review"
+	StatusDone       = "done"
+)
+
+// Workflow configures the status columns of a project's board. A todo's status
+// is the key of its column, and it is completed exactly when that column is a
+// done column.
+type Workflow struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"-"`
+	UserID      primitive.ObjectID  `bson:"user_id" json:"-"`
+	WorkspaceID *primitive.ObjectID `bson:"workspace_id,omitempty" json:"-"`
+	// ProjectID is nil for the default workflow, which is not stored.
+	ProjectID *primitive.ObjectID `bson:"project_id" json:"project_id,omitempty"`
+	Columns   []WorkflowColumn    `bson:"columns" json:"columns"`
+	// Transitions lists, per column key, the columns a todo may move to next.
+	// Columns without an entry allow any move.
+	Transitions map[string][]string `bson:"transitions,omitempty" json:"transitions,omitempty"`
+	UpdatedAt   time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+type WorkflowColumn struct {
+	Key  string `bson:"key" json:"key"`
+	Name string `bson:"name" json:"name"`
+	// WIPLimit caps how many todos the column holds; 0 means no limit.
+	WIPLimit int `bson:"wip_limit" json:"wip_limit"`
+	// Done marks columns whose todos count as completed.
+	Done bool `bson:"done" json:"done"`
+}
+
+// DefaultWorkflow returns the workflow of projects that have not configured one.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{Columns: []WorkflowColumn{
+		{Key: StatusBacklog, Name: "Backlog"},
+		{Key: StatusInProgress, Name: "In progress"},
+		{Key: StatusReview, Name: "Review"},
+		{Key: StatusDone, Name: "Done", Done: true},
+	}}
+}
+
+// Column returns the column with key, if any.
+func (w *Workflow) Column(key string) (*WorkflowColumn, bool) {
+	for i := range w.Columns {
+		if w.Columns[i].Key == key {
+			return &w.Columns[i], true
+		}
+	}
+	return nil, false
+}
+
+// StatusFor returns where a todo with status and completed belongs: its own
+// column while that exists and agrees with completed, otherwise the first done
+// column for completed todos and the first open column for the rest.
+func (w *Workflow) StatusFor(status string, completed bool) string {
+	if col, ok := w.Column(status); ok && col.Done == completed {
+		return status
+	}
+	for _, col := range w.Columns {
+		if col.Done == completed {
+			return col.Key
+		}
+	}
+	return status
+}
+
+// Allows reports whether a todo may move from one column to another.
+func (w *Workflow) Allows(from, to string) bool {
+	next, ok := w.Transitions[from]
+	if !ok || from == to {
+		return true
+	}
+	for _, key := range next {
+		if key == to {
+			return true
+		}
+	}
+	return false
+}
+
+// LegacyStatus is the status of a todo written before statuses existed.
+func LegacyStatus(completed bool) string {
+	if completed {
+		return StatusDone
+	}
+	return StatusBacklog
+}
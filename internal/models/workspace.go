@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkspaceRole is a member's role: viewers read, members also create and edit
+// todos, admins also manage members and invitations. The owner is the creator.
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleOwner  WorkspaceRole = "owner"
+	WorkspaceRoleAdmin  WorkspaceRole = "admin"
+	WorkspaceRoleMember WorkspaceRole = "member"
+	WorkspaceRoleViewer WorkspaceRole = "viewer"
+)
+
+// TodoRole maps a workspace role onto the permissions used for todos.
+func (r WorkspaceRole) TodoRole() ShareRole {
+	switch r {
+	case WorkspaceRoleOwner, WorkspaceRoleAdmin:
+		return ShareRoleOwner
+	case WorkspaceRoleMember:
+		return ShareRoleEditor
+	case WorkspaceRoleViewer:
+		return ShareRoleViewer
+	}
+	return ""
+}
+
+// CanManage reports whether the role may manage members and invitations.
+func (r WorkspaceRole) CanManage() bool {
+	return r == WorkspaceRoleOwner || r == WorkspaceRoleAdmin
+}
+
+// Workspace is a team that owns todos together. Members and pending
+// invitations are embedded; a workspace stays small enough for that.
+type Workspace struct {
+	ID          primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	Name        string                `bson:"name" json:"name"`
+	OwnerID     primitive.ObjectID    `bson:"owner_id" json:"owner_id"`
+	Members     []WorkspaceMember     `bson:"members" json:"members"`
+	Invitations []WorkspaceInvitation `bson:"invitations" json:"invitations"`
+	CreatedAt   time.Time             `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time             `bson:"updated_at" json:"updated_at"`
+}
+
+// Member returns the membership of userID, if any.
+func (w *Workspace) Member(userID primitive.ObjectID) (*WorkspaceMember, bool) {
+	for i := range w.Members {
+		if w.Members[i].UserID == userID {
+			return &w.Members[i], true
+		}
+	}
+	return nil, false
+}
+
+type WorkspaceMember struct {
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Email    string             `bson:"email" json:"email"`
+	Name     string             `bson:"name" json:"name"`
+	Role     WorkspaceRole      `bson:"role" json:"role"`
+	JoinedAt time.Time          `bson:"joined_at" json:"joined_at"`
+}
+
+// WorkspaceInvitation is pending until the invitee accepts (it becomes a
+// member) or it is revoked.
+type WorkspaceInvitation struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Email     string             `bson:"email" json:"email"`
+	Role      WorkspaceRole      `bson:"role" json:"role"`
+	InvitedBy primitive.ObjectID `bson:"invited_by" json:"invited_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
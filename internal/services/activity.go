@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 200
+)
+
+// ActivityService reads the todo audit trail.
+type ActivityService struct {
+	events database.TodoEventRepository
+	todos  *TodoService
+}
+
+func NewActivityService(events database.TodoEventRepository, todos *TodoService) *ActivityService {
+	return &ActivityService{events: events, todos: todos}
+}
+
+// TodoHistory returns events for a single todo, newest first, to anyone who can
+// view it (including collaborators). Deleted todos keep their history.
+func (s *ActivityService) TodoHistory(ctx context.Context, userID, todoID primitive.ObjectID, before primitive.ObjectID, limit int64) ([]*models.TodoEvent, error) {
+	if _, err := s.todos.access(ctx, userID, todoID, models.ShareRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.events.ListByTodo(ctx, todoID, database.EventPage{Before: before, Limit: clampActivityLimit(limit)})
+}
+
+// Feed returns the user-wide activity feed, newest first.
+func (s *ActivityService) Feed(ctx context.Context, userID primitive.ObjectID, before primitive.ObjectID, limit int64) ([]*models.TodoEvent, error) {
+	return s.events.ListByUser(ctx, userID, database.EventPage{Before: before, Limit: clampActivityLimit(limit)})
+}
+
+func clampActivityLimit(limit int64) int64 {
+	if limit <= 0 {
+		return defaultActivityLimit
+	}
+	if limit > maxActivityLimit {
+		return maxActivityLimit
+	}
+	return limit
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/group14000/golang-todo/internal/domain"
 )
 
 const openRouterURL = "https://openrouter.ai/api/v1/chat/completions"
@@ -48,7 +50,7 @@ func NewAIService(apiKey string) *AIService {
 
 func (s *AIService) Chat(ctx context.Context, messages []AIMessage) (string, error) {
 	if s.apiKey == "" {
-		return "", errors.New("AI service not configured")
+		return "", domain.Unavailable("AI service not configured").WithCode("ai_not_configured")
 	}
 	payload := AIChatRequest{Model: "deepseek/deepseek-chat-v3.1:free", Messages: messages}
 	b, _ := json.Marshal(payload)
@@ -89,7 +91,7 @@ type StreamChunk struct {
 // ChatStream performs a streaming chat completion.
 func (s *AIService) ChatStream(ctx context.Context, messages []AIMessage) (<-chan StreamChunk, error) {
 	if s.apiKey == "" {
-		return nil, errors.New("AI service not configured")
+		return nil, domain.Unavailable("AI service not configured").WithCode("ai_not_configured")
 	}
 	payload := AIChatRequest{Model: "deepseek/deepseek-chat-v3.1:free", Messages: messages, Stream: true}
 	b, _ := json.Marshal(payload)
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	appPasswordPrefix = "tdp_"
+	// appPasswordTouchEvery limits last_used_at writes for chatty clients.
+	appPasswordTouchEvery = time.Minute
+)
+
+// AppPasswordService issues personal tokens ("app passwords") and
+// authenticates requests made with them. They are meant for protocol clients
+// such as CalDAV apps, which send them as the Basic auth password (with the
+// account email as user name) or as a Bearer token.
+type AppPasswordService struct {
+	repo  database.AppPasswordRepository
+	users database.UserRepository
+}
+
+func NewAppPasswordService(repo database.AppPasswordRepository, users database.UserRepository) *AppPasswordService {
+	return &AppPasswordService{repo: repo, users: users}
+}
+
+// Create issues a token and returns it alongside its record; it is not shown again.
+func (s *AppPasswordService) Create(ctx context.Context, userID primitive.ObjectID, name string) (*models.AppPassword, string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	token := appPasswordPrefix + hex.EncodeToString(b)
+	p := &models.AppPassword{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashAppPassword(token),
+		Prefix:    token[:len(appPasswordPrefix)+6],
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.Create(ctx, p); err != nil {
+		return nil, "", err
+	}
+	return p, token, nil
+}
+
+func (s *AppPasswordService) List(ctx context.Context, userID primitive.ObjectID) ([]*models.AppPassword, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Delete revokes a token immediately.
+func (s *AppPasswordService) Delete(ctx context.Context, userID, id primitive.ObjectID) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+// Authenticate resolves a token to its user. A non-empty username must be the
+// account's email. Failures are all reported as the same unauthorized error.
+func (s *AppPasswordService) Authenticate(ctx context.Context, username, token string) (*models.User, error) {
+	invalid := domain.Unauthorized("invalid credentials").WithCode("invalid_credentials")
+	if !strings.HasPrefix(token, appPasswordPrefix) {
+		return nil, invalid
+	}
+	p, err := s.repo.FindByTokenHash(ctx, hashAppPassword(token))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, invalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.FindUserByID(ctx, p.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, invalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if username != "" && !strings.EqualFold(username, user.Email) {
+		return nil, invalid
+	}
+
+	if now := time.Now(); p.LastUsedAt == nil || now.Sub(*p.LastUsedAt) > appPasswordTouchEvery {
+		if err := s.repo.Touch(ctx, p.ID, now); err != nil {
+			log.Printf("app passwords: touch %s: %v", p.ID.Hex(), err)
+		}
+	}
+	return user, nil
+}
+
+func hashAppPassword(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"github.com/group14000/golang-todo/internal/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// sniffLength is how much of an upload http.DetectContentType looks at.
+	sniffLength = 512
+	// maxFilenameLength caps stored filenames, in runes.
+	maxFilenameLength = 255
+)
+
+// AttachmentService stores files attached to todos. Metadata goes to Mongo and
+// the bytes to the configured blob store. Attachments stay while their todo is
+// in the trash and are removed when the trash purge removes the todo.
+type AttachmentService struct {
+	repo     database.AttachmentRepository
+	todos    *TodoService
+	store    storage.Store
+	backend  string
+	maxBytes int64
+	allowed  []string
+}
+
+func NewAttachmentService(repo database.AttachmentRepository, todos *TodoService, store storage.Store, backend string, maxBytes int64, allowed []string) *AttachmentService {
+	return &AttachmentService{repo: repo, todos: todos, store: store, backend: backend, maxBytes: maxBytes, allowed: allowed}
+}
+
+// MaxBytes is the largest file Upload accepts.
+func (s *AttachmentService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// AttachmentUpload is a file being attached. Size must be the exact length of Body.
+type AttachmentUpload struct {
+	Filename string
+	Size     int64
+	Body     io.Reader
+}
+
+// Upload attaches a file to a todo; collaborators need the editor role. The
+// content type is sniffed from the first bytes and must be on the allow list.
+func (s *AttachmentService) Upload(ctx context.Context, userID, todoID primitive.ObjectID, up AttachmentUpload) (*models.Attachment, error) {
+	todo, err := s.todos.access(ctx, userID, todoID, models.ShareRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if todo.DeletedAt != nil {
+		return nil, errTodoNotFound()
+	}
+	if up.Size > s.maxBytes {
+		return nil, s.TooLarge()
+	}
+	if up.Size == 0 {
+		return nil, domain.Validation("file is empty").WithCode("empty_file")
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(up.Body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !s.allowedType(contentType) {
+		return nil, domain.Validation("files of type %s cannot be attached", baseMediaType(contentType)).WithCode("unsupported_file_type")
+	}
+
+	id := primitive.NewObjectID()
+	att := &models.Attachment{
+		ID:          id,
+		TodoID:      todo.ID,
+		UploaderID:  userID,
+		Filename:    cleanFilename(up.Filename),
+		ContentType: contentType,
+		Size:        up.Size,
+		Backend:     s.backend,
+		StorageKey:  todo.ID.Hex() + "/" + id.Hex(),
+		CreatedAt:   time.Now(),
+	}
+	hash := sha256.New()
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), up.Body), hash)
+	if err := s.store.Put(ctx, att.StorageKey, body, up.Size, contentType); err != nil {
+		return nil, domain.Wrap(domain.ErrUnavailable, err, "could not store the file").WithCode("storage_unavailable")
+	}
+	att.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	if err := s.repo.Create(ctx, att); err != nil {
+		s.deleteBlob(ctx, att)
+		return nil, err
+	}
+	return att, nil
+}
+
+// List returns a todo's attachments, oldest first.
+func (s *AttachmentService) List(ctx context.Context, userID, todoID primitive.ObjectID) ([]*models.Attachment, error) {
+	if _, err := s.todos.Get(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByTodo(ctx, todoID)
+}
+
+// Open returns an attachment and a seekable reader over its bytes, which the
+// caller must close.
+func (s *AttachmentService) Open(ctx context.Context, userID, todoID, attachmentID primitive.ObjectID) (*models.Attachment, io.ReadSeekCloser, error) {
+	if _, err := s.todos.Get(ctx, userID, todoID); err != nil {
+		return nil, nil, err
+	}
+	att, err := s.repo.Get(ctx, todoID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if att.Backend != s.backend {
+		return nil, nil, domain.Unavailable("this file is kept in %s storage, which is not configured", att.Backend).WithCode("storage_unavailable")
+	}
+	return att, storage.Open(ctx, s.store, att.StorageKey, att.Size), nil
+}
+
+// Delete removes an attachment; collaborators need the editor role.
+func (s *AttachmentService) Delete(ctx context.Context, userID, todoID, attachmentID primitive.ObjectID) error {
+	if _, err := s.todos.access(ctx, userID, todoID, models.ShareRoleEditor); err != nil {
+		return err
+	}
+	att, err := s.repo.Get(ctx, todoID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, att.ID); err != nil {
+		return err
+	}
+	s.deleteBlob(ctx, att)
+	return nil
+}
+
+// PurgeTodo implements TodoPurger: it removes a todo's attachments and their
+// blobs. It goes on past a failed attachment and returns the first error, so
+// the rest are retried with the todo. Attachments stored in another backend
+// keep their record, the only reference to a blob this server cannot delete.
+func (s *AttachmentService) PurgeTodo(ctx context.Context, todoID primitive.ObjectID) error {
+	attachments, err := s.repo.ListAllByTodo(ctx, todoID)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for _, att := range attachments {
+		if att.Backend != s.backend {
+			log.Printf("attachments: keeping %s of purged todo %s: blob %s is in the %s backend", att.ID.Hex(), todoID.Hex(), att.StorageKey, att.Backend)
+			continue
+		}
+		// Remove the blob first so a failure leaves a record pointing at it, not an orphaned blob.
+		if err := s.store.Delete(ctx, att.StorageKey); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("delete blob %s: %w", att.StorageKey, err)
+			}
+			continue
+		}
+		if err := s.repo.Delete(ctx, att.ID); err != nil && !errors.Is(err, domain.ErrNotFound) && firstErr == nil {
+			firstErr = fmt.Errorf("delete attachment %s: %w", att.ID.Hex(), err)
+		}
+	}
+	return firstErr
+}
+
+// deleteBlob removes a blob whose record is gone; a failure only leaves an orphan, so it is logged.
+func (s *AttachmentService) deleteBlob(ctx context.Context, att *models.Attachment) {
+	if att.Backend != s.backend {
+		return
+	}
+	if err := s.store.Delete(ctx, att.StorageKey); err != nil {
+		log.Printf("attachments: delete blob %s: %v", att.StorageKey, err)
+	}
+}
+
+func (s *AttachmentService) allowedType(contentType string) bool {
+	base := baseMediaType(contentType)
+	for _, a := range s.allowed {
+		if a == base || (strings.HasSuffix(a, "/*") && strings.HasPrefix(base, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// TooLarge is the error for files over MaxBytes.
+func (s *AttachmentService) TooLarge() error {
+	return domain.Validation("attachments are limited to %s", formatBytes(s.maxBytes)).WithCode("file_too_large")
+}
+
+// baseMediaType drops parameters such as charset from a content type.
+func baseMediaType(contentType string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mt
+	}
+	return contentType
+}
+
+// cleanFilename keeps the last path element of a client-supplied name and
+// strips control characters, so it is safe to echo in Content-Disposition.
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if r := []rune(name); len(r) > maxFilenameLength {
+		name = string(r[:maxFilenameLength])
+	}
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	return name
+}
+
+func formatBytes(n int64) string {
+	if n >= 1<<20 && n%(1<<20) == 0 {
+		return fmt.Sprintf("%d MB", n>>20)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
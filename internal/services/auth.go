@@ -2,11 +2,12 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
 	"github.com/group14000/golang-todo/internal/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
@@ -28,11 +29,14 @@ func NewAuthService(userRepo database.UserRepository, otpRepo database.OTPReposi
 	}
 }
 
-func (s *AuthService) SignUp(ctx context.Context, name, email, password string) error {
+func (s *AuthService) SignUp(ctx context.Context, name, email, password, locale string) error {
 	// Check if user already exists
-	existingUser, _ := s.userRepo.FindUserByEmail(ctx, email)
+	existingUser, err := s.userRepo.FindUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
 	if existingUser != nil {
-		return fmt.Errorf("user already exists")
+		return domain.Conflict("user already exists").WithCode("user_exists")
 	}
 
 	// Generate OTP
@@ -55,7 +59,7 @@ func (s *AuthService) SignUp(ctx context.Context, name, email, password string)
 	}
 
 	// Send OTP email
-	if err := s.emailService.SendOTP(email, otpCode, "signup"); err != nil {
+	if err := s.emailService.SendOTP(email, name, otpCode, models.OTPTypeSignup, locale); err != nil {
 		return err
 	}
 
@@ -70,19 +74,22 @@ type LoginResponse struct {
 func (s *AuthService) Login(ctx context.Context, email, password string) (*LoginResponse, error) {
 	// Find user by email
 	user, err := s.userRepo.FindUserByEmail(ctx, email)
-	if err != nil {
-		return nil, err // User not found or other error
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.Unauthorized("invalid credentials").WithCode("invalid_credentials")
 	}
-
-	// Check if user is verified
-	if !user.IsVerified {
-		return nil, fmt.Errorf("please verify your email first")
+	if err != nil {
+		return nil, err
 	}
 
 	// Verify password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return nil, err // Invalid password
+		return nil, domain.Unauthorized("invalid credentials").WithCode("invalid_credentials")
+	}
+
+	// Check if user is verified (after the password check so it does not reveal accounts)
+	if !user.IsVerified {
+		return nil, domain.Forbidden("please verify your email first").WithCode("email_not_verified")
 	}
 
 	// Generate access token (15 minutes)
@@ -103,11 +110,14 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*Login
 	}, nil
 }
 
-func (s *AuthService) VerifyOTP(ctx context.Context, email, code, name, password string) error {
+func (s *AuthService) VerifyOTP(ctx context.Context, email, code, name, password, locale string) error {
 	// Find valid OTP
 	otp, err := s.otpRepo.FindValidOTP(ctx, email, code, models.OTPTypeSignup)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Validation("invalid or expired OTP").WithCode("invalid_otp")
+	}
 	if err != nil {
-		return fmt.Errorf("invalid or expired OTP")
+		return err
 	}
 
 	// Hash password
@@ -116,14 +126,19 @@ func (s *AuthService) VerifyOTP(ctx context.Context, email, code, name, password
 		return err
 	}
 
+	// Seed preferences; the locale comes from the signup request's Accept-Language
+	prefs := models.DefaultPreferences()
+	prefs.Locale = s.emailService.Templates().ResolveLocale(locale)
+
 	// Create user
 	user := &models.User{
-		ID:         primitive.NewObjectID(),
-		Name:       name,
-		Email:      email,
-		Password:   string(hashedPassword),
-		IsVerified: true,
-		CreatedAt:  time.Now(),
+		ID:          primitive.NewObjectID(),
+		Name:        name,
+		Email:       email,
+		Password:    string(hashedPassword),
+		IsVerified:  true,
+		Preferences: prefs,
+		CreatedAt:   time.Now(),
 	}
 
 	// Save user to database
@@ -135,11 +150,11 @@ func (s *AuthService) VerifyOTP(ctx context.Context, email, code, name, password
 	return s.otpRepo.MarkAsUsed(ctx, otp.ID.Hex())
 }
 
-func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+func (s *AuthService) ForgotPassword(ctx context.Context, email, locale string) error {
 	// Check if user exists
-	_, err := s.userRepo.FindUserByEmail(ctx, email)
+	user, err := s.userRepo.FindUserByEmail(ctx, email)
 	if err != nil {
-		return fmt.Errorf("user not found")
+		return err
 	}
 
 	// Generate OTP
@@ -161,15 +176,23 @@ func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
 		return err
 	}
 
+	// Prefer the user's saved locale over the request header
+	if user.Preferences.Locale != "" {
+		locale = user.Preferences.Locale
+	}
+
 	// Send OTP email
-	return s.emailService.SendOTP(email, otpCode, "forgot_password")
+	return s.emailService.SendOTP(email, user.Name, otpCode, models.OTPTypeForgotPassword, locale)
 }
 
 func (s *AuthService) ResetPassword(ctx context.Context, email, code, newPassword string) error {
 	// Find valid OTP
 	otp, err := s.otpRepo.FindValidOTP(ctx, email, code, models.OTPTypeForgotPassword)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Validation("invalid or expired OTP").WithCode("invalid_otp")
+	}
 	if err != nil {
-		return fmt.Errorf("invalid or expired OTP")
+		return err
 	}
 
 	// Hash new password
@@ -196,7 +219,7 @@ func (s *AuthService) ResetPassword(ctx context.Context, email, code, newPasswor
 func (s *AuthService) GetProfile(ctx context.Context, userID string) (*models.User, error) {
 	objID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid user ID")
+		return nil, domain.Unauthorized("invalid user ID").WithCode("invalid_token")
 	}
 
 	user, err := s.userRepo.FindUserByID(ctx, objID)
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	calDAVSyncTokenPrefix = "urn:golang-todo:sync:"
+	calDAVSyncPage        = 500
+)
+
+// CalDAVService maps a user's todos onto a single CalDAV task collection.
+// Each todo is one VTODO resource named "<id>.ics", or whatever name the
+// client used when it created the todo with PUT. Resource ETags are the todo
+// version, and sync tokens are positions in the todo event log, so
+// sync-collection reports exactly the todos that changed.
+type CalDAVService struct {
+	todos  *TodoService
+	repo   database.TodoRepository
+	events database.TodoEventRepository
+}
+
+func NewCalDAVService(todos *TodoService, repo database.TodoRepository, events database.TodoEventRepository) *CalDAVService {
+	return &CalDAVService{todos: todos, repo: repo, events: events}
+}
+
+// CalDAVPrecondition carries the conditional headers of a write.
+type CalDAVPrecondition struct {
+	IfMatch []int64 // nil means no If-Match
+	// IfNoneMatchAny is "If-None-Match: *": only create, never overwrite.
+	IfNoneMatchAny bool
+}
+
+// CalDAVChanges is the result of a sync-collection report.
+type CalDAVChanges struct {
+	Changed []*models.Todo
+	Removed []string // resource names of deleted todos
+	Token   string
+}
+
+// CalDAVResourceName is the name of the todo's resource in the collection.
+func CalDAVResourceName(t *models.Todo) string {
+	if t.CalDAV != nil && t.CalDAV.Name != "" {
+		return t.CalDAV.Name
+	}
+	return t.ID.Hex() + ".ics"
+}
+
+// Location is the timezone used for dates without one.
+func (s *CalDAVService) Location(ctx context.Context, userID primitive.ObjectID) (*time.Location, error) {
+	prefs, err := s.todos.prefs.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return prefs.Location(), nil
+}
+
+// List returns every live todo; archived ones are included, as CalDAV clients
+// keep their own view of completed tasks.
+func (s *CalDAVService) List(ctx context.Context, userID primitive.ObjectID) ([]*models.Todo, error) {
+	return s.repo.ListByUser(ctx, userID, database.TodoListOptions{Sort: "created_at", Archived: database.IncludeArchived})
+}
+
+// Get returns the live todo behind a resource name.
+func (s *CalDAVService) Get(ctx context.Context, userID primitive.ObjectID, name string) (*models.Todo, error) {
+	todo, err := s.find(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if todo.DeletedAt != nil {
+		return nil, domain.NotFound("todo not found").WithCode("todo_not_found")
+	}
+	return todo, nil
+}
+
+// find resolves a resource name, including soft-deleted todos.
+func (s *CalDAVService) find(ctx context.Context, userID primitive.ObjectID, name string) (*models.Todo, error) {
+	todo, err := s.repo.GetByCalDAVName(ctx, userID, name)
+	if !errors.Is(err, domain.ErrNotFound) {
+		return todo, err
+	}
+	if hex, ok := strings.CutSuffix(name, ".ics"); ok {
+		if id, idErr := primitive.ObjectIDFromHex(hex); idErr == nil {
+			return s.repo.GetIncludingDeleted(ctx, userID, id)
+		}
+	}
+	return nil, err
+}
+
+// Render returns the todo as an iCalendar object with a single VTODO.
+func (s *CalDAVService) Render(todo *models.Todo, loc *time.Location) []byte {
+	var buf bytes.Buffer
+	w := newICalWriter(&buf, loc, "", true, false)
+	w.Write(todo)
+	w.Close()
+	return buf.Bytes()
+}
+
+// Put creates or replaces the todo behind name from an iCalendar object. Only
+// the fields todos have are kept: summary, description, due date and
+// completion. A name whose todo was deleted brings it back. It reports whether
+// the todo was created.
+func (s *CalDAVService) Put(ctx context.Context, userID primitive.ObjectID, name string, body io.Reader, cond CalDAVPrecondition) (*models.Todo, bool, error) {
+	loc, err := s.Location(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	records, err := parseICalImport(body, loc)
+	if err != nil {
+		return nil, false, domain.Validation("%s", domain.Message(err)).WithCode("invalid_calendar_data")
+	}
+	if len(records) != 1 {
+		return nil, false, domain.Validation("a resource must contain exactly one VTODO").WithCode("unsupported_calendar_component")
+	}
+	rec := records[0]
+	if rec.Err != nil {
+		return nil, false, rec.Err
+	}
+	if strings.TrimSpace(rec.Title) == "" {
+		return nil, false, domain.Validation("SUMMARY is required").WithCode("title_required")
+	}
+
+	existing, err := s.find(ctx, userID, name)
+	if errors.Is(err, domain.ErrNotFound) {
+		if cond.IfMatch != nil {
+			return nil, false, domain.PreconditionFailed("resource does not exist").WithCode("version_mismatch")
+		}
+		todo, err := s.create(ctx, userID, name, rec)
+		return todo, err == nil, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if existing.DeletedAt != nil {
+		if cond.IfMatch != nil {
+			return nil, false, domain.PreconditionFailed("resource does not exist").WithCode("version_mismatch")
+		}
+		if existing, err = s.todos.Restore(ctx, userID, existing.ID); err != nil {
+			return nil, false, err
+		}
+	} else if cond.IfNoneMatchAny {
+		return nil, false, domain.PreconditionFailed("resource already exists").WithCode("resource_exists")
+	}
+
+	// CalDAV clients have already marked the task done and cannot show why it was refused.
+	u := TodoUpdate{IfVersions: cond.IfMatch, Force: true}
+	changed := false
+	if rec.Title != existing.Title {
+		u.Title, changed = &rec.Title, true
+	}
+	if rec.Description != existing.Description {
+		u.Description, changed = &rec.Description, true
+	}
+	if rec.Completed != existing.Completed {
+		u.Completed, changed = &rec.Completed, true
+	}
+	switch {
+	case rec.DueAt != nil && (existing.DueAt == nil || !existing.DueAt.Equal(*rec.DueAt)):
+		u.DueAt, changed = rec.DueAt, true
+	case rec.DueAt == nil && existing.DueAt != nil:
+		u.ClearDueAt, changed = true, true
+	}
+	if !changed {
+		if cond.IfMatch != nil && !slices.Contains(cond.IfMatch, existing.Version) {
+			return nil, false, domain.PreconditionFailed("If-Match does not match the current version").WithCode("version_mismatch")
+		}
+		return existing, false, nil
+	}
+	todo, err := s.todos.Update(ctx, userID, existing.ID, u)
+	return todo, false, err
+}
+
+func (s *CalDAVService) create(ctx context.Context, userID primitive.ObjectID, name string, rec importRecord) (*models.Todo, error) {
+	todo, err := s.todos.Create(ctx, userID, TodoInput{
+		Title:       rec.Title,
+		Description: rec.Description,
+		DueAt:       rec.DueAt,
+		CalDAV:      &models.TodoCalDAV{Name: name, UID: rec.UID},
+	})
+	if err != nil || !rec.Completed {
+		return todo, err
+	}
+	completed := true
+	return s.todos.Update(ctx, userID, todo.ID, TodoUpdate{Completed: &completed})
+}
+
+// Delete soft-deletes the todo behind name.
+func (s *CalDAVService) Delete(ctx context.Context, userID primitive.ObjectID, name string, ifVersions []int64) error {
+	todo, err := s.Get(ctx, userID, name)
+	if err != nil {
+		return err
+	}
+	return s.todos.Delete(ctx, userID, todo.ID, ifVersions)
+}
+
+// SyncToken identifies the collection's current state: the newest todo event.
+// It doubles as the CTag.
+func (s *CalDAVService) SyncToken(ctx context.Context, userID primitive.ObjectID) (string, error) {
+	latest, err := s.events.ListByUser(ctx, userID, database.EventPage{Limit: 1})
+	if err != nil {
+		return "", err
+	}
+	if len(latest) == 0 {
+		return calDAVSyncTokenPrefix + primitive.NilObjectID.Hex(), nil
+	}
+	return calDAVSyncTokenPrefix + latest[0].ID.Hex(), nil
+}
+
+// Changes lists what changed after token. An empty token means an initial
+// sync: every live todo is returned as changed.
+func (s *CalDAVService) Changes(ctx context.Context, userID primitive.ObjectID, token string) (*CalDAVChanges, error) {
+	if token == "" {
+		// Read the token first so changes made while listing are picked up next time.
+		current, err := s.SyncToken(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		todos, err := s.List(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &CalDAVChanges{Changed: todos, Token: current}, nil
+	}
+
+	hex, ok := strings.CutPrefix(token, calDAVSyncTokenPrefix)
+	after, err := primitive.ObjectIDFromHex(hex)
+	if !ok || err != nil {
+		return nil, domain.Forbidden("invalid sync token").WithCode("invalid_sync_token")
+	}
+
+	var order []primitive.ObjectID
+	seen := map[primitive.ObjectID]bool{}
+	for {
+		events, err := s.events.ListAfter(ctx, userID, after, nil, calDAVSyncPage)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			if !seen[e.TodoID] {
+				seen[e.TodoID] = true
+				order = append(order, e.TodoID)
+			}
+			after = e.ID
+		}
+		if len(events) < calDAVSyncPage {
+			break
+		}
+	}
+
+	changes := &CalDAVChanges{Token: calDAVSyncTokenPrefix + after.Hex()}
+	for _, id := range order {
+		todo, err := s.repo.GetIncludingDeleted(ctx, userID, id)
+		if errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if todo.DeletedAt != nil {
+			changes.Removed = append(changes.Removed, CalDAVResourceName(todo))
+		} else {
+			changes.Changed = append(changes.Changed, todo)
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CalendarService manages each user's secret iCalendar feed URL and renders
+// the feed. Anyone holding the URL can read the user's todos, so the token is
+// random, stored hashed, and can be revoked or replaced at any time.
+type CalendarService struct {
+	users   database.UserRepository
+	todos   database.TodoRepository
+	baseURL string
+}
+
+func NewCalendarService(users database.UserRepository, todos database.TodoRepository, baseURL string) *CalendarService {
+	return &CalendarService{users: users, todos: todos, baseURL: baseURL}
+}
+
+// CalendarFeedInfo describes a user's feed. URL is only set right after the
+// token is generated.
+type CalendarFeedInfo struct {
+	Enabled   bool       `json:"enabled" example:"true"`
+	URL       string     `json:"url,omitempty" example:"https://api.todo.example.com/calendar/3f9c...e1.ics"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// CalendarComponents selects what a feed contains.
+type CalendarComponents struct {
+	Todos  bool // a VTODO per todo
+	Events bool // a VEVENT per todo with a due date
+}
+
+func (s *CalendarService) Status(ctx context.Context, userID primitive.ObjectID) (*CalendarFeedInfo, error) {
+	user, err := s.users.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Calendar == nil {
+		return &CalendarFeedInfo{}, nil
+	}
+	return &CalendarFeedInfo{Enabled: true, CreatedAt: &user.Calendar.CreatedAt}, nil
+}
+
+// Regenerate issues a new feed token, invalidating any previous URL.
+func (s *CalendarService) Regenerate(ctx context.Context, userID primitive.ObjectID) (*CalendarFeedInfo, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(b)
+	feed := &models.CalendarFeed{TokenHash: hashCalendarToken(token), CreatedAt: time.Now()}
+	if err := s.users.SetCalendarFeed(ctx, userID, feed); err != nil {
+		return nil, err
+	}
+	return &CalendarFeedInfo{
+		Enabled:   true,
+		URL:       s.baseURL + "/calendar/" + token + ".ics",
+		CreatedAt: &feed.CreatedAt,
+	}, nil
+}
+
+// Revoke disables the feed; the old URL stops working immediately.
+func (s *CalendarService) Revoke(ctx context.Context, userID primitive.ObjectID) error {
+	return s.users.SetCalendarFeed(ctx, userID, nil)
+}
+
+// Feed writes the calendar for token to w. Unknown or revoked tokens are not found.
+func (s *CalendarService) Feed(ctx context.Context, token string, components CalendarComponents, w io.Writer) error {
+	user, err := s.users.FindUserByCalendarToken(ctx, hashCalendarToken(token))
+	if err != nil {
+		return err
+	}
+	out := newICalWriter(w, user.Preferences.WithDefaults().Location(), "Todos", components.Todos, components.Events)
+	if err := s.todos.EachByUser(ctx, user.ID, database.TodoListOptions{Sort: "created_at"}, out.Write); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
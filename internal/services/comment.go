@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxCommentMentions caps how many people one comment can notify.
+	maxCommentMentions = 20
+	// mentionExcerptLength is how much of the comment a mention email quotes, in runes.
+	mentionExcerptLength = 280
+)
+
+// mentionPattern matches "@" followed by an email address, e.g. "@jane@example.com",
+// at the start of the body or after a character that cannot end an address.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9._%+-])@([A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+
+// CommentService manages comments on todos. Anyone who can see a todo can
+// comment on it and @mention others who can see it.
+type CommentService struct {
+	comments database.CommentRepository
+	todos    *TodoService
+	users    database.UserRepository
+	email    *EmailService
+	baseURL  string
+}
+
+func NewCommentService(comments database.CommentRepository, todos *TodoService, users database.UserRepository, email *EmailService, baseURL string) *CommentService {
+	return &CommentService{comments: comments, todos: todos, users: users, email: email, baseURL: baseURL}
+}
+
+// CommentInput is a new comment. ParentID replies to a comment; replies to a
+// reply join its thread.
+type CommentInput struct {
+	Body     string
+	ParentID *primitive.ObjectID
+}
+
+// List returns the todo's threads oldest first: top-level comments with their
+// replies. Deleted comments are left out unless replies still hang off them.
+func (s *CommentService) List(ctx context.Context, userID, todoID primitive.ObjectID) ([]*models.Comment, error) {
+	if _, err := s.todos.Get(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	all, err := s.comments.ListByTodo(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := map[primitive.ObjectID]*models.Comment{}
+	for _, c := range all {
+		if c.ParentID == nil {
+			roots[c.ID] = c
+		}
+	}
+	for _, c := range all {
+		if c.ParentID == nil || c.DeletedAt != nil {
+			continue
+		}
+		if root := roots[*c.ParentID]; root != nil {
+			root.Replies = append(root.Replies, c)
+		}
+	}
+	threads := []*models.Comment{}
+	for _, c := range all {
+		if c.ParentID == nil && (c.DeletedAt == nil || len(c.Replies) > 0) {
+			threads = append(threads, c)
+		}
+	}
+	return threads, nil
+}
+
+// Create adds a comment and notifies the people it mentions.
+func (s *CommentService) Create(ctx context.Context, userID, todoID primitive.ObjectID, in CommentInput) (*models.Comment, error) {
+	todo, err := s.todos.Get(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	parentID := in.ParentID
+	if parentID != nil {
+		parent, err := s.comments.Get(ctx, todoID, *parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.DeletedAt != nil {
+			return nil, errCommentNotFound()
+		}
+		if parent.ParentID != nil {
+			parentID = parent.ParentID
+		}
+	}
+	mentions, err := s.mentions(ctx, todo, userID, in.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &models.Comment{
+		ID:        primitive.NewObjectID(),
+		TodoID:    todo.ID,
+		ParentID:  parentID,
+		AuthorID:  userID,
+		Body:      in.Body,
+		Mentions:  mentions,
+		CreatedAt: time.Now(),
+	}
+	if err := s.comments.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+	// The comment is saved, so a failed count update is only logged.
+	if err := s.todos.repo.AddCommentCount(ctx, todo.ID, 1); err != nil {
+		log.Printf("comments: count for %s: %v", todo.ID.Hex(), err)
+	}
+	s.todos.recordComment(ctx, todo, comment)
+	s.notify(ctx, todo, comment, mentions)
+	return comment, nil
+}
+
+// Edit replaces the body of the caller's own comment. Only people newly
+// mentioned by the edit are notified.
+func (s *CommentService) Edit(ctx context.Context, userID, todoID, commentID primitive.ObjectID, body string) (*models.Comment, error) {
+	todo, err := s.todos.Get(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.comments.Get(ctx, todoID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if current.DeletedAt != nil {
+		return nil, errCommentNotFound()
+	}
+	if current.AuthorID != userID {
+		return nil, domain.Forbidden("only the author can edit a comment").WithCode("insufficient_permission")
+	}
+	mentions, err := s.mentions(ctx, todo, userID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.comments.Update(ctx, todoID, commentID, bson.M{"body": body, "mentions": mentions, "edited_at": time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	var added []primitive.ObjectID
+	for _, id := range mentions {
+		if !slices.Contains(current.Mentions, id) {
+			added = append(added, id)
+		}
+	}
+	s.notify(ctx, todo, comment, added)
+	return comment, nil
+}
+
+// Delete removes a comment. Authors can delete their own; people with the
+// owner role on the todo can delete any.
+func (s *CommentService) Delete(ctx context.Context, userID, todoID, commentID primitive.ObjectID) error {
+	if _, err := s.todos.Get(ctx, userID, todoID); err != nil {
+		return err
+	}
+	current, err := s.comments.Get(ctx, todoID, commentID)
+	if err != nil {
+		return err
+	}
+	if current.DeletedAt != nil {
+		return errCommentNotFound()
+	}
+	if current.AuthorID != userID {
+		if _, err := s.todos.access(ctx, userID, todoID, models.ShareRoleOwner); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.comments.Update(ctx, todoID, commentID, bson.M{"body": "", "mentions": nil, "deleted_at": time.Now()}); err != nil {
+		return err
+	}
+	if err := s.todos.repo.AddCommentCount(ctx, todoID, -1); err != nil {
+		log.Printf("comments: count for %s: %v", todoID.Hex(), err)
+	}
+	return nil
+}
+
+// mentions resolves the @email mentions in body to the users who can see todo.
+// Unknown addresses and people without access are ignored, as is the author.
+func (s *CommentService) mentions(ctx context.Context, todo *models.Todo, authorID primitive.ObjectID, body string) ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		email := normalizeEmail(m[1])
+		if seen[email] {
+			continue
+		}
+		seen[email] = true
+		if len(seen) > maxCommentMentions {
+			return nil, domain.Validation("a comment can mention at most %d people", maxCommentMentions).WithCode("too_many_mentions")
+		}
+
+		user, err := s.users.FindUserByEmail(ctx, email)
+		if errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if user.ID == authorID || slices.Contains(ids, user.ID) {
+			continue
+		}
+		ok, err := s.todos.canSee(ctx, todo, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			ids = append(ids, user.ID)
+		}
+	}
+	return ids, nil
+}
+
+// notify emails the mentioned users who have not turned mention emails off.
+// The comment is already saved, so failures are logged.
+func (s *CommentService) notify(ctx context.Context, todo *models.Todo, comment *models.Comment, userIDs []primitive.ObjectID) {
+	if len(userIDs) == 0 {
+		return
+	}
+	author, err := s.users.FindUserByID(ctx, comment.AuthorID)
+	if err != nil {
+		log.Printf("comments: mention author %s: %v", comment.AuthorID.Hex(), err)
+		return
+	}
+	for _, id := range userIDs {
+		user, err := s.users.FindUserByID(ctx, id)
+		if err != nil {
+			log.Printf("comments: mention recipient %s: %v", id.Hex(), err)
+			continue
+		}
+		prefs := user.Preferences.WithDefaults()
+		if !*prefs.EmailNotifications.Mentions {
+			continue
+		}
+		err = s.email.Send(user.Email, "comment_mention", prefs.Locale, map[string]any{
+			"AuthorName": author.Name,
+			"Title":      todo.Title,
+			"Excerpt":    excerpt(comment.Body, mentionExcerptLength),
+			"URL":        s.baseURL,
+		})
+		if err != nil {
+			log.Printf("comments: mention email for %s on %s: %v", id.Hex(), comment.ID.Hex(), err)
+		}
+	}
+}
+
+func errCommentNotFound() error {
+	return domain.NotFound("comment not found").WithCode("comment_not_found")
+}
+
+// excerpt shortens s to at most n runes, marking the cut with an ellipsis.
+func excerpt(s string, n int) string {
+	s = strings.TrimSpace(s)
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n])) + "…"
+}
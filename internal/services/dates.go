@@ -0,0 +1,53 @@
+package services
+
+import (
+	"time"
+
+	"github.com/group14000/golang-todo/internal/domain"
+)
+
+// Named due-date windows understood by the todo list filter.
+const (
+	DueToday    = "today"
+	DueTomorrow = "tomorrow"
+	DueThisWeek = "this_week"
+	DueNextWeek = "next_week"
+	DueOverdue  = "overdue"
+)
+
+// startOfDay returns local midnight of t in loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// startOfWeek returns local midnight of the most recent firstDay on or before t.
+func startOfWeek(t time.Time, loc *time.Location, firstDay time.Weekday) time.Time {
+	day := startOfDay(t, loc)
+	offset := (int(day.Weekday()) - int(firstDay) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// dueWindow resolves a named window to [from, before) in the user's timezone.
+// A nil bound means open-ended.
+func dueWindow(name string, now time.Time, loc *time.Location, firstDay time.Weekday) (from, before *time.Time, err error) {
+	day := startOfDay(now, loc)
+	week := startOfWeek(now, loc, firstDay)
+	var f, b time.Time
+	switch name {
+	case DueToday:
+		f, b = day, day.AddDate(0, 0, 1)
+	case DueTomorrow:
+		f, b = day.AddDate(0, 0, 1), day.AddDate(0, 0, 2)
+	case DueThisWeek:
+		f, b = week, week.AddDate(0, 0, 7)
+	case DueNextWeek:
+		f, b = week.AddDate(0, 0, 7), week.AddDate(0, 0, 14)
+	case DueOverdue:
+		b = now
+		return nil, &b, nil
+	default:
+		return nil, nil, domain.Validation("unknown due window %q", name).WithCode("invalid_due_window")
+	}
+	return &f, &b, nil
+}
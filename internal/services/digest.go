@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Digest kinds; also used as unsubscribe token scopes ("all" opts out of both).
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+	DigestAll    = "all"
+)
+
+const (
+	digestTick           = 5 * time.Minute
+	unsubscribeTokenUse  = "unsubscribe"
+	unsubscribeTokenTTL  = 90 * 24 * time.Hour
+	digestItemDateLayout = "Mon Jan 2 15:04"
+)
+
+// DigestService builds and sends daily/weekly todo digests in each user's timezone.
+type DigestService struct {
+	userRepo     database.UserRepository
+	todoRepo     database.TodoRepository
+	emailService *EmailService
+	jwtSecret    string
+	baseURL      string
+	hour         int
+}
+
+func NewDigestService(userRepo database.UserRepository, todoRepo database.TodoRepository, emailService *EmailService, jwtSecret, baseURL string, hour int) *DigestService {
+	return &DigestService{
+		userRepo:     userRepo,
+		todoRepo:     todoRepo,
+		emailService: emailService,
+		jwtSecret:    jwtSecret,
+		baseURL:      baseURL,
+		hour:         hour,
+	}
+}
+
+// DigestItem is a todo line as rendered in the digest templates.
+type DigestItem struct {
+	Title string
+	Due   string
+}
+
+// Digest is the template data for the "digest" email.
+type Digest struct {
+	Name           string
+	Period         string
+	Overdue        []DigestItem
+	Due            []DigestItem
+	Completed      []DigestItem
+	UnsubscribeURL string
+}
+
+// Empty reports whether there is nothing worth emailing.
+func (d *Digest) Empty() bool {
+	return len(d.Overdue) == 0 && len(d.Due) == 0 && len(d.Completed) == 0
+}
+
+// Start runs the scheduler until ctx is cancelled.
+func (s *DigestService) Start(ctx context.Context) {
+	ticker := time.NewTicker(digestTick)
+	defer ticker.Stop()
+	for {
+		s.RunOnce(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends every digest that is due at now. Failures are logged per user.
+func (s *DigestService) RunOnce(ctx context.Context, now time.Time) {
+	users, err := s.userRepo.ListDigestSubscribers(ctx)
+	if err != nil {
+		log.Printf("digest: list subscribers: %v", err)
+		return
+	}
+	for _, u := range users {
+		prefs := u.Preferences.WithDefaults()
+		local := now.In(prefs.Location())
+		if local.Hour() < s.hour {
+			continue
+		}
+		today := local.Format("2006-01-02")
+
+		if prefs.EmailNotifications.DailyDigest && u.Digest.LastDaily != today {
+			s.sendIfClaimed(ctx, u, DigestDaily, today, now)
+		}
+		if prefs.EmailNotifications.WeeklyDigest && local.Weekday() == prefs.FirstWeekday() && u.Digest.LastWeekly != today {
+			s.sendIfClaimed(ctx, u, DigestWeekly, today, now)
+		}
+	}
+}
+
+func (s *DigestService) sendIfClaimed(ctx context.Context, u *models.User, kind, today string, now time.Time) {
+	claimed, err := s.userRepo.ClaimDigest(ctx, u.ID, kind, today)
+	if err != nil {
+		log.Printf("digest: claim %s for %s: %v", kind, u.ID.Hex(), err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	if err := s.Send(ctx, u, kind, now); err != nil {
+		log.Printf("digest: send %s to %s: %v", kind, u.ID.Hex(), err)
+	}
+}
+
+// Send builds and emails a digest to u regardless of schedule. Empty digests are skipped.
+func (s *DigestService) Send(ctx context.Context, u *models.User, kind string, now time.Time) error {
+	digest, err := s.Build(ctx, u, kind, now)
+	if err != nil {
+		return err
+	}
+	if digest.Empty() {
+		return nil
+	}
+	headers := map[string]string{
+		"List-Unsubscribe":      "<" + digest.UnsubscribeURL + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	return s.emailService.SendWithHeaders(u.Email, "digest", u.Preferences.WithDefaults().Locale, digest, headers)
+}
+
+// Build collects overdue, due and recently completed todos for the digest period.
+func (s *DigestService) Build(ctx context.Context, u *models.User, kind string, now time.Time) (*Digest, error) {
+	prefs := u.Preferences.WithDefaults()
+	loc := prefs.Location()
+
+	window := DueToday
+	completedSince := now.Add(-24 * time.Hour)
+	if kind == DigestWeekly {
+		window = DueThisWeek
+		completedSince = now.AddDate(0, 0, -7)
+	}
+
+	open := false
+	done := true
+	today := startOfDay(now, loc)
+	overdue, err := s.todoRepo.ListByUser(ctx, u.ID, database.TodoListOptions{DueBefore: &today, Completed: &open, Sort: "due_at"})
+	if err != nil {
+		return nil, err
+	}
+	from, before, err := dueWindow(window, now, loc, prefs.FirstWeekday())
+	if err != nil {
+		return nil, err
+	}
+	due, err := s.todoRepo.ListByUser(ctx, u.ID, database.TodoListOptions{DueFrom: from, DueBefore: before, Completed: &open, Sort: "due_at"})
+	if err != nil {
+		return nil, err
+	}
+	completed, err := s.todoRepo.ListByUser(ctx, u.ID, database.TodoListOptions{Completed: &done, CompletedFrom: &completedSince, Sort: "-completed_at", Archived: database.IncludeArchived})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.UnsubscribeToken(u.ID, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Digest{
+		Name:           u.Name,
+		Period:         kind,
+		Overdue:        digestItems(overdue, loc),
+		Due:            digestItems(due, loc),
+		Completed:      digestItems(completed, loc),
+		UnsubscribeURL: s.baseURL + "/unsubscribe?token=" + url.QueryEscape(token),
+	}, nil
+}
+
+func digestItems(todos []*models.Todo, loc *time.Location) []DigestItem {
+	items := make([]DigestItem, 0, len(todos))
+	for _, t := range todos {
+		item := DigestItem{Title: t.Title}
+		if t.DueAt != nil {
+			item.Due = t.DueAt.In(loc).Format(digestItemDateLayout)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// UnsubscribeToken returns a signed token, valid for 90 days, that opts userID out of kind.
+// Its audience keeps it from being accepted anywhere a login token is.
+func (s *DigestService) UnsubscribeToken(userID primitive.ObjectID, kind string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"uid":     userID.Hex(),
+		"aud":     unsubscribeTokenUse,
+		"purpose": unsubscribeTokenUse,
+		"kind":    kind,
+		"iat":     now.Unix(),
+		"exp":     now.Add(unsubscribeTokenTTL).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+}
+
+// CheckUnsubscribeToken validates a token from an email link without changing
+// anything and returns the digest kind it would turn off.
+func (s *DigestService) CheckUnsubscribeToken(token string) (string, error) {
+	_, kind, err := s.parseUnsubscribeToken(token)
+	return kind, err
+}
+
+// Unsubscribe validates a token from an email link and turns the matching digest off.
+// It returns the kind that was disabled.
+func (s *DigestService) Unsubscribe(ctx context.Context, token string) (string, error) {
+	uid, kind, err := s.parseUnsubscribeToken(token)
+	if err != nil {
+		return "", err
+	}
+	update := bson.M{}
+	switch kind {
+	case DigestDaily:
+		update["preferences.email_notifications.daily_digest"] = false
+	case DigestWeekly:
+		update["preferences.email_notifications.weekly_digest"] = false
+	case DigestAll:
+		update["preferences.email_notifications.daily_digest"] = false
+		update["preferences.email_notifications.weekly_digest"] = false
+	}
+	if err := s.userRepo.UpdatePreferences(ctx, uid, update); err != nil {
+		return "", err
+	}
+	return kind, nil
+}
+
+func (s *DigestService) parseUnsubscribeToken(token string) (primitive.ObjectID, string, error) {
+	invalid := domain.Validation("invalid or expired unsubscribe token").WithCode("invalid_unsubscribe_token")
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return []byte(s.jwtSecret), nil
+	}, jwt.WithAudience(unsubscribeTokenUse), jwt.WithExpirationRequired())
+	if err != nil || !parsed.Valid {
+		return primitive.NilObjectID, "", invalid
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != unsubscribeTokenUse {
+		return primitive.NilObjectID, "", invalid
+	}
+	uidHex, _ := claims["uid"].(string)
+	uid, err := primitive.ObjectIDFromHex(uidHex)
+	if err != nil {
+		return primitive.NilObjectID, "", invalid
+	}
+	kind, _ := claims["kind"].(string)
+	if kind != DigestDaily && kind != DigestWeekly && kind != DigestAll {
+		return primitive.NilObjectID, "", invalid
+	}
+	return uid, kind, nil
+}
@@ -4,44 +4,78 @@ import (
 	"crypto/rand"
 	"crypto/tls"
 	"fmt"
+	"log"
 	"math/big"
 
 	"github.com/group14000/golang-todo/internal/config"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
 	"gopkg.in/gomail.v2"
 )
 
 type EmailService struct {
-	config *config.Config
+	config    *config.Config
+	templates *EmailTemplates
 }
 
 func NewEmailService(cfg *config.Config) *EmailService {
-	return &EmailService{config: cfg}
-}
-
-func (s *EmailService) SendOTP(to, otp string, otpType string) error {
-	subject := "Verify Your Email"
-	body := fmt.Sprintf(`
-		<h2>Email Verification</h2>
-		<p>Your OTP for %s is: <strong>%s</strong></p>
-		<p>This code will expire in 10 minutes.</p>
-		<p>If you didn't request this, please ignore this email.</p>
-	`, otpType, otp)
-
-	if otpType == "forgot_password" {
-		subject = "Reset Your Password"
-		body = fmt.Sprintf(`
-			<h2>Password Reset</h2>
-			<p>Your OTP for password reset is: <strong>%s</strong></p>
-			<p>This code will expire in 10 minutes.</p>
-			<p>If you didn't request this, please ignore this email.</p>
-		`, otp)
+	brand := Branding{Name: cfg.BrandName, URL: cfg.BrandURL, LogoURL: cfg.BrandLogoURL}
+	templates, err := NewEmailTemplates(cfg.EmailTemplateDir, cfg.DefaultLocale, brand)
+	if err != nil {
+		// An unusable override dir should not take email down; fall back to embedded templates.
+		log.Printf("Warning: %v; using embedded email templates", err)
+		templates, err = NewEmailTemplates("", cfg.DefaultLocale, brand)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	return &EmailService{config: cfg, templates: templates}
+}
+
+// Templates exposes the template set (used by the admin preview endpoint).
+func (s *EmailService) Templates() *EmailTemplates {
+	return s.templates
+}
+
+// SendOTP emails a one-time code using the template matching the OTP type.
+func (s *EmailService) SendOTP(to, name, otp string, otpType models.OTPType, locale string) error {
+	var tmpl string
+	switch otpType {
+	case models.OTPTypeSignup:
+		tmpl = "otp_signup"
+	case models.OTPTypeForgotPassword:
+		tmpl = "otp_forgot_password"
+	default:
+		return fmt.Errorf("unsupported OTP type %q", otpType)
+	}
+	return s.Send(to, tmpl, locale, map[string]any{
+		"Name":             name,
+		"Code":             otp,
+		"ExpiresInMinutes": 10,
+	})
+}
+
+// Send renders the named template and delivers it as a multipart/alternative message.
+func (s *EmailService) Send(to, template, locale string, data any) error {
+	return s.SendWithHeaders(to, template, locale, data, nil)
+}
+
+// SendWithHeaders is Send with extra message headers (e.g. List-Unsubscribe).
+func (s *EmailService) SendWithHeaders(to, template, locale string, data any, headers map[string]string) error {
+	rendered, err := s.templates.Render(template, locale, data)
+	if err != nil {
+		return err
 	}
 
 	m := gomail.NewMessage()
 	m.SetHeader("From", s.config.EmailUser)
 	m.SetHeader("To", to)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", body)
+	m.SetHeader("Subject", rendered.Subject)
+	for k, v := range headers {
+		m.SetHeader(k, v)
+	}
+	m.SetBody("text/plain", rendered.Text)
+	m.AddAlternative("text/html", rendered.HTML)
 
 	d := gomail.NewDialer(s.config.EmailHost, s.config.EmailPort, s.config.EmailUser, s.config.EmailPassword)
 	if s.config.EmailUseTLS {
@@ -51,6 +85,15 @@ func (s *EmailService) SendOTP(to, otp string, otpType string) error {
 	return d.DialAndSend(m)
 }
 
+// Preview renders a template with built-in sample data without sending it.
+func (s *EmailService) Preview(template, locale string) (*RenderedEmail, error) {
+	data, ok := emailSampleData[template]
+	if !ok {
+		return nil, domain.NotFound("email template %q not found", template).WithCode("template_not_found")
+	}
+	return s.templates.Render(template, locale, data)
+}
+
 func (s *EmailService) GenerateOTP() string {
 	const digits = "0123456789"
 	otp := make([]byte, 6)
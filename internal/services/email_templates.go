@@ -0,0 +1,341 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/email
+var embeddedEmailTemplates embed.FS
+
+// Branding holds the variables shared by every email layout.
+type Branding struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	LogoURL string `json:"logo_url"`
+}
+
+// RenderedEmail is the output of a template render, ready to be sent as multipart/alternative.
+type RenderedEmail struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+	Locale  string `json:"locale"`
+}
+
+// emailView is the root object templates are executed against.
+type emailView struct {
+	Brand   Branding
+	Locale  string
+	Subject string
+	Data    any
+}
+
+// EmailTemplates renders localized subject/text/html email bodies.
+//
+// Layout on disk (embedded and override directory share it):
+//
+//	layout.html, layout.txt            shared branding wrapper, must call {{template "content" .}}
+//	<locale>/<name>.subject.txt        subject line (text/template)
+//	<locale>/<name>.txt                plain-text body, defines "content"
+//	<locale>/<name>.html               HTML body, defines "content"
+//
+// Files in the override directory take precedence over the embedded defaults.
+type EmailTemplates struct {
+	fsys          fs.FS
+	base          fs.FS
+	override      fs.FS
+	brand         Branding
+	defaultLocale string
+}
+
+func NewEmailTemplates(overrideDir, defaultLocale string, brand Branding) (*EmailTemplates, error) {
+	base, err := fs.Sub(embeddedEmailTemplates, "templates/email")
+	if err != nil {
+		return nil, err
+	}
+	t := &EmailTemplates{base: base, brand: brand, defaultLocale: strings.ToLower(defaultLocale)}
+	if overrideDir != "" {
+		info, err := os.Stat(overrideDir)
+		if err != nil {
+			return nil, fmt.Errorf("email template dir: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("email template dir %s is not a directory", overrideDir)
+		}
+		t.override = os.DirFS(overrideDir)
+	}
+	t.fsys = overlayFS{override: t.override, base: t.base}
+	if t.defaultLocale == "" {
+		t.defaultLocale = "en"
+	}
+	return t, nil
+}
+
+// Render produces subject, text and HTML for the named template in the best matching locale.
+func (t *EmailTemplates) Render(name, locale string, data any) (*RenderedEmail, error) {
+	loc := t.ResolveLocale(locale)
+	if !t.exists(loc, name) {
+		if !t.exists(t.defaultLocale, name) {
+			return nil, fmt.Errorf("email template %q not found", name)
+		}
+		loc = t.defaultLocale
+	}
+
+	view := emailView{Brand: t.brand, Locale: loc, Data: data}
+
+	subjectSrc, err := fs.ReadFile(t.fsys, loc+"/"+name+".subject.txt")
+	if err != nil {
+		return nil, err
+	}
+	subjectTpl, err := texttemplate.New("subject").Parse(string(subjectSrc))
+	if err != nil {
+		return nil, err
+	}
+	var subject bytes.Buffer
+	if err := subjectTpl.Execute(&subject, view); err != nil {
+		return nil, err
+	}
+	view.Subject = strings.TrimSpace(subject.String())
+
+	text, err := t.renderText(loc, name, view)
+	if err != nil {
+		return nil, err
+	}
+	html, err := t.renderHTML(loc, name, view)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RenderedEmail{Subject: view.Subject, Text: text, HTML: html, Locale: loc}, nil
+}
+
+func (t *EmailTemplates) renderText(loc, name string, view emailView) (string, error) {
+	layout, err := fs.ReadFile(t.fsys, "layout.txt")
+	if err != nil {
+		return "", err
+	}
+	body, err := fs.ReadFile(t.fsys, loc+"/"+name+".txt")
+	if err != nil {
+		return "", err
+	}
+	tpl, err := texttemplate.New("layout").Parse(string(layout))
+	if err != nil {
+		return "", err
+	}
+	if _, err := tpl.Parse(string(body)); err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tpl.Execute(&out, view); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()) + "\n", nil
+}
+
+func (t *EmailTemplates) renderHTML(loc, name string, view emailView) (string, error) {
+	layout, err := fs.ReadFile(t.fsys, "layout.html")
+	if err != nil {
+		return "", err
+	}
+	body, err := fs.ReadFile(t.fsys, loc+"/"+name+".html")
+	if err != nil {
+		return "", err
+	}
+	tpl, err := htmltemplate.New("layout").Parse(string(layout))
+	if err != nil {
+		return "", err
+	}
+	if _, err := tpl.Parse(string(body)); err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tpl.Execute(&out, view); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func (t *EmailTemplates) exists(locale, name string) bool {
+	_, err := fs.Stat(t.fsys, locale+"/"+name+".subject.txt")
+	return err == nil
+}
+
+// Locales lists the locale directories available across embedded and override templates.
+func (t *EmailTemplates) Locales() []string {
+	seen := map[string]bool{}
+	for _, fsys := range []fs.FS{t.override, t.base} {
+		if fsys == nil {
+			continue
+		}
+		entries, err := fs.ReadDir(fsys, ".")
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				seen[strings.ToLower(e.Name())] = true
+			}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for l := range seen {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Names lists the template names available in the default locale.
+func (t *EmailTemplates) Names() []string {
+	seen := map[string]bool{}
+	for _, fsys := range []fs.FS{t.override, t.base} {
+		if fsys == nil {
+			continue
+		}
+		entries, err := fs.ReadDir(fsys, t.defaultLocale)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if name, ok := strings.CutSuffix(e.Name(), ".subject.txt"); ok {
+				seen[name] = true
+			}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for n := range seen {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ResolveLocale picks the best available locale for a preference string.
+// It accepts a bare tag ("es", "pt-BR") or a full Accept-Language header value.
+func (t *EmailTemplates) ResolveLocale(pref string) string {
+	available := map[string]bool{}
+	for _, l := range t.Locales() {
+		available[l] = true
+	}
+	for _, tag := range parseAcceptLanguage(pref) {
+		if available[tag] {
+			return tag
+		}
+		if base, _, ok := strings.Cut(tag, "-"); ok && available[base] {
+			return base
+		}
+	}
+	return t.defaultLocale
+}
+
+// parseAcceptLanguage returns lower-cased language tags ordered by quality.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+		if tag == "" || tag == "*" || q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	out := make([]string, len(tags))
+	for i, w := range tags {
+		out[i] = w.tag
+	}
+	return out
+}
+
+// overlayFS serves files from override first, falling back to base.
+type overlayFS struct {
+	override fs.FS
+	base     fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if o.override != nil {
+		f, err := o.override.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return o.base.Open(name)
+}
+
+// emailSampleData provides placeholder data for admin previews.
+var emailSampleData = map[string]map[string]any{
+	"otp_signup": {
+		"Name":             "Jane Doe",
+		"Code":             "123456",
+		"ExpiresInMinutes": 10,
+	},
+	"otp_forgot_password": {
+		"Name":             "Jane Doe",
+		"Code":             "654321",
+		"ExpiresInMinutes": 10,
+	},
+	"digest": {
+		"Name":   "Jane Doe",
+		"Period": DigestDaily,
+		"Overdue": []DigestItem{
+			{Title: "File expense report", Due: "Mon Jan 6 17:00"},
+		},
+		"Due": []DigestItem{
+			{Title: "Buy milk", Due: "Wed Jan 8 18:00"},
+			{Title: "Call the dentist"},
+		},
+		"Completed": []DigestItem{
+			{Title: "Renew passport"},
+		},
+		"UnsubscribeURL": "https://todo.example.com/unsubscribe?token=sample",
+	},
+	"share_invitation": {
+		"InviterName": "Jane Doe",
+		"Email":       "john@example.com",
+		"Resource":    "todo",
+		"Title":       "Plan the offsite",
+		"Role":        "editor",
+		"URL":         "https://todo.example.com",
+	},
+	"workspace_invitation": {
+		"InviterName":   "Jane Doe",
+		"WorkspaceName": "Acme Marketing",
+		"Email":         "john@example.com",
+		"Role":          "member",
+		"URL":           "https://todo.example.com",
+	},
+	"comment_mention": {
+		"AuthorName": "Jane Doe",
+		"Title":      "Plan the offsite",
+		"Excerpt":    "@john@example.com can you book the venue by Friday?",
+		"URL":        "https://todo.example.com",
+	},
+}
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The filter language of saved filters. A query combines terms:
+//
+//	label:work           todos with the label; label:a,b for any of several, label:none
+//	project:<id>         todos in the project; project:none
+//	priority:high        also priority>=high, priority<normal, priority:low,normal
+//	due:today            a due window (today, tomorrow, this_week, next_week, overdue),
+//	                     none, any or a day; also due<=+7d, due>2025-01-31
+//	completed:false
+//	report "q3 plan"     words and quoted phrases search the title and description
+//
+// Terms side by side must all match. AND, OR and NOT (upper case), a leading
+// "-" and parentheses combine them; AND binds tighter than OR. Values only
+// ever become literal Mongo values, never operators or field names.
+
+const (
+	maxFilterLength = 1000
+	maxFilterTerms  = 50
+	maxFilterDepth  = 10
+)
+
+type filterTokenKind int
+
+const (
+	filterWord filterTokenKind = iota
+	filterLParen
+	filterRParen
+	filterAnd
+	filterOr
+	filterNot
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	pos  int // byte offset in the query, for error messages
+	// field and op are empty for search text; value has its quotes removed.
+	field, op, value string
+}
+
+var filterFields = map[string]bool{"label": true, "project": true, "priority": true, "due": true, "completed": true}
+
+// priorityOrder ranks priorities for comparisons; normal is stored as no priority.
+var priorityOrder = []string{models.PriorityLow, models.PriorityNormal, models.PriorityHigh, models.PriorityUrgent}
+
+var relativeDay = regexp.MustCompile(`^[+-]\d{1,4}d$`)
+
+func filterError(pos int, format string, args ...any) error {
+	return domain.Validation("filter: %s (at position %d)", fmt.Sprintf(format, args...), pos+1).WithCode("invalid_filter")
+}
+
+// compileFilter turns a filter query into a Mongo filter over todos. Relative
+// dates are resolved against now in loc; an empty query matches everything.
+func compileFilter(query string, now time.Time, loc *time.Location, firstDay time.Weekday) (bson.M, error) {
+	if len(query) > maxFilterLength {
+		return nil, domain.Validation("filter: a query has at most %d characters", maxFilterLength).WithCode("invalid_filter")
+	}
+	tokens, err := lexFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return bson.M{}, nil
+	}
+	c := &filterCompiler{tokens: tokens, now: now, loc: loc, firstDay: firstDay}
+	m, err := c.or(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := c.peek(); tok != nil {
+		return nil, filterError(tok.pos, "unexpected %s", describeToken(tok))
+	}
+	return m, nil
+}
+
+func lexFilter(query string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(query); {
+		ch := query[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '(':
+			tokens = append(tokens, filterToken{kind: filterLParen, pos: i})
+			i++
+		case ch == ')':
+			tokens = append(tokens, filterToken{kind: filterRParen, pos: i})
+			i++
+		case ch == '-' && i+1 < len(query) && !strings.ContainsRune(" \t\n\r)", rune(query[i+1])):
+			tokens = append(tokens, filterToken{kind: filterNot, pos: i})
+			i++
+		default:
+			tok, next, err := lexFilterWord(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+// lexFilterWord reads a word starting at start, unquoting "..." sections, and
+// splits it into field, operator and value when it starts with a field name.
+func lexFilterWord(query string, start int) (filterToken, int, error) {
+	var text strings.Builder
+	quoteAt := -1 // offset in text of the first quoted section
+	i := start
+	for i < len(query) && !strings.ContainsRune(" \t\n\r()", rune(query[i])) {
+		if query[i] != '"' {
+			text.WriteByte(query[i])
+			i++
+			continue
+		}
+		if quoteAt < 0 {
+			quoteAt = text.Len()
+		}
+		open := i
+		for i++; ; i++ {
+			if i >= len(query) {
+				return filterToken{}, 0, filterError(open, "unterminated quote")
+			}
+			if query[i] == '\\' && i+1 < len(query) {
+				i++
+			} else if query[i] == '"' {
+				i++
+				break
+			}
+			text.WriteByte(query[i])
+		}
+	}
+
+	tok := filterToken{kind: filterWord, pos: start, value: text.String()}
+	if quoteAt < 0 {
+		switch tok.value {
+		case "AND":
+			tok.kind = filterAnd
+			return tok, i, nil
+		case "OR":
+			tok.kind = filterOr
+			return tok, i, nil
+		case "NOT":
+			tok.kind = filterNot
+			return tok, i, nil
+		}
+	}
+	plain := tok.value
+	if quoteAt >= 0 {
+		plain = plain[:quoteAt]
+	}
+	at := strings.IndexAny(plain, ":<>")
+	if at <= 0 || strings.IndexFunc(plain[:at], func(r rune) bool { return r > unicode.MaxASCII || !unicode.IsLetter(r) }) >= 0 {
+		// Not a field: times like 10:30 or URLs stay search text.
+		return tok, i, nil
+	}
+	field := strings.ToLower(plain[:at])
+	if !filterFields[field] {
+		return filterToken{}, 0, filterError(start, "unknown field %q; use label, project, priority, due or completed, or quote the text", field)
+	}
+	op := tok.value[at : at+1]
+	if op != ":" && at+1 < len(tok.value) && tok.value[at+1] == '=' && at+1 < len(plain) {
+		op += "="
+	}
+	tok.field, tok.op, tok.value = field, op, tok.value[at+len(op):]
+	return tok, i, nil
+}
+
+func describeToken(tok *filterToken) string {
+	switch tok.kind {
+	case filterLParen:
+		return `"("`
+	case filterRParen:
+		return `")"`
+	case filterAnd:
+		return "AND"
+	case filterOr:
+		return "OR"
+	case filterNot:
+		return "NOT"
+	}
+	return strconv.Quote(tok.field + tok.op + tok.value)
+}
+
+type filterCompiler struct {
+	tokens   []filterToken
+	pos      int
+	terms    int
+	now      time.Time
+	loc      *time.Location
+	firstDay time.Weekday
+}
+
+func (c *filterCompiler) peek() *filterToken {
+	if c.pos < len(c.tokens) {
+		return &c.tokens[c.pos]
+	}
+	return nil
+}
+
+func (c *filterCompiler) accept(kind filterTokenKind) bool {
+	if tok := c.peek(); tok != nil && tok.kind == kind {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+func (c *filterCompiler) or(depth int) (bson.M, error) {
+	var parts bson.A
+	for {
+		m, err := c.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, m)
+		if !c.accept(filterOr) {
+			break
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0].(bson.M), nil
+	}
+	return bson.M{"$or": parts}, nil
+}
+
+func (c *filterCompiler) and(depth int) (bson.M, error) {
+	var parts bson.A
+	for {
+		m, err := c.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, m)
+		if c.accept(filterAnd) {
+			continue
+		}
+		// Adjacent terms are an implicit AND.
+		if tok := c.peek(); tok == nil || (tok.kind != filterWord && tok.kind != filterLParen && tok.kind != filterNot) {
+			break
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0].(bson.M), nil
+	}
+	return bson.M{"$and": parts}, nil
+}
+
+func (c *filterCompiler) unary(depth int) (bson.M, error) {
+	tok := c.peek()
+	if tok == nil {
+		end := 0
+		if len(c.tokens) > 0 {
+			end = c.tokens[len(c.tokens)-1].pos
+		}
+		return nil, filterError(end, "unexpected end of query")
+	}
+	if depth > maxFilterDepth {
+		return nil, filterError(tok.pos, "nested more than %d levels deep", maxFilterDepth)
+	}
+	c.pos++
+	switch tok.kind {
+	case filterNot:
+		m, err := c.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nor": bson.A{m}}, nil
+	case filterLParen:
+		m, err := c.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if !c.accept(filterRParen) {
+			return nil, filterError(tok.pos, "missing closing parenthesis")
+		}
+		return m, nil
+	case filterWord:
+		if c.terms++; c.terms > maxFilterTerms {
+			return nil, filterError(tok.pos, "a query has at most %d terms", maxFilterTerms)
+		}
+		return c.term(tok)
+	}
+	return nil, filterError(tok.pos, "unexpected %s", describeToken(tok))
+}
+
+func (c *filterCompiler) term(tok *filterToken) (bson.M, error) {
+	if tok.field == "" {
+		if tok.value == "" {
+			return nil, filterError(tok.pos, "empty search text")
+		}
+		re := primitive.Regex{Pattern: regexp.QuoteMeta(tok.value), Options: "i"}
+		return bson.M{"$or": bson.A{bson.M{"title": re}, bson.M{"description": re}}}, nil
+	}
+	if tok.value == "" {
+		return nil, filterError(tok.pos, "%s needs a value", tok.field)
+	}
+	if tok.op != ":" && tok.field != "due" && tok.field != "priority" {
+		return nil, filterError(tok.pos, "%s only supports %q", tok.field, ":")
+	}
+
+	switch tok.field {
+	case "label":
+		var values bson.A
+		for _, v := range strings.Split(tok.value, ",") {
+			if v = strings.ToLower(strings.TrimSpace(v)); v == "none" {
+				values = append(values, nil, bson.A{})
+			} else if v != "" {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			return nil, filterError(tok.pos, "label needs a value")
+		}
+		return inFilter("labels", values), nil
+	case "project":
+		var values bson.A
+		for _, v := range strings.Split(tok.value, ",") {
+			if v = strings.TrimSpace(v); v == "none" {
+				values = append(values, nil)
+				continue
+			}
+			id, err := primitive.ObjectIDFromHex(v)
+			if err != nil {
+				return nil, filterError(tok.pos, "project must be a project ID or none")
+			}
+			values = append(values, id)
+		}
+		return inFilter("project_id", values), nil
+	case "priority":
+		return c.priority(tok)
+	case "due":
+		return c.due(tok)
+	case "completed":
+		b, err := strconv.ParseBool(tok.value)
+		if err != nil {
+			return nil, filterError(tok.pos, "completed must be true or false")
+		}
+		return bson.M{"completed": b}, nil
+	}
+	return nil, filterError(tok.pos, "unknown field %q", tok.field)
+}
+
+func (c *filterCompiler) priority(tok *filterToken) (bson.M, error) {
+	rank := func(name string) (int, error) {
+		i := slices.Index(priorityOrder, strings.ToLower(strings.TrimSpace(name)))
+		if i < 0 {
+			return 0, filterError(tok.pos, "priority must be low, normal, high or urgent")
+		}
+		return i, nil
+	}
+	var ranks []int
+	if tok.op == ":" {
+		for _, v := range strings.Split(tok.value, ",") {
+			r, err := rank(v)
+			if err != nil {
+				return nil, err
+			}
+			ranks = append(ranks, r)
+		}
+	} else {
+		r, err := rank(tok.value)
+		if err != nil {
+			return nil, err
+		}
+		for i := range priorityOrder {
+			if (tok.op == "<" && i < r) || (tok.op == "<=" && i <= r) || (tok.op == ">" && i > r) || (tok.op == ">=" && i >= r) {
+				ranks = append(ranks, i)
+			}
+		}
+	}
+	values := bson.A{}
+	for _, r := range ranks {
+		if p := priorityOrder[r]; p == models.PriorityNormal {
+			// Create omits a normal priority, but an update stores it as "".
+			values = append(values, nil, "")
+		} else {
+			values = append(values, p)
+		}
+	}
+	return inFilter("priority", values), nil
+}
+
+func (c *filterCompiler) due(tok *filterToken) (bson.M, error) {
+	if tok.op == ":" {
+		switch v := strings.ToLower(tok.value); v {
+		case "none":
+			return bson.M{"due_at": nil}, nil
+		case "any":
+			return bson.M{"due_at": bson.M{"$ne": nil}}, nil
+		case DueOverdue:
+			// Like the list's due=overdue, only open todos are overdue.
+			return bson.M{"due_at": bson.M{"$lt": c.now}, "completed": false}, nil
+		case DueToday, DueTomorrow, DueThisWeek, DueNextWeek:
+			from, before, err := dueWindow(v, c.now, c.loc, c.firstDay)
+			if err != nil {
+				return nil, err
+			}
+			return bson.M{"due_at": bson.M{"$gte": *from, "$lt": *before}}, nil
+		}
+	}
+	day, err := c.day(tok.value)
+	if err != nil {
+		return nil, filterError(tok.pos, "due needs a window (today, tomorrow, this_week, next_week, overdue), none, any, a date like 2025-01-31 or a day offset like +7d")
+	}
+	next := day.AddDate(0, 0, 1)
+	switch tok.op {
+	case "<":
+		return bson.M{"due_at": bson.M{"$lt": day}}, nil
+	case "<=":
+		return bson.M{"due_at": bson.M{"$lt": next}}, nil
+	case ">":
+		return bson.M{"due_at": bson.M{"$gte": next}}, nil
+	case ">=":
+		return bson.M{"due_at": bson.M{"$gte": day}}, nil
+	}
+	return bson.M{"due_at": bson.M{"$gte": day, "$lt": next}}, nil
+}
+
+// day resolves today, tomorrow, yesterday, +Nd/-Nd or a YYYY-MM-DD date to local midnight.
+func (c *filterCompiler) day(v string) (time.Time, error) {
+	today := startOfDay(c.now, c.loc)
+	switch v = strings.ToLower(v); {
+	case v == "today":
+		return today, nil
+	case v == "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case v == "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case relativeDay.MatchString(v):
+		n, err := strconv.Atoi(strings.TrimSuffix(v, "d"))
+		if err != nil {
+			return time.Time{}, err
+		}
+		return today.AddDate(0, 0, n), nil
+	}
+	return time.ParseInLocation(time.DateOnly, v, c.loc)
+}
+
+// inFilter matches field against one value, or any of several.
+func inFilter(field string, values bson.A) bson.M {
+	if len(values) == 1 {
+		return bson.M{field: values[0]}
+	}
+	return bson.M{field: bson.M{"$in": values}}
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+)
+
+// iCalendar (RFC 5545) encoding and decoding of todos.
+
+const (
+	icalProdID     = "-//golang-todo//Todo API//EN"
+	icalUIDDomain  = "@golang-todo"
+	icalDate       = "20060102"
+	icalDateTime   = "20060102T150405"
+	icalDateTimeZ  = "20060102T150405Z"
+	icalLineOctets = 75
+)
+
+// icalWriter renders todos as a VCALENDAR. Each todo becomes a VTODO and, when
+// events is set, each todo with a due date also becomes a VEVENT so it shows up
+// in calendar apps that ignore tasks. Due dates at midnight in loc are written
+// as all-day dates.
+type icalWriter struct {
+	w      *bufio.Writer
+	loc    *time.Location
+	todos  bool
+	events bool
+}
+
+func newICalWriter(w io.Writer, loc *time.Location, name string, todos, events bool) *icalWriter {
+	x := &icalWriter{w: bufio.NewWriter(w), loc: loc, todos: todos, events: events}
+	x.prop("BEGIN", "VCALENDAR")
+	x.prop("VERSION", "2.0")
+	x.prop("PRODID", icalProdID)
+	x.prop("CALSCALE", "GREGORIAN")
+	x.prop("METHOD", "PUBLISH")
+	if name != "" {
+		x.prop("X-WR-CALNAME", icalEscape(name))
+		x.prop("X-WR-TIMEZONE", loc.String())
+	}
+	return x
+}
+
+func (x *icalWriter) Write(t *models.Todo) error {
+	if x.todos {
+		x.prop("BEGIN", "VTODO")
+		x.prop("UID", icalEscape(icalUID(t)))
+		x.common(t)
+		if t.DueAt != nil {
+			x.dateProp("DUE", *t.DueAt)
+		}
+		if t.Completed {
+			x.prop("STATUS", "COMPLETED")
+			if t.CompletedAt != nil {
+				x.prop("COMPLETED", t.CompletedAt.UTC().Format(icalDateTimeZ))
+			}
+		} else {
+			x.prop("STATUS", "NEEDS-ACTION")
+		}
+		x.prop("END", "VTODO")
+	}
+	if x.events && t.DueAt != nil {
+		x.prop("BEGIN", "VEVENT")
+		x.prop("UID", t.ID.Hex()+"-due"+icalUIDDomain)
+		x.common(t)
+		x.dateProp("DTSTART", *t.DueAt)
+		x.prop("TRANSP", "TRANSPARENT")
+		x.prop("END", "VEVENT")
+	}
+	// bufio.Writer errors are sticky; Flush in Close reports them.
+	return nil
+}
+
+// icalUID keeps the UID a CalDAV client assigned, so it recognises its own tasks.
+func icalUID(t *models.Todo) string {
+	if t.CalDAV != nil && t.CalDAV.UID != "" {
+		return t.CalDAV.UID
+	}
+	return t.ID.Hex() + icalUIDDomain
+}
+
+func (x *icalWriter) common(t *models.Todo) {
+	x.prop("DTSTAMP", t.UpdatedAt.UTC().Format(icalDateTimeZ))
+	x.prop("CREATED", t.CreatedAt.UTC().Format(icalDateTimeZ))
+	x.prop("LAST-MODIFIED", t.UpdatedAt.UTC().Format(icalDateTimeZ))
+	if t.Version > 0 {
+		x.prop("SEQUENCE", strconv.FormatInt(t.Version-1, 10))
+	}
+	x.prop("SUMMARY", icalEscape(t.Title))
+	if t.Description != "" {
+		x.prop("DESCRIPTION", icalEscape(t.Description))
+	}
+}
+
+func (x *icalWriter) dateProp(name string, t time.Time) {
+	if isLocalMidnight(t, x.loc) {
+		x.prop(name+";VALUE=DATE", t.In(x.loc).Format(icalDate))
+		return
+	}
+	x.prop(name, t.UTC().Format(icalDateTimeZ))
+}
+
+// prop writes one content line, folded at 75 octets without splitting runes.
+func (x *icalWriter) prop(name, value string) {
+	line := name + ":" + value
+	limit := icalLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		x.w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = icalLineOctets - 1 // continuation lines start with a space
+	}
+	x.w.WriteString(line + "\r\n")
+}
+
+func (x *icalWriter) Close() error {
+	x.prop("END", "VCALENDAR")
+	return x.w.Flush()
+}
+
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func icalUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' || s[i] == 'N' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// icalLine is an unfolded content line.
+type icalLine struct {
+	Row    int
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// parseICalImport reads the VTODO components of a VCALENDAR: SUMMARY becomes
+// the title, DESCRIPTION the description, DUE the due date (dates and floating
+// times use TZID, or loc without one) and STATUS:COMPLETED or a COMPLETED
+// timestamp marks it done. Events and nested components such as VALARM are
+// ignored.
+func parseICalImport(r io.Reader, loc *time.Location) ([]importRecord, error) {
+	lines, err := unfoldICal(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || lines[0].Name != "BEGIN" || !strings.EqualFold(lines[0].Value, "VCALENDAR") {
+		return nil, invalidImportFile("iCalendar file must start with BEGIN:VCALENDAR")
+	}
+
+	var records []importRecord
+	var current *importRecord
+	depth := 0 // components nested inside the current VTODO
+	for _, l := range lines {
+		switch {
+		case current == nil:
+			if l.Name == "BEGIN" && strings.EqualFold(l.Value, "VTODO") {
+				current = &importRecord{Row: l.Row}
+			}
+		case l.Name == "BEGIN":
+			depth++
+		case l.Name == "END" && depth > 0:
+			depth--
+		case l.Name == "END":
+			records = append(records, *current)
+			current = nil
+		case depth > 0:
+		case l.Name == "UID":
+			current.UID = icalUnescape(l.Value)
+		case l.Name == "SUMMARY":
+			current.Title = strings.TrimSpace(icalUnescape(l.Value))
+		case l.Name == "DESCRIPTION":
+			current.Description = strings.TrimSpace(icalUnescape(l.Value))
+		case l.Name == "STATUS":
+			current.Completed = current.Completed || strings.EqualFold(l.Value, "COMPLETED")
+		case l.Name == "COMPLETED":
+			current.Completed = true
+		case l.Name == "DUE" && current.Err == nil:
+			current.DueAt, current.Err = parseICalTime(l, loc)
+		}
+	}
+	if current != nil {
+		return nil, invalidImportFile("VTODO starting on line %d is not closed", current.Row)
+	}
+	return records, nil
+}
+
+func parseICalTime(l icalLine, loc *time.Location) (*time.Time, error) {
+	if tzid := l.Params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+	var t time.Time
+	var err error
+	switch {
+	case strings.EqualFold(l.Params["VALUE"], "DATE") || len(l.Value) == len(icalDate):
+		t, err = time.ParseInLocation(icalDate, l.Value, loc)
+	case strings.HasSuffix(l.Value, "Z"):
+		t, err = time.Parse(icalDateTimeZ, l.Value)
+	default:
+		t, err = time.ParseInLocation(icalDateTime, l.Value, loc)
+	}
+	if err != nil {
+		return nil, domain.Validation("DUE %q is not an iCalendar date or date-time", l.Value).WithCode("invalid_due_at")
+	}
+	return &t, nil
+}
+
+func unfoldICal(r io.Reader) ([]icalLine, error) {
+	var raw []icalLine
+	err := scanLines(r, func(row int, text string) {
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(raw) > 0 {
+			raw[len(raw)-1].Value += text[1:]
+			return
+		}
+		if text != "" {
+			raw = append(raw, icalLine{Row: row, Value: text})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	lines := raw[:0]
+	for _, l := range raw {
+		if parsed, ok := parseICalLine(l.Row, l.Value); ok {
+			lines = append(lines, parsed)
+		}
+	}
+	return lines, nil
+}
+
+// parseICalLine splits "NAME;PARAM=value;PARAM="quoted":VALUE".
+func parseICalLine(row int, text string) (icalLine, bool) {
+	colon := -1
+	quoted := false
+	for i := 0; i < len(text); i++ {
+		if text[i] == '"' {
+			quoted = !quoted
+		} else if text[i] == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return icalLine{}, false
+	}
+	parts := strings.Split(text[:colon], ";")
+	l := icalLine{Row: row, Name: strings.ToUpper(parts[0]), Params: map[string]string{}, Value: text[colon+1:]}
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			l.Params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return l, true
+}
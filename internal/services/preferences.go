@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PreferencesService struct {
+	userRepo database.UserRepository
+}
+
+func NewPreferencesService(userRepo database.UserRepository) *PreferencesService {
+	return &PreferencesService{userRepo: userRepo}
+}
+
+// PreferencesPatch carries the fields of a partial preferences update; nil means unchanged.
+type PreferencesPatch struct {
+	Timezone            *string
+	Locale              *string
+	WeekStart           *string
+	DefaultProjectID    *primitive.ObjectID
+	ClearDefaultProject bool
+	DefaultSort         *string
+	DailyDigest         *bool
+	WeeklyDigest        *bool
+	Mentions            *bool
+	AutoArchiveDays     *int
+}
+
+// Get returns the user's preferences with defaults applied for unset fields.
+func (s *PreferencesService) Get(ctx context.Context, userID primitive.ObjectID) (models.UserPreferences, error) {
+	user, err := s.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		return models.UserPreferences{}, err
+	}
+	return user.Preferences.WithDefaults(), nil
+}
+
+func (s *PreferencesService) Update(ctx context.Context, userID primitive.ObjectID, patch PreferencesPatch) (models.UserPreferences, error) {
+	update := bson.M{}
+	if patch.Timezone != nil {
+		if _, err := time.LoadLocation(*patch.Timezone); err != nil {
+			return models.UserPreferences{}, domain.Validation("unknown timezone %q", *patch.Timezone).WithCode("invalid_timezone")
+		}
+		update["preferences.timezone"] = *patch.Timezone
+	}
+	if patch.Locale != nil {
+		update["preferences.locale"] = *patch.Locale
+	}
+	if patch.WeekStart != nil {
+		update["preferences.week_start"] = *patch.WeekStart
+	}
+	if patch.ClearDefaultProject {
+		update["preferences.default_project_id"] = nil
+	} else if patch.DefaultProjectID != nil {
+		update["preferences.default_project_id"] = *patch.DefaultProjectID
+	}
+	if patch.DefaultSort != nil {
+		update["preferences.default_sort"] = *patch.DefaultSort
+	}
+	if patch.DailyDigest != nil {
+		update["preferences.email_notifications.daily_digest"] = *patch.DailyDigest
+	}
+	if patch.WeeklyDigest != nil {
+		update["preferences.email_notifications.weekly_digest"] = *patch.WeeklyDigest
+	}
+	if patch.Mentions != nil {
+		update["preferences.email_notifications.mentions"] = *patch.Mentions
+	}
+	if patch.AutoArchiveDays != nil {
+		update["preferences.auto_archive_days"] = *patch.AutoArchiveDays
+	}
+
+	if len(update) > 0 {
+		if err := s.userRepo.UpdatePreferences(ctx, userID, update); err != nil {
+			return models.UserPreferences{}, err
+		}
+	}
+	return s.Get(ctx, userID)
+}
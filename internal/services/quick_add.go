@@ -0,0 +1,574 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuickAddParse is what the quick-add parser understood. Title is the text
+// left over once dates, times, recurrence, #labels, !priority and @project
+// have been taken out.
+type QuickAddParse struct {
+	Title      string              `json:"title" example:"Pay rent"`
+	DueAt      *time.Time          `json:"due_at,omitempty" example:"2025-02-01T09:00:00+01:00"`
+	Recurrence *models.Recurrence  `json:"recurrence,omitempty"`
+	Labels     []string            `json:"labels" example:"finance"`
+	Priority   string              `json:"priority,omitempty" example:"high"`
+	ProjectID  *primitive.ObjectID `json:"project_id,omitempty" swaggertype:"string" example:"64f1c2a7e1b2c3d4e5f60718"`
+	// Warnings name the parts of the text that looked meaningful but were not used.
+	Warnings []string `json:"warnings,omitempty" example:"@home is not a project ID; projects are referenced as @<id>"`
+}
+
+// QuickAddResult carries the parse and, unless it was a dry run, the created todo.
+type QuickAddResult struct {
+	Parsed QuickAddParse `json:"parsed"`
+	Todo   *models.Todo  `json:"todo,omitempty"`
+}
+
+// QuickAdd parses text like "Pay rent every 1st of month at 9am #finance !high"
+// in the user's timezone and creates the todo through Create, or with dryRun
+// only returns the parse.
+func (s *TodoService) QuickAdd(ctx context.Context, userID primitive.ObjectID, text string, dryRun bool) (*QuickAddResult, error) {
+	prefs, err := s.prefs.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	parsed := parseQuickAdd(text, time.Now(), prefs.Location(), prefs.FirstWeekday())
+	if parsed.Title == "" {
+		return nil, domain.Validation("nothing is left for the title once dates and tags are taken out").WithCode("empty_title")
+	}
+	result := &QuickAddResult{Parsed: parsed}
+	if dryRun {
+		return result, nil
+	}
+	result.Todo, err = s.Create(ctx, userID, TodoInput{
+		Title:      parsed.Title,
+		DueAt:      parsed.DueAt,
+		ProjectID:  parsed.ProjectID,
+		Labels:     parsed.Labels,
+		Priority:   parsed.Priority,
+		Recurrence: parsed.Recurrence,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+var (
+	quickClock    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	quickOrdinal  = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)$`)
+	quickPriority = map[string]string{
+		"low": models.PriorityLow, "normal": models.PriorityNormal, "high": models.PriorityHigh, "urgent": models.PriorityUrgent,
+		"1": models.PriorityUrgent, "2": models.PriorityHigh, "3": models.PriorityNormal, "4": models.PriorityLow,
+		"!": models.PriorityHigh, "!!": models.PriorityUrgent,
+	}
+	quickMonths = map[string]time.Month{
+		"jan": time.January, "january": time.January, "feb": time.February, "february": time.February,
+		"mar": time.March, "march": time.March, "apr": time.April, "april": time.April, "may": time.May,
+		"jun": time.June, "june": time.June, "jul": time.July, "july": time.July, "aug": time.August,
+		"august": time.August, "sep": time.September, "sept": time.September, "september": time.September,
+		"oct": time.October, "october": time.October, "nov": time.November, "november": time.November,
+		"dec": time.December, "december": time.December,
+	}
+	quickWeekdays = map[string]time.Weekday{
+		"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+		"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+		"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "tues": time.Tuesday, "wed": time.Wednesday,
+		"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	}
+)
+
+// quickParser consumes the words of a quick-add text. Every matcher looks at
+// the words from position i and returns how many it used, 0 for no match.
+type quickParser struct {
+	words    []string // as typed
+	lower    []string // lowercased, trailing punctuation removed
+	used     []bool
+	now      time.Time // in the user's location
+	today    time.Time // local midnight
+	firstDay time.Weekday
+
+	date    *time.Time // local midnight of the due day
+	clock   *[2]int    // hour and minute
+	evening bool       // "tonight": 8pm unless a time is given
+	exact   *time.Time // "in 2 hours" sets the instant directly
+	rec     *models.Recurrence
+	out     QuickAddParse
+}
+
+func parseQuickAdd(text string, now time.Time, loc *time.Location, firstDay time.Weekday) QuickAddParse {
+	p := &quickParser{words: strings.Fields(text), now: now.In(loc), today: startOfDay(now, loc), firstDay: firstDay}
+	p.used = make([]bool, len(p.words))
+	for _, w := range p.words {
+		p.lower = append(p.lower, strings.TrimRight(strings.ToLower(w), ",.;"))
+	}
+	p.out.Labels = []string{}
+
+	for i := 0; i < len(p.words); i++ {
+		if n := p.match(i); n > 0 {
+			for j := i; j < i+n; j++ {
+				p.used[j] = true
+			}
+			i += n - 1
+		}
+	}
+
+	var title []string
+	for i, w := range p.words {
+		if !p.used[i] {
+			title = append(title, w)
+		}
+	}
+	p.out.Title = strings.Join(title, " ")
+	p.out.DueAt = p.due()
+	p.out.Recurrence = p.rec
+	return p.out
+}
+
+func (p *quickParser) match(i int) int {
+	if n := p.sigil(i); n > 0 {
+		return n
+	}
+	switch p.lower[i] {
+	case "every", "each":
+		return p.recurrence(i + 1)
+	case "daily":
+		return p.setRecurrence(&models.Recurrence{Frequency: models.RecurDaily}, 1)
+	case "weekly":
+		return p.setRecurrence(&models.Recurrence{Frequency: models.RecurWeekly}, 1)
+	case "monthly":
+		return p.setRecurrence(&models.Recurrence{Frequency: models.RecurMonthly}, 1)
+	case "yearly", "annually":
+		return p.setRecurrence(&models.Recurrence{Frequency: models.RecurYearly}, 1)
+	case "at":
+		if n := p.time(i+1, true); n > 0 {
+			return n + 1
+		}
+		return 0
+	case "on", "by", "due":
+		if n := p.dateOrTime(i+1, true); n > 0 {
+			return n + 1
+		}
+		return 0
+	case "in":
+		return p.relative(i + 1)
+	}
+	return p.dateOrTime(i, false)
+}
+
+// sigil handles #label, !priority and @project.
+func (p *quickParser) sigil(i int) int {
+	w := p.words[i]
+	if len(w) < 2 {
+		return 0
+	}
+	switch w[0] {
+	case '#':
+		label := strings.TrimRight(strings.ToLower(w[1:]), ",.;")
+		if label != "" && !slices.Contains(p.out.Labels, label) {
+			p.out.Labels = append(p.out.Labels, label)
+		}
+		return 1
+	case '!':
+		priority, ok := quickPriority[strings.ToLower(w[1:])]
+		if !ok {
+			return 0
+		}
+		p.out.Priority = priority
+		return 1
+	case '@':
+		if id, err := primitive.ObjectIDFromHex(w[1:]); err == nil {
+			p.out.ProjectID = &id
+		} else {
+			p.out.Warnings = append(p.out.Warnings, fmt.Sprintf("%s is not a project ID; projects are referenced as @<id>", w))
+		}
+		return 1
+	}
+	return 0
+}
+
+func (p *quickParser) dateOrTime(i int, afterPreposition bool) int {
+	if n := p.day(i, afterPreposition); n > 0 {
+		return n
+	}
+	return p.time(i, false)
+}
+
+// day matches a calendar day: today, tomorrow, a weekday, next week/month,
+// 2025-03-01, "march 5", "5 march", "the 5th".
+func (p *quickParser) day(i int, afterPreposition bool) int {
+	if i >= len(p.words) {
+		return 0
+	}
+	w := p.lower[i]
+	switch w {
+	case "today":
+		return p.setDate(p.today, 1)
+	case "tonight":
+		p.evening = true
+		return p.setDate(p.today, 1)
+	case "tomorrow", "tmrw", "tmr":
+		return p.setDate(p.today.AddDate(0, 0, 1), 1)
+	case "next", "this":
+		if i+1 >= len(p.words) {
+			return 0
+		}
+		next := p.lower[i+1]
+		week := startOfWeek(p.now, p.now.Location(), p.firstDay)
+		switch {
+		case w == "next" && next == "week":
+			return p.setDate(week.AddDate(0, 0, 7), 2)
+		case w == "next" && next == "month":
+			return p.setDate(time.Date(p.today.Year(), p.today.Month()+1, 1, 0, 0, 0, 0, p.today.Location()), 2)
+		}
+		if wd, ok := quickWeekdays[next]; ok {
+			d := p.onOrAfter(p.today, wd)
+			if w == "next" {
+				d = p.onOrAfter(week.AddDate(0, 0, 7), wd)
+			}
+			return p.setDate(d, 2)
+		}
+		return 0
+	case "the":
+		if i+1 < len(p.words) {
+			if n := p.dayOfMonth(i + 1); n > 0 {
+				return n + 1
+			}
+		}
+		return 0
+	}
+	if wd, ok := quickWeekdays[w]; ok && (afterPreposition || len(w) > 4) {
+		return p.setDate(p.onOrAfter(p.today, wd), 1)
+	}
+	if d, err := time.ParseInLocation(time.DateOnly, w, p.today.Location()); err == nil {
+		return p.setDate(d, 1)
+	}
+	if m, ok := quickMonths[w]; ok && i+1 < len(p.words) {
+		if day, ok := dayNumber(p.lower[i+1]); ok {
+			return p.monthDate(m, day, i+2, 2)
+		}
+	}
+	if day, ok := dayNumber(w); ok && i+1 < len(p.words) {
+		next := p.lower[i+1]
+		if next == "of" && i+2 < len(p.words) {
+			if m, ok := quickMonths[p.lower[i+2]]; ok {
+				return p.monthDate(m, day, i+3, 3)
+			}
+		}
+		if m, ok := quickMonths[next]; ok {
+			return p.monthDate(m, day, i+2, 2)
+		}
+	}
+	if afterPreposition {
+		return p.dayOfMonth(i)
+	}
+	return 0
+}
+
+// dayOfMonth matches an ordinal such as "15th": the next such day of a month.
+func (p *quickParser) dayOfMonth(i int) int {
+	m := quickOrdinal.FindStringSubmatch(p.lower[i])
+	if m == nil {
+		return 0
+	}
+	day, _ := strconv.Atoi(m[1])
+	if day < 1 || day > 31 {
+		return 0
+	}
+	first := time.Date(p.today.Year(), p.today.Month(), 1, 0, 0, 0, 0, p.today.Location())
+	d := monthDayIn(first, day)
+	if d.Before(p.today) {
+		d = monthDayIn(first.AddDate(0, 1, 0), day)
+	}
+	return p.setDate(d, 1)
+}
+
+// monthDate sets month/day, taking a year from word i if there is one and
+// otherwise the next such date from today. Days the month does not have
+// ("feb 30", "april 31") are not dates, so nothing is matched.
+func (p *quickParser) monthDate(m time.Month, day, i, n int) int {
+	loc := p.today.Location()
+	if i < len(p.words) {
+		if year, err := strconv.Atoi(p.lower[i]); err == nil && year >= 2000 && year <= 2100 {
+			d := time.Date(year, m, day, 0, 0, 0, 0, loc)
+			if d.Day() != day {
+				return 0
+			}
+			return p.setDate(d, n+1)
+		}
+	}
+	// Feb 29 waits for the next leap year; eight years always include one.
+	for year := p.today.Year(); year <= p.today.Year()+8; year++ {
+		d := time.Date(year, m, day, 0, 0, 0, 0, loc)
+		if d.Day() == day && !d.Before(p.today) {
+			return p.setDate(d, n)
+		}
+	}
+	return 0
+}
+
+// time matches 9am, 9:30pm, 17:00, "9 pm", noon and midnight; a bare hour only after "at".
+func (p *quickParser) time(i int, afterAt bool) int {
+	if i >= len(p.words) || p.clock != nil {
+		return 0
+	}
+	switch p.lower[i] {
+	case "noon":
+		p.clock = &[2]int{12, 0}
+		return 1
+	case "midnight":
+		p.clock = &[2]int{0, 0}
+		return 1
+	}
+	m := quickClock.FindStringSubmatch(p.lower[i])
+	if m == nil {
+		return 0
+	}
+	n, suffix := 1, m[3]
+	if suffix == "" && i+1 < len(p.words) && (p.lower[i+1] == "am" || p.lower[i+1] == "pm") {
+		n, suffix = 2, p.lower[i+1]
+	}
+	if suffix == "" && m[2] == "" && !afterAt {
+		return 0
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	switch {
+	case suffix != "" && (hour < 1 || hour > 12), hour > 23, minute > 59:
+		return 0
+	case suffix == "pm" && hour < 12:
+		hour += 12
+	case suffix == "am" && hour == 12:
+		hour = 0
+	}
+	p.clock = &[2]int{hour, minute}
+	return n
+}
+
+// relative matches "in 3 days", "in a week", "in 2 hours" (the words after "in").
+func (p *quickParser) relative(i int) int {
+	if i+1 >= len(p.words) || p.date != nil || p.exact != nil {
+		return 0
+	}
+	amount := 1
+	if w := p.lower[i]; w != "a" && w != "an" {
+		var err error
+		if amount, err = strconv.Atoi(w); err != nil || amount < 1 || amount > 1000 {
+			return 0
+		}
+	}
+	unit := strings.TrimSuffix(p.lower[i+1], "s")
+	switch unit {
+	case "minute", "min":
+		t := p.now.Add(time.Duration(amount) * time.Minute)
+		p.exact = &t
+	case "hour", "hr":
+		t := p.now.Add(time.Duration(amount) * time.Hour)
+		p.exact = &t
+	case "day":
+		return p.setDate(p.today.AddDate(0, 0, amount), 3)
+	case "week":
+		return p.setDate(p.today.AddDate(0, 0, 7*amount), 3)
+	case "month":
+		return p.setDate(p.today.AddDate(0, amount, 0), 3)
+	case "year":
+		return p.setDate(p.today.AddDate(amount, 0, 0), 3)
+	default:
+		return 0
+	}
+	return 3
+}
+
+// recurrence matches what follows "every": day, 2 weeks, other month,
+// weekday, monday and thursday, 1st of the month, month on the 15th, year.
+func (p *quickParser) recurrence(i int) int {
+	if i >= len(p.words) || p.rec != nil {
+		return 0
+	}
+	interval, n := 1, 0
+	if p.lower[i] == "other" {
+		interval, n = 2, 1
+	} else if v, err := strconv.Atoi(p.lower[i]); err == nil && v >= 1 && v <= 365 {
+		interval, n = v, 1
+	}
+	if i+n >= len(p.words) {
+		return 0
+	}
+	rec := &models.Recurrence{}
+	if interval > 1 {
+		rec.Interval = interval
+	}
+
+	switch unit := strings.TrimSuffix(p.lower[i+n], "s"); unit {
+	case "day":
+		rec.Frequency = models.RecurDaily
+		return p.setRecurrence(rec, n+2)
+	case "week":
+		rec.Frequency = models.RecurWeekly
+		return p.setRecurrence(rec, n+2)
+	case "year":
+		rec.Frequency = models.RecurYearly
+		return p.setRecurrence(rec, n+2)
+	case "month":
+		rec.Frequency = models.RecurMonthly
+		used := n + 2
+		// "every month on the 15th"
+		if j := i + n + 1; j+2 < len(p.words) && p.lower[j] == "on" && p.lower[j+1] == "the" {
+			if day, ok := ordinal(p.lower[j+2]); ok {
+				rec.MonthDay, used = day, used+3
+			}
+		}
+		return p.setRecurrence(rec, used)
+	case "weekday":
+		rec.Frequency, rec.Weekdays = models.RecurWeekly, []string{"mon", "tue", "wed", "thu", "fri"}
+		return p.setRecurrence(rec, n+2)
+	case "weekend":
+		rec.Frequency, rec.Weekdays = models.RecurWeekly, []string{"sat", "sun"}
+		return p.setRecurrence(rec, n+2)
+	}
+
+	// "every 1st of the month", "every 15th"
+	if day, ok := ordinal(p.lower[i+n]); ok {
+		rec.Frequency, rec.MonthDay = models.RecurMonthly, day
+		used := n + 2
+		j := i + n + 1
+		if j < len(p.words) && p.lower[j] == "of" {
+			j++
+			if j < len(p.words) && p.lower[j] == "the" {
+				j++
+			}
+			if j < len(p.words) && (p.lower[j] == "month" || p.lower[j] == "each") {
+				if p.lower[j] == "each" && j+1 < len(p.words) && p.lower[j+1] == "month" {
+					j++
+				}
+				used = j - i + 2
+			}
+		}
+		return p.setRecurrence(rec, used)
+	}
+
+	// "every monday", "every mon, wed and fri"
+	used := 1
+	for j := i + n; j < len(p.words); j++ {
+		if p.lower[j] == "and" && len(rec.Weekdays) > 0 {
+			continue
+		}
+		wd, ok := quickWeekdays[p.lower[j]]
+		if !ok {
+			break
+		}
+		if name := models.Weekdays[wd]; !slices.Contains(rec.Weekdays, name) {
+			rec.Weekdays = append(rec.Weekdays, name)
+		}
+		used = j - i + 2
+	}
+	if len(rec.Weekdays) == 0 {
+		return 0
+	}
+	rec.Frequency = models.RecurWeekly
+	return p.setRecurrence(rec, used)
+}
+
+func (p *quickParser) setRecurrence(rec *models.Recurrence, n int) int {
+	if p.rec != nil {
+		return 0
+	}
+	p.rec = rec
+	return n
+}
+
+func (p *quickParser) setDate(d time.Time, n int) int {
+	if p.date != nil || p.exact != nil {
+		return 0
+	}
+	p.date = &d
+	return n
+}
+
+// onOrAfter returns the first wd on or after day.
+func (p *quickParser) onOrAfter(day time.Time, wd time.Weekday) time.Time {
+	return day.AddDate(0, 0, (int(wd)-int(day.Weekday())+7)%7)
+}
+
+// due combines the parsed parts. A time without a day means its next
+// occurrence; a recurrence without a day starts at its first occurrence.
+func (p *quickParser) due() *time.Time {
+	if p.exact != nil {
+		return p.exact
+	}
+	if p.date == nil && p.clock == nil && p.rec == nil {
+		return nil
+	}
+	if p.clock == nil && p.evening {
+		p.clock = &[2]int{20, 0}
+	}
+	at := func(d time.Time) time.Time {
+		if p.clock == nil {
+			return d
+		}
+		return time.Date(d.Year(), d.Month(), d.Day(), p.clock[0], p.clock[1], 0, 0, d.Location())
+	}
+	if p.date != nil {
+		t := at(*p.date)
+		return &t
+	}
+	// Date-only occurrences count for the whole day; timed ones must still be ahead.
+	upcoming := func(t time.Time) bool {
+		if p.clock == nil {
+			return !t.Before(p.today)
+		}
+		return t.After(p.now)
+	}
+	t := at(p.today)
+	// Recurrences pinned to weekdays or a day of the month start on the first
+	// such day; the others start today.
+	if r := p.rec; r != nil && (len(r.Weekdays) > 0 || r.MonthDay > 0) {
+		t = r.Next(t.AddDate(0, 0, -1))
+	}
+	for i := 0; i < maxRecurrenceSkips && !upcoming(t); i++ {
+		if p.rec != nil {
+			t = p.rec.Next(t)
+		} else {
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+	return &t
+}
+
+// dayNumber parses "5" or "5th" as a day of the month.
+func dayNumber(w string) (int, bool) {
+	if day, ok := ordinal(w); ok {
+		return day, true
+	}
+	day, err := strconv.Atoi(w)
+	return day, err == nil && day >= 1 && day <= 31
+}
+
+// ordinal parses "1st" through "31st".
+func ordinal(w string) (int, bool) {
+	m := quickOrdinal.FindStringSubmatch(w)
+	if m == nil {
+		return 0, false
+	}
+	day, _ := strconv.Atoi(m[1])
+	return day, day >= 1 && day <= 31
+}
+
+// monthDayIn returns day of first's month, moved to the last day in shorter months.
+func monthDayIn(first time.Time, day int) time.Time {
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, last)-1)
+}
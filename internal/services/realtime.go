@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Realtime event sources (REALTIME_MODE).
+const (
+	RealtimeAuto         = "auto"
+	RealtimeChangeStream = "changestream"
+	RealtimeMemory       = "memory"
+)
+
+const (
+	// realtimeReplayLimit caps how many missed events are replayed on resume;
+	// beyond it the client is told to resync instead.
+	realtimeReplayLimit = 500
+	// realtimeBuffer is the per-subscriber backlog. A subscriber that falls
+	// further behind is disconnected and expected to resume with Last-Event-ID.
+	realtimeBuffer = 64
+	// realtimeRetry is the delay between change stream reconnect attempts.
+	realtimeRetry = 5 * time.Second
+	// realtimeLookupTimeout bounds the share lookup made for each event.
+	realtimeLookupTimeout = 5 * time.Second
+)
+
+// TodoEventPublisher receives todo events right after they are recorded.
+type TodoEventPublisher interface {
+	Publish(event *models.TodoEvent)
+}
+
+// RealtimeMessage is one item of a user's live stream. Resync tells the client
+// that missed events could not be replayed and it should refetch its todos.
+type RealtimeMessage struct {
+	Event  *models.TodoEvent
+	Resync bool
+}
+
+// RealtimeService fans recorded todo events out to connected clients. Events
+// of personal todos reach the owner's personal streams and the collaborators
+// who accepted a share of the todo; events of workspace todos reach only the
+// streams opened in that workspace (with X-Workspace-ID).
+//
+// With a replica set the events come from a change stream on todo_events, so
+// every server instance sees writes made by the others. On a standalone server
+// (or REALTIME_MODE=memory) TodoService publishes in-process instead, which
+// only reaches clients connected to the same instance.
+type RealtimeService struct {
+	events       database.TodoEventRepository
+	shares       database.ShareRepository
+	mode         string
+	changeStream atomic.Bool
+
+	mu sync.Mutex
+	// subs is keyed by user ID for personal streams and by workspace ID for
+	// workspace streams.
+	subs map[primitive.ObjectID]map[*realtimeSubscriber]struct{}
+}
+
+type realtimeSubscriber struct {
+	ch chan *models.TodoEvent
+}
+
+func NewRealtimeService(events database.TodoEventRepository, shares database.ShareRepository, mode string) *RealtimeService {
+	return &RealtimeService{
+		events: events,
+		shares: shares,
+		mode:   mode,
+		subs:   map[primitive.ObjectID]map[*realtimeSubscriber]struct{}{},
+	}
+}
+
+// Start picks the event source. When a change stream can be opened it is
+// consumed in the background until ctx is done; in auto mode a failure to open
+// one falls back to in-process publishing.
+func (s *RealtimeService) Start(ctx context.Context) error {
+	if s.mode == RealtimeMemory {
+		log.Println("realtime: using in-process events")
+		return nil
+	}
+	stream, err := s.events.Watch(ctx, nil)
+	if err != nil {
+		if s.mode == RealtimeChangeStream {
+			return fmt.Errorf("realtime: open change stream: %w", err)
+		}
+		log.Printf("realtime: change streams unavailable (%v); using in-process events", err)
+		return nil
+	}
+	s.changeStream.Store(true)
+	log.Println("realtime: using Mongo change streams")
+	go s.watch(ctx, stream)
+	return nil
+}
+
+// watch dispatches change stream events, reconnecting from the last resume
+// token after failures. If resuming keeps failing (e.g. the oplog rolled over)
+// it starts afresh; connected clients recover with Last-Event-ID.
+func (s *RealtimeService) watch(ctx context.Context, stream database.TodoEventStream) {
+	for {
+		var err error
+		for {
+			var event *models.TodoEvent
+			if event, err = stream.Next(ctx); err != nil {
+				break
+			}
+			s.dispatch(event)
+		}
+		token := stream.ResumeToken()
+		stream.Close(context.Background())
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("realtime: change stream interrupted: %v", err)
+
+		for attempt := 1; ; attempt++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(realtimeRetry):
+			}
+			if attempt > 3 {
+				token = nil
+			}
+			if stream, err = s.events.Watch(ctx, token); err == nil {
+				break
+			}
+			log.Printf("realtime: reopen change stream (attempt %d): %v", attempt, err)
+		}
+	}
+}
+
+// Publish delivers an event recorded by this instance. It is a no-op when the
+// change stream is the source, since the insert will arrive through it.
+func (s *RealtimeService) Publish(event *models.TodoEvent) {
+	if s.changeStream.Load() {
+		return
+	}
+	s.dispatch(event)
+}
+
+func (s *RealtimeService) dispatch(event *models.TodoEvent) {
+	var keys []primitive.ObjectID
+	if event.WorkspaceID != nil {
+		keys = []primitive.ObjectID{*event.WorkspaceID}
+	} else {
+		keys = []primitive.ObjectID{event.UserID}
+		if s.listening() {
+			keys = append(keys, s.collaborators(event)...)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		for sub := range s.subs[key] {
+			select {
+			case sub.ch <- event:
+			default:
+				s.removeLocked(key, sub)
+			}
+		}
+	}
+}
+
+// listening reports whether anyone is connected, so events nobody would
+// receive skip the share lookup.
+func (s *RealtimeService) listening() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subs) > 0
+}
+
+// collaborators returns the users other than the owner who accepted a share of
+// the event's todo, directly or through its project.
+func (s *RealtimeService) collaborators(event *models.TodoEvent) []primitive.ObjectID {
+	ctx, cancel := context.WithTimeout(context.Background(), realtimeLookupTimeout)
+	defer cancel()
+	shares, err := s.shares.ListAcceptedForTodo(ctx, event.UserID, event.TodoID, event.ProjectID)
+	if err != nil {
+		log.Printf("realtime: list collaborators of %s: %v", event.TodoID.Hex(), err)
+		return nil
+	}
+	seen := map[primitive.ObjectID]bool{event.UserID: true}
+	var ids []primitive.ObjectID
+	for _, sh := range shares {
+		if sh.InviteeID != nil && !seen[*sh.InviteeID] {
+			seen[*sh.InviteeID] = true
+			ids = append(ids, *sh.InviteeID)
+		}
+	}
+	return ids
+}
+
+func (s *RealtimeService) subscribe(key primitive.ObjectID) *realtimeSubscriber {
+	sub := &realtimeSubscriber{ch: make(chan *models.TodoEvent, realtimeBuffer)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subs[key] == nil {
+		s.subs[key] = map[*realtimeSubscriber]struct{}{}
+	}
+	s.subs[key][sub] = struct{}{}
+	return sub
+}
+
+func (s *RealtimeService) unsubscribe(key primitive.ObjectID, sub *realtimeSubscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(key, sub)
+}
+
+func (s *RealtimeService) removeLocked(key primitive.ObjectID, sub *realtimeSubscriber) {
+	if _, ok := s.subs[key][sub]; !ok {
+		return
+	}
+	delete(s.subs[key], sub)
+	if len(s.subs[key]) == 0 {
+		delete(s.subs, key)
+	}
+	close(sub.ch)
+}
+
+// Stream returns the live events of the ctx workspace's todos, or of the
+// user's todos and the todos shared with them, until ctx is done. With a
+// non-zero lastEventID, events recorded after it are replayed first (or a
+// single resync message if too many were missed). The channel closes when ctx
+// is done or the client falls too far behind.
+func (s *RealtimeService) Stream(ctx context.Context, userID, lastEventID primitive.ObjectID) (<-chan RealtimeMessage, error) {
+	key := userID
+	scope, inWorkspace := database.WorkspaceFrom(ctx)
+	if inWorkspace {
+		key = scope.ID
+	}
+	// Subscribe before reading the backlog so nothing recorded in between is lost.
+	sub := s.subscribe(key)
+
+	var replay []*models.TodoEvent
+	resync := false
+	if !lastEventID.IsZero() {
+		var shared []*models.Share
+		if !inWorkspace {
+			var err error
+			if shared, err = s.shares.ListAccepted(ctx, userID); err != nil {
+				s.unsubscribe(key, sub)
+				return nil, err
+			}
+		}
+		events, err := s.events.ListAfter(ctx, userID, lastEventID, shared, realtimeReplayLimit+1)
+		if err != nil {
+			s.unsubscribe(key, sub)
+			return nil, err
+		}
+		if len(events) > realtimeReplayLimit {
+			resync = true
+		} else {
+			replay = events
+		}
+	}
+
+	out := make(chan RealtimeMessage)
+	go func() {
+		defer close(out)
+		defer s.unsubscribe(key, sub)
+
+		send := func(m RealtimeMessage) bool {
+			select {
+			case out <- m:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if resync && !send(RealtimeMessage{Resync: true}) {
+			return
+		}
+		replayed := make(map[primitive.ObjectID]bool, len(replay))
+		for _, e := range replay {
+			replayed[e.ID] = true
+			if !send(RealtimeMessage{Event: e}) {
+				return
+			}
+		}
+		for {
+			select {
+			case e, ok := <-sub.ch:
+				if !ok {
+					return
+				}
+				if replayed[e.ID] {
+					continue
+				}
+				if !send(RealtimeMessage{Event: e}) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
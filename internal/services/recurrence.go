@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxRecurrenceSkips bounds how many missed occurrences repeat steps over.
+const maxRecurrenceSkips = 1000
+
+// repeat creates the next occurrence of a recurring todo that was just
+// completed. The copy is due at the first occurrence after its due date (or
+// today, without one) that is still in the future, so a todo completed late
+// does not come back overdue. Each todo spawns one occurrence: reopening and
+// completing it again does not create another. Failures are logged: the
+// completion itself has already been stored.
+func (s *TodoService) repeat(ctx context.Context, actorID primitive.ObjectID, done *models.Todo) {
+	rec := done.Recurrence
+	if rec == nil || done.NextOccurrenceID != nil {
+		return
+	}
+	prefs, err := s.prefs.Get(ctx, done.UserID)
+	if err != nil {
+		log.Printf("todos: repeat %s: %v", done.ID.Hex(), err)
+		return
+	}
+	now := time.Now()
+	loc := prefs.Location()
+	anchor := startOfDay(now, loc)
+	if done.DueAt != nil {
+		anchor = done.DueAt.In(loc)
+	}
+	if rec.Frequency == models.RecurMonthly && rec.MonthDay == 0 && done.DueAt != nil {
+		// Pin the day, or a todo due on the 31st would drift to the 28th after February.
+		pinned := *rec
+		pinned.MonthDay = anchor.Day()
+		rec = &pinned
+	}
+	next := rec.Next(anchor)
+	for i := 0; i < maxRecurrenceSkips && !next.After(now); i++ {
+		next = rec.Next(next)
+	}
+
+	created, err := s.Create(ctx, done.UserID, TodoInput{
+		Title:           done.Title,
+		Description:     done.Description,
+		DueAt:           &next,
+		ProjectID:       done.ProjectID,
+		AssigneeID:      done.AssigneeID,
+		Labels:          done.Labels,
+		Priority:        done.Priority,
+		Recurrence:      rec,
+		EstimateMinutes: done.EstimateMinutes,
+	})
+	if err != nil {
+		log.Printf("todos: repeat %s completed by %s: %v", done.ID.Hex(), actorID.Hex(), err)
+		return
+	}
+	if err := s.repo.SetNextOccurrence(ctx, done.ID, created.ID); err != nil {
+		log.Printf("todos: record next occurrence of %s: %v", done.ID.Hex(), err)
+		return
+	}
+	done.NextOccurrenceID = &created.ID
+}
+
+// normalizePriority lowercases p and maps normal to empty.
+func normalizePriority(p string) (string, error) {
+	switch p = strings.ToLower(strings.TrimSpace(p)); p {
+	case "", models.PriorityNormal:
+		return "", nil
+	case models.PriorityLow, models.PriorityHigh, models.PriorityUrgent:
+		return p, nil
+	}
+	return "", domain.Validation("priority must be low, normal, high or urgent").WithCode("invalid_priority")
+}
+
+func validateRecurrence(r *models.Recurrence) error {
+	if r == nil {
+		return nil
+	}
+	invalid := func(format string, args ...any) error {
+		return domain.Validation(format, args...).WithCode("invalid_recurrence")
+	}
+	switch r.Frequency {
+	case models.RecurDaily, models.RecurWeekly, models.RecurMonthly, models.RecurYearly:
+	default:
+		return invalid("frequency must be daily, weekly, monthly or yearly")
+	}
+	if r.Interval < 0 || r.Interval > 365 {
+		return invalid("interval must be between 1 and 365")
+	}
+	if len(r.Weekdays) > 0 && r.Frequency != models.RecurWeekly {
+		return invalid("weekdays only apply to weekly recurrences")
+	}
+	for _, d := range r.Weekdays {
+		if !slices.Contains(models.Weekdays, d) {
+			return invalid("weekdays must be sun, mon, tue, wed, thu, fri or sat")
+		}
+	}
+	if r.MonthDay != 0 && (r.Frequency != models.RecurMonthly || r.MonthDay < 1 || r.MonthDay > 31) {
+		return invalid("month_day must be 1-31 and only applies to monthly recurrences")
+	}
+	return nil
+}
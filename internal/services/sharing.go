@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SharingService manages invitations to collaborate on todos and projects.
+// Access checks on the todos themselves live in TodoService.
+type SharingService struct {
+	shares  database.ShareRepository
+	todos   *TodoService
+	users   database.UserRepository
+	email   *EmailService
+	baseURL string
+}
+
+func NewSharingService(shares database.ShareRepository, todos *TodoService, users database.UserRepository, email *EmailService, baseURL string) *SharingService {
+	return &SharingService{shares: shares, todos: todos, users: users, email: email, baseURL: baseURL}
+}
+
+// ShareInput is an invitation to send.
+type ShareInput struct {
+	Email string
+	Role  models.ShareRole
+}
+
+// ShareTodo invites someone to a todo. The caller must own it or be a co-owner.
+func (s *SharingService) ShareTodo(ctx context.Context, userID, todoID primitive.ObjectID, in ShareInput) (*models.Share, error) {
+	if err := checkPersonalScope(ctx); err != nil {
+		return nil, err
+	}
+	todo, err := s.todos.access(ctx, userID, todoID, models.ShareRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if todo.DeletedAt != nil {
+		return nil, errTodoNotFound()
+	}
+	return s.invite(ctx, userID, todo.UserID, models.ShareResourceTodo, todo.ID, todo.Title, in)
+}
+
+// ShareProject invites someone to every todo the caller files under projectID,
+// including ones added later.
+func (s *SharingService) ShareProject(ctx context.Context, userID, projectID primitive.ObjectID, in ShareInput) (*models.Share, error) {
+	if err := checkPersonalScope(ctx); err != nil {
+		return nil, err
+	}
+	return s.invite(ctx, userID, userID, models.ShareResourceProject, projectID, "", in)
+}
+
+func (s *SharingService) invite(ctx context.Context, inviterID, ownerID primitive.ObjectID, resource models.ShareResource, resourceID primitive.ObjectID, title string, in ShareInput) (*models.Share, error) {
+	email := normalizeEmail(in.Email)
+	inviter, err := s.users.FindUserByID(ctx, inviterID)
+	if err != nil {
+		return nil, err
+	}
+	owner := inviter
+	if ownerID != inviterID {
+		if owner, err = s.users.FindUserByID(ctx, ownerID); err != nil {
+			return nil, err
+		}
+	}
+	if email == normalizeEmail(owner.Email) {
+		return nil, domain.Validation("%s already owns this %s", email, resource).WithCode("cannot_share_with_owner")
+	}
+
+	_, err = s.shares.Find(ctx, ownerID, resource, resourceID, email)
+	if err == nil {
+		return nil, domain.Conflict("this %s is already shared with %s", resource, email).WithCode("share_exists")
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	share := &models.Share{
+		ID:         primitive.NewObjectID(),
+		OwnerID:    ownerID,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Email:      email,
+		Role:       in.Role,
+		Status:     models.ShareStatusPending,
+		InvitedBy:  inviterID,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.shares.Create(ctx, share); err != nil {
+		return nil, err
+	}
+
+	// The invitation is also listed under GET /shares, so a failed email is not fatal.
+	locale := ""
+	if invitee, err := s.users.FindUserByEmail(ctx, email); err == nil {
+		locale = invitee.Preferences.Locale
+	}
+	err = s.email.Send(email, "share_invitation", locale, map[string]any{
+		"InviterName": inviter.Name,
+		"Email":       email,
+		"Resource":    string(resource),
+		"Title":       title,
+		"Role":        string(in.Role),
+		"URL":         s.baseURL,
+	})
+	if err != nil {
+		log.Printf("sharing: invitation email for share %s: %v", share.ID.Hex(), err)
+	}
+	return share, nil
+}
+
+// ListTodoShares lists who a todo is shared with; any collaborator may see it.
+func (s *SharingService) ListTodoShares(ctx context.Context, userID, todoID primitive.ObjectID) ([]*models.Share, error) {
+	todo, err := s.todos.access(ctx, userID, todoID, models.ShareRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.shares.ListByResource(ctx, todo.UserID, models.ShareResourceTodo, todo.ID)
+}
+
+// ListProjectShares lists who the caller's project is shared with.
+func (s *SharingService) ListProjectShares(ctx context.Context, userID, projectID primitive.ObjectID) ([]*models.Share, error) {
+	return s.shares.ListByResource(ctx, userID, models.ShareResourceProject, projectID)
+}
+
+// Incoming lists the invitations and shares addressed to the user's email.
+func (s *SharingService) Incoming(ctx context.Context, userID primitive.ObjectID) ([]*models.Share, error) {
+	user, err := s.users.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.shares.ListByEmail(ctx, normalizeEmail(user.Email))
+}
+
+// Accept accepts an invitation addressed to the user's email. Accepting twice is a no-op.
+func (s *SharingService) Accept(ctx context.Context, userID, shareID primitive.ObjectID) (*models.Share, error) {
+	user, err := s.users.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	share, err := s.shares.GetByID(ctx, shareID)
+	if err != nil {
+		return nil, err
+	}
+	if share.Email != normalizeEmail(user.Email) {
+		return nil, errShareNotFound()
+	}
+	if share.Status == models.ShareStatusAccepted {
+		return share, nil
+	}
+	return s.shares.Accept(ctx, shareID, userID, time.Now())
+}
+
+// Revoke deletes a share. The owner and co-owners of the resource can revoke
+// it; the invitee can decline or leave.
+func (s *SharingService) Revoke(ctx context.Context, userID, shareID primitive.ObjectID) error {
+	share, err := s.shares.GetByID(ctx, shareID)
+	if err != nil {
+		return err
+	}
+	allowed, err := s.canRevoke(ctx, userID, share)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errShareNotFound()
+	}
+	return s.shares.Delete(ctx, shareID)
+}
+
+func (s *SharingService) canRevoke(ctx context.Context, userID primitive.ObjectID, share *models.Share) (bool, error) {
+	if share.OwnerID == userID || (share.InviteeID != nil && *share.InviteeID == userID) {
+		return true, nil
+	}
+	user, err := s.users.FindUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if share.Email == normalizeEmail(user.Email) {
+		return true, nil
+	}
+	if share.Resource != models.ShareResourceTodo {
+		return false, nil
+	}
+	_, err = s.todos.access(ctx, userID, share.ResourceID, models.ShareRoleOwner)
+	if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrForbidden) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SharedTodos lists the live todos other users have shared with the user,
+// directly or through a project.
+func (s *SharingService) SharedTodos(ctx context.Context, userID primitive.ObjectID) ([]*models.Todo, error) {
+	shares, err := s.shares.ListAccepted(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[primitive.ObjectID]bool{}
+	todos := []*models.Todo{}
+	add := func(t *models.Todo) {
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			todos = append(todos, t)
+		}
+	}
+	for _, sh := range shares {
+		switch sh.Resource {
+		case models.ShareResourceTodo:
+			t, err := s.todos.repo.GetByID(ctx, sh.OwnerID, sh.ResourceID)
+			if errors.Is(err, domain.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			add(t)
+		case models.ShareResourceProject:
+			projectID := sh.ResourceID
+			list, err := s.todos.repo.ListByUser(ctx, sh.OwnerID, database.TodoListOptions{ProjectID: &projectID})
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range list {
+				add(t)
+			}
+		}
+	}
+	return todos, s.todos.annotate(ctx, todos...)
+}
+
+// checkPersonalScope rejects sharing workspace todos: members already have access,
+// and shares must not leak them to other tenants.
+func checkPersonalScope(ctx context.Context) error {
+	if _, ok := database.WorkspaceFrom(ctx); ok {
+		return domain.Validation("workspace todos are shared by inviting members to the workspace").WithCode("workspace_sharing_unsupported")
+	}
+	return nil
+}
+
+// shareRole returns the best role shares grant on todo, or "" for none.
+func shareRole(shares []*models.Share, todo *models.Todo) models.ShareRole {
+	var best models.ShareRole
+	for _, sh := range shares {
+		if sh.OwnerID != todo.UserID || sh.Status != models.ShareStatusAccepted {
+			continue
+		}
+		covers := (sh.Resource == models.ShareResourceTodo && sh.ResourceID == todo.ID) ||
+			(sh.Resource == models.ShareResourceProject && todo.ProjectID != nil && sh.ResourceID == *todo.ProjectID)
+		if covers && sh.Role.Allows(best) {
+			best = sh.Role
+		}
+	}
+	return best
+}
+
+func errShareNotFound() error {
+	return domain.NotFound("share not found").WithCode("share_not_found")
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+)
+
+// StatsService computes productivity statistics over the user's todos.
+type StatsService struct {
+	todos database.TodoRepository
+	prefs *PreferencesService
+}
+
+func NewStatsService(todos database.TodoRepository, prefs *PreferencesService) *StatsService {
+	return &StatsService{todos: todos, prefs: prefs}
+}
+
+// Stats summarises the user's live todos. Days and weeks are local to the
+// user's timezone and weeks start on their preferred weekday.
+type Stats struct {
+	Timezone  string `json:"timezone" example:"Europe/Berlin"`
+	Days      int    `json:"days" example:"30"`
+	Total     int64  `json:"total" example:"42"`
+	Open      int64  `json:"open" example:"12"`
+	Completed int64  `json:"completed" example:"30"`
+	Overdue   int64  `json:"overdue" example:"3"`
+	// AvgCompletionSeconds is the mean time from created_at to completed_at.
+	AvgCompletionSeconds int64 `json:"avg_completion_seconds" example:"172800"`
+	// CurrentStreak counts consecutive days with a completion, ending today
+	// (or yesterday, while today has none yet).
+	CurrentStreak int `json:"current_streak" example:"4"`
+	LongestStreak int `json:"longest_streak" example:"11"`
+	// CompletedPerDay covers the last Days days, oldest first, zeros included.
+	CompletedPerDay []StatsPeriod `json:"completed_per_day"`
+	// CompletedPerWeek covers every week overlapping those days, counting whole weeks.
+	CompletedPerWeek []StatsPeriod  `json:"completed_per_week"`
+	ByProject        []StatsProject `json:"by_project"`
+	ByLabel          []StatsLabel   `json:"by_label"`
+}
+
+// StatsPeriod is the number of completions in the day or week starting on Start.
+type StatsPeriod struct {
+	Start string `json:"start" example:"2025-01-13"`
+	Count int64  `json:"count" example:"5"`
+}
+
+// StatsCounts breaks a group of todos down by state.
+type StatsCounts struct {
+	Total     int64 `json:"total" example:"8"`
+	Open      int64 `json:"open" example:"3"`
+	Completed int64 `json:"completed" example:"5"`
+	Overdue   int64 `json:"overdue" example:"1"`
+}
+
+// StatsProject is the breakdown for one project; ProjectID is null for todos without a project.
+type StatsProject struct {
+	ProjectID *primitive.ObjectID `json:"project_id" swaggertype:"string" example:"64f1c2a7e1b2c3d4e5f60718"`
+	StatsCounts
+}
+
+// StatsLabel is the breakdown for one label; Label is empty for todos without
+// labels. A todo counts under each of its labels.
+type StatsLabel struct {
+	Label string `json:"label" example:"urgent"`
+	StatsCounts
+}
+
+// Stats aggregates the user's todos, with daily and weekly completions over
+// the last days days (0 means 30).
+func (s *StatsService) Stats(ctx context.Context, userID primitive.ObjectID, days int) (*Stats, error) {
+	if days == 0 {
+		days = defaultStatsDays
+	}
+	if days < 1 || days > maxStatsDays {
+		return nil, domain.Validation("days must be between 1 and %d", maxStatsDays).WithCode("invalid_days")
+	}
+	prefs, err := s.prefs.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := prefs.Location()
+	now := time.Now()
+
+	agg, err := s.todos.Stats(ctx, userID, database.TodoStatsQuery{Timezone: loc.String(), Now: now})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{
+		Timezone:             loc.String(),
+		Days:                 days,
+		Total:                agg.Total,
+		Open:                 agg.Total - agg.Completed,
+		Completed:            agg.Completed,
+		Overdue:              agg.Overdue,
+		AvgCompletionSeconds: int64(agg.AvgCompletionMillis / 1000),
+		ByProject:            make([]StatsProject, 0, len(agg.ByProject)),
+		ByLabel:              make([]StatsLabel, 0, len(agg.ByLabel)),
+	}
+	perDay := make(map[string]int64, len(agg.CompletedByDay))
+	for _, d := range agg.CompletedByDay {
+		perDay[d.Day] = d.Count
+	}
+
+	// Calendar arithmetic runs on UTC midnights so DST changes cannot skip or repeat a day.
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	first := today.AddDate(0, 0, 1-days)
+	for d := first; !d.After(today); d = d.AddDate(0, 0, 1) {
+		stats.CompletedPerDay = append(stats.CompletedPerDay, StatsPeriod{Start: d.Format(time.DateOnly), Count: perDay[d.Format(time.DateOnly)]})
+	}
+	weekday := prefs.FirstWeekday()
+	week := first.AddDate(0, 0, -((int(first.Weekday()) - int(weekday) + 7) % 7))
+	for ; !week.After(today); week = week.AddDate(0, 0, 7) {
+		var count int64
+		for i := 0; i < 7; i++ {
+			count += perDay[week.AddDate(0, 0, i).Format(time.DateOnly)]
+		}
+		stats.CompletedPerWeek = append(stats.CompletedPerWeek, StatsPeriod{Start: week.Format(time.DateOnly), Count: count})
+	}
+	stats.CurrentStreak, stats.LongestStreak = streaks(agg.CompletedByDay, today)
+
+	for _, p := range agg.ByProject {
+		stats.ByProject = append(stats.ByProject, StatsProject{ProjectID: p.ProjectID, StatsCounts: statsCounts(p.TodoStatsCounts)})
+	}
+	for _, l := range agg.ByLabel {
+		label := ""
+		if l.Label != nil {
+			label = *l.Label
+		}
+		stats.ByLabel = append(stats.ByLabel, StatsLabel{Label: label, StatsCounts: statsCounts(l.TodoStatsCounts)})
+	}
+	return stats, nil
+}
+
+// streaks returns the current and longest runs of consecutive days in days,
+// which are YYYY-MM-DD in ascending order. today is a UTC midnight.
+func streaks(days []database.TodoStatsDay, today time.Time) (current, longest int) {
+	run := 0
+	var prev time.Time
+	for _, d := range days {
+		day, err := time.Parse(time.DateOnly, d.Day)
+		if err != nil || day.After(today) {
+			continue
+		}
+		if run > 0 && day.Equal(prev.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		prev = day
+		longest = max(longest, run)
+	}
+	if run > 0 && (prev.Equal(today) || prev.Equal(today.AddDate(0, 0, -1))) {
+		current = run
+	}
+	return current, longest
+}
+
+func statsCounts(c database.TodoStatsCounts) StatsCounts {
+	return StatsCounts{Total: c.Total, Open: c.Total - c.Completed, Completed: c.Completed, Overdue: c.Overdue}
+}
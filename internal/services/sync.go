@@ -0,0 +1,418 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// syncOverlap is subtracted from the session start when issuing the next
+	// token, so writes whose updated_at was stamped just before a pull but
+	// committed after it are still picked up. Clients apply changes by version,
+	// so seeing a todo twice is harmless.
+	syncOverlap = 5 * time.Second
+	// syncMaxAttempts bounds retries when a todo changes between read and write.
+	syncMaxAttempts = 3
+)
+
+// Sync operations and conflict strategies.
+const (
+	SyncOpCreate = "create"
+	SyncOpUpdate = "update"
+	SyncOpDelete = "delete"
+
+	SyncMerge = "merge" // field-level: only fields changed on both sides conflict
+	SyncLWW   = "lww"   // whole-record last writer wins
+)
+
+// Per-change outcomes reported by Push.
+const (
+	SyncApplied  = "applied"  // written as sent (or already in that state)
+	SyncMerged   = "merged"   // written after resolving conflicts, see Conflicts
+	SyncRejected = "rejected" // server state won; nothing written
+	SyncFailed   = "error"    // invalid change, see Error/Code
+)
+
+// SyncService implements delta sync for offline-first clients. Reads go to
+// the repository; writes go through TodoService so they are versioned and audited.
+type SyncService struct {
+	todoRepo database.TodoRepository
+	events   database.TodoEventRepository
+	todos    *TodoService
+}
+
+func NewSyncService(todoRepo database.TodoRepository, events database.TodoEventRepository, todos *TodoService) *SyncService {
+	return &SyncService{todoRepo: todoRepo, events: events, todos: todos}
+}
+
+// SyncChanges is one page of changes since a sync token.
+type SyncChanges struct {
+	Changed []*models.Todo  `json:"changed"`
+	Deleted []SyncTombstone `json:"deleted"`
+	// Token is passed as since on the next pull. When HasMore is set, pull
+	// again immediately to get the rest of this batch.
+	Token   string `json:"token" example:"eyJzIjoiMjAyNS0wMS0zMVQxNzowMDowMFoifQ"`
+	HasMore bool   `json:"has_more"`
+}
+
+// SyncTombstone reports a todo deleted since the token.
+type SyncTombstone struct {
+	ID        primitive.ObjectID `json:"id" swaggertype:"string" example:"66f0c2a1e4b0a1b2c3d4e5f6"`
+	DeletedAt time.Time          `json:"deleted_at"`
+	Version   int64              `json:"version" example:"4"`
+}
+
+// syncToken is the opaque position handed to clients. Since is the lower
+// bound of the session (zero on the first sync, which skips tombstones);
+// Start and After are set while a session is being paged.
+type syncToken struct {
+	Since time.Time            `json:"since,omitzero"`
+	Start time.Time            `json:"start,omitzero"`
+	After *database.TodoCursor `json:"after,omitempty"`
+}
+
+func (t syncToken) encode() string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSyncToken(s string) (syncToken, error) {
+	var t syncToken
+	if s == "" {
+		return t, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(b, &t) != nil {
+		return t, domain.Validation("invalid sync token").WithCode("invalid_sync_token")
+	}
+	return t, nil
+}
+
+// Pull returns up to limit todos changed since the token. An empty token
+// starts a full sync of live todos.
+func (s *SyncService) Pull(ctx context.Context, userID primitive.ObjectID, since string, limit int64) (*SyncChanges, error) {
+	tok, err := decodeSyncToken(since)
+	if err != nil {
+		return nil, err
+	}
+	start := tok.Start
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	todos, err := s.todoRepo.ListChanged(ctx, userID, database.TodoChangesQuery{
+		Since:          tok.Since,
+		After:          tok.After,
+		IncludeDeleted: !tok.Since.IsZero(),
+		Limit:          limit + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := &SyncChanges{Changed: []*models.Todo{}, Deleted: []SyncTombstone{}}
+	next := syncToken{Since: start.Add(-syncOverlap)}
+	if int64(len(todos)) > limit {
+		todos = todos[:limit]
+		last := todos[len(todos)-1]
+		next = syncToken{Since: tok.Since, Start: start, After: &database.TodoCursor{UpdatedAt: last.UpdatedAt, ID: last.ID}}
+		out.HasMore = true
+	}
+	for _, t := range todos {
+		if t.DeletedAt != nil {
+			out.Deleted = append(out.Deleted, SyncTombstone{ID: t.ID, DeletedAt: *t.DeletedAt, Version: t.Version})
+			continue
+		}
+		out.Changed = append(out.Changed, t)
+	}
+	out.Token = next.encode()
+	return out, nil
+}
+
+// SyncChange is one client-side change. Fields left nil were not touched.
+type SyncChange struct {
+	ID primitive.ObjectID
+	Op string
+	// BaseVersion is the server version the client edited (0 for creates).
+	BaseVersion int64
+	// ClientUpdatedAt is when the change was made on the device; it decides
+	// last-writer-wins conflicts and is capped at the server's clock.
+	ClientUpdatedAt time.Time
+
+	Title       *string
+	Description *string
+	Completed   *bool
+	DueAt       *time.Time
+	ProjectID   *primitive.ObjectID
+}
+
+// SyncResult reports what Push did with one change.
+type SyncResult struct {
+	ID        primitive.ObjectID `json:"id" swaggertype:"string" example:"66f0c2a1e4b0a1b2c3d4e5f6"`
+	Op        string             `json:"op" example:"update"`
+	Status    string             `json:"status" example:"merged"`
+	Todo      *models.Todo       `json:"todo,omitempty"` // server state afterwards; omitted once deleted
+	Conflicts []SyncConflict     `json:"conflicts,omitempty"`
+	Code      string             `json:"code,omitempty" example:"todo_not_found"`
+	Error     string             `json:"error,omitempty" example:"todo not found"`
+}
+
+// SyncConflict describes a field changed both on the server and by the client.
+type SyncConflict struct {
+	Field       string `json:"field" example:"title"`
+	ClientValue any    `json:"client_value"`
+	ServerValue any    `json:"server_value"`
+	Resolution  string `json:"resolution" example:"client_wins"` // client_wins or server_wins
+}
+
+// Push applies a batch of client changes in order. Failures are reported per
+// change; only unexpected errors (e.g. the database is down) fail the batch.
+func (s *SyncService) Push(ctx context.Context, userID primitive.ObjectID, strategy string, changes []SyncChange) ([]SyncResult, error) {
+	if strategy == "" {
+		strategy = SyncMerge
+	}
+	results := make([]SyncResult, 0, len(changes))
+	for _, ch := range changes {
+		if now := time.Now(); ch.ClientUpdatedAt.After(now) {
+			ch.ClientUpdatedAt = now
+		}
+		var res SyncResult
+		var err error
+		for attempt := 1; ; attempt++ {
+			res, err = s.apply(ctx, userID, strategy, ch)
+			if !errors.Is(err, domain.ErrPreconditionFailed) || attempt == syncMaxAttempts {
+				break
+			}
+		}
+		if err != nil {
+			if domain.Message(err) == "" {
+				return nil, err
+			}
+			res = SyncResult{Status: SyncFailed, Code: domain.Code(err), Error: domain.Message(err)}
+		}
+		res.ID, res.Op = ch.ID, ch.Op
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func (s *SyncService) apply(ctx context.Context, userID primitive.ObjectID, strategy string, ch SyncChange) (SyncResult, error) {
+	if ch.Op == SyncOpCreate {
+		return s.create(ctx, userID, ch)
+	}
+
+	current, err := s.todoRepo.GetIncludingDeleted(ctx, userID, ch.ID)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if ch.Op == SyncOpDelete {
+		return s.delete(ctx, userID, current, ch)
+	}
+	if current.DeletedAt != nil {
+		// Deletes win: an edit never resurrects a todo.
+		return SyncResult{Status: SyncRejected, Conflicts: []SyncConflict{{
+			Field: "deleted_at", ClientValue: nil, ServerValue: *current.DeletedAt, Resolution: "server_wins",
+		}}}, nil
+	}
+
+	apply, conflicts, err := s.resolve(ctx, userID, strategy, current, ch)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	status := SyncApplied
+	if len(conflicts) > 0 {
+		status = SyncMerged
+	}
+	if len(apply) == 0 {
+		if len(conflicts) > 0 {
+			status = SyncRejected
+		}
+		return SyncResult{Status: status, Todo: current, Conflicts: conflicts}, nil
+	}
+
+	update := ch.update(apply)
+	update.IfVersions = []int64{current.Version}
+	// The completion already happened offline; refusing it would leave the client stuck.
+	update.Force = true
+	todo, err := s.todos.Update(ctx, userID, ch.ID, update)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	return SyncResult{Status: status, Todo: todo, Conflicts: conflicts}, nil
+}
+
+func (s *SyncService) create(ctx context.Context, userID primitive.ObjectID, ch SyncChange) (SyncResult, error) {
+	if existing, err := s.todoRepo.GetIncludingDeleted(ctx, userID, ch.ID); err == nil {
+		// A retried create: report what the server has.
+		return SyncResult{Status: SyncApplied, Todo: existing}, nil
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return SyncResult{}, err
+	}
+	if ch.Title == nil || *ch.Title == "" {
+		return SyncResult{}, domain.Validation("title is required").WithCode("title_required")
+	}
+
+	in := TodoInput{ID: ch.ID, Title: *ch.Title, DueAt: ch.DueAt, ProjectID: ch.ProjectID}
+	if ch.Description != nil {
+		in.Description = *ch.Description
+	}
+	todo, err := s.todos.Create(ctx, userID, in)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if ch.Completed != nil && *ch.Completed {
+		if todo, err = s.todos.Update(ctx, userID, todo.ID, TodoUpdate{Completed: ch.Completed}); err != nil {
+			return SyncResult{}, err
+		}
+	}
+	return SyncResult{Status: SyncApplied, Todo: todo}, nil
+}
+
+func (s *SyncService) delete(ctx context.Context, userID primitive.ObjectID, current *models.Todo, ch SyncChange) (SyncResult, error) {
+	if current.DeletedAt != nil {
+		return SyncResult{Status: SyncApplied}, nil
+	}
+	status := SyncApplied
+	var conflicts []SyncConflict
+	if ch.BaseVersion != current.Version {
+		// The todo changed after the client saw it: newest action wins.
+		if !ch.ClientUpdatedAt.After(current.UpdatedAt) {
+			return SyncResult{Status: SyncRejected, Todo: current, Conflicts: []SyncConflict{{
+				Field: "deleted_at", ClientValue: ch.ClientUpdatedAt, ServerValue: nil, Resolution: "server_wins",
+			}}}, nil
+		}
+		status = SyncMerged
+		conflicts = []SyncConflict{{Field: "deleted_at", ClientValue: ch.ClientUpdatedAt, ServerValue: nil, Resolution: "client_wins"}}
+	}
+	if err := s.todos.Delete(ctx, userID, current.ID, []int64{current.Version}); err != nil {
+		return SyncResult{}, err
+	}
+	return SyncResult{Status: status, Conflicts: conflicts}, nil
+}
+
+// resolve decides which of the client's fields to write. Without concurrent
+// server changes all of them are. Otherwise a field conflicts when the server
+// also changed it (merge) or when the record changed at all (lww), and the
+// later of the client's and server's timestamps wins.
+func (s *SyncService) resolve(ctx context.Context, userID primitive.ObjectID, strategy string, current *models.Todo, ch SyncChange) (map[string]bool, []SyncConflict, error) {
+	client := ch.fields()
+	apply := map[string]bool{}
+	if ch.BaseVersion == current.Version {
+		for f := range client {
+			apply[f] = true
+		}
+		return apply, nil, nil
+	}
+
+	// serverChangedAt maps each field changed since BaseVersion to when it last changed.
+	serverChangedAt := map[string]time.Time{}
+	if strategy == SyncMerge {
+		events, err := s.events.ListByTodoSinceVersion(ctx, userID, current.ID, ch.BaseVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, e := range events {
+			for _, c := range e.Changes {
+				serverChangedAt[c.Field] = e.CreatedAt
+			}
+		}
+		// Without a trail for those versions (older events), assume any field may have changed.
+		if len(events) == 0 {
+			strategy = SyncLWW
+		}
+	}
+	if strategy == SyncLWW {
+		for f := range client {
+			serverChangedAt[f] = current.UpdatedAt
+		}
+	}
+
+	server := todoFields(current)
+	var conflicts []SyncConflict
+	for f, v := range client {
+		changedAt, changed := serverChangedAt[f]
+		if !changed || sameFieldValue(v, server[f]) {
+			apply[f] = true
+			continue
+		}
+		resolution := "server_wins"
+		if ch.ClientUpdatedAt.After(changedAt) {
+			resolution = "client_wins"
+			apply[f] = true
+		}
+		conflicts = append(conflicts, SyncConflict{Field: f, ClientValue: v, ServerValue: server[f], Resolution: resolution})
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Field < conflicts[j].Field })
+	return apply, conflicts, nil
+}
+
+// fields lists the fields the client set, keyed like FieldChange.Field.
+func (ch SyncChange) fields() map[string]any {
+	f := map[string]any{}
+	if ch.Title != nil {
+		f["title"] = *ch.Title
+	}
+	if ch.Description != nil {
+		f["description"] = *ch.Description
+	}
+	if ch.Completed != nil {
+		f["completed"] = *ch.Completed
+	}
+	if ch.DueAt != nil {
+		f["due_at"] = *ch.DueAt
+	}
+	if ch.ProjectID != nil {
+		f["project_id"] = *ch.ProjectID
+	}
+	return f
+}
+
+// update builds a TodoUpdate from the selected client fields.
+func (ch SyncChange) update(apply map[string]bool) TodoUpdate {
+	var u TodoUpdate
+	if apply["title"] {
+		u.Title = ch.Title
+	}
+	if apply["description"] {
+		u.Description = ch.Description
+	}
+	if apply["completed"] {
+		u.Completed = ch.Completed
+	}
+	if apply["due_at"] {
+		u.DueAt = ch.DueAt
+	}
+	if apply["project_id"] {
+		u.ProjectID = ch.ProjectID
+	}
+	return u
+}
+
+func todoFields(t *models.Todo) map[string]any {
+	return map[string]any{
+		"title":       t.Title,
+		"description": t.Description,
+		"completed":   t.Completed,
+		"due_at":      optionalTime(t.DueAt),
+		"project_id":  optionalObjectID(t.ProjectID),
+	}
+}
+
+// sameFieldValue compares field values, treating instants at Mongo's millisecond precision.
+func sameFieldValue(a, b any) bool {
+	at, aok := a.(time.Time)
+	bt, bok := b.(time.Time)
+	if aok && bok {
+		return at.Truncate(time.Millisecond).Equal(bt.Truncate(time.Millisecond))
+	}
+	return a == b
+}
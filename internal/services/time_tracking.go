@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxTimeEntry bounds a single entry; longer stretches are almost always a forgotten timer.
+	maxTimeEntry = 24 * time.Hour
+	// maxReportDays bounds the range of a time report.
+	maxReportDays = 366
+	// defaultReportDays is the range of a report without dates, ending today.
+	defaultReportDays = 30
+)
+
+// Time report groupings.
+const (
+	TimeByDay     = "day"
+	TimeByProject = "project"
+	TimeByLabel   = "label"
+)
+
+// TimeTrackingService records time spent on todos, by timer or by hand, and
+// reports on it. Access to the todos is checked through TodoService.
+type TimeTrackingService struct {
+	repo  database.TimeEntryRepository
+	todos *TodoService
+	prefs *PreferencesService
+}
+
+func NewTimeTrackingService(repo database.TimeEntryRepository, todos *TodoService, prefs *PreferencesService) *TimeTrackingService {
+	return &TimeTrackingService{repo: repo, todos: todos, prefs: prefs}
+}
+
+// TimeEntryInput is a time entry entered by hand: a start and either an end or
+// a number of minutes.
+type TimeEntryInput struct {
+	StartedAt time.Time
+	EndedAt   *time.Time
+	Minutes   int
+	Note      string
+}
+
+// TimeEntryPatch holds the fields of a partial entry update; nil means unchanged.
+type TimeEntryPatch struct {
+	StartedAt *time.Time
+	EndedAt   *time.Time
+	Note      *string
+}
+
+// TimeReport is tracked time summed per day, project or label.
+type TimeReport struct {
+	From         string          `json:"from" example:"2025-01-01"`
+	To           string          `json:"to" example:"2025-01-31"`
+	GroupBy      string          `json:"group_by" example:"day"`
+	TotalSeconds int64           `json:"total_seconds" example:"5400"`
+	Rows         []TimeReportRow `json:"rows"`
+}
+
+// TimeReportRow is one group. Key is a date (YYYY-MM-DD), a project ID or a
+// label; it is empty for time on todos without a project or label.
+type TimeReportRow struct {
+	Key     string `json:"key" example:"2025-01-15"`
+	Seconds int64  `json:"seconds" example:"3600"`
+	Entries int    `json:"entries" example:"2"`
+}
+
+// StartTimer starts the user's timer on a todo; collaborators need the editor
+// role. A user has one running timer at a time.
+func (s *TimeTrackingService) StartTimer(ctx context.Context, userID, todoID primitive.ObjectID, note string) (*models.TimeEntry, error) {
+	if _, err := s.editableTodo(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	entry := &models.TimeEntry{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		TodoID:    todoID,
+		StartedAt: now,
+		Note:      note,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Start(ctx, entry); err != nil {
+		return nil, err
+	}
+	return withSeconds(entry, now), nil
+}
+
+// StopTimer stops the user's running timer on a todo.
+func (s *TimeTrackingService) StopTimer(ctx context.Context, userID, todoID primitive.ObjectID) (*models.TimeEntry, error) {
+	now := time.Now()
+	entry, err := s.repo.Stop(ctx, userID, todoID, now)
+	if err != nil {
+		return nil, err
+	}
+	return withSeconds(entry, now), nil
+}
+
+// RunningTimer returns the user's running timer, in whichever tenant it was started.
+func (s *TimeTrackingService) RunningTimer(ctx context.Context, userID primitive.ObjectID) (*models.TimeEntry, error) {
+	entry, err := s.repo.Running(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return withSeconds(entry, time.Now()), nil
+}
+
+// ListEntries returns everyone's time entries on a todo the user can see.
+func (s *TimeTrackingService) ListEntries(ctx context.Context, userID, todoID primitive.ObjectID) ([]*models.TimeEntry, error) {
+	if _, err := s.todos.Get(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.ListByTodo(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, e := range entries {
+		withSeconds(e, now)
+	}
+	return entries, nil
+}
+
+// CreateEntry records time on a todo by hand; collaborators need the editor role.
+func (s *TimeTrackingService) CreateEntry(ctx context.Context, userID, todoID primitive.ObjectID, in TimeEntryInput) (*models.TimeEntry, error) {
+	if _, err := s.editableTodo(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	end := in.EndedAt
+	switch {
+	case end != nil && in.Minutes != 0:
+		return nil, domain.Validation("send either ended_at or minutes, not both").WithCode("invalid_time_entry")
+	case end == nil && in.Minutes <= 0:
+		return nil, domain.Validation("ended_at or minutes is required").WithCode("invalid_time_entry")
+	case end == nil:
+		t := in.StartedAt.Add(time.Duration(in.Minutes) * time.Minute)
+		end = &t
+	}
+	now := time.Now()
+	if err := checkTimeRange(in.StartedAt, *end, now); err != nil {
+		return nil, err
+	}
+	entry := &models.TimeEntry{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		TodoID:    todoID,
+		StartedAt: in.StartedAt,
+		EndedAt:   end,
+		Note:      in.Note,
+		Manual:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		return nil, err
+	}
+	return withSeconds(entry, now), nil
+}
+
+// UpdateEntry edits one of the user's own entries. A running timer's end can
+// only be set by stopping it.
+func (s *TimeTrackingService) UpdateEntry(ctx context.Context, userID, entryID primitive.ObjectID, p TimeEntryPatch) (*models.TimeEntry, error) {
+	entry, err := s.repo.Get(ctx, userID, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if p.EndedAt != nil && entry.EndedAt == nil {
+		return nil, domain.Validation("stop the timer to end this entry").WithCode("timer_running")
+	}
+
+	now := time.Now()
+	set := bson.M{"updated_at": now}
+	start, end := entry.StartedAt, entry.EndedAt
+	if p.StartedAt != nil {
+		start = *p.StartedAt
+		set["started_at"] = start
+	}
+	if p.EndedAt != nil {
+		end = p.EndedAt
+		set["ended_at"] = *end
+	}
+	if p.Note != nil {
+		set["note"] = *p.Note
+	}
+	if end != nil {
+		if err := checkTimeRange(start, *end, now); err != nil {
+			return nil, err
+		}
+	} else if start.After(now) {
+		return nil, domain.Validation("started_at cannot be in the future").WithCode("invalid_time_entry")
+	}
+
+	updated, err := s.repo.Update(ctx, userID, entryID, set)
+	if err != nil {
+		return nil, err
+	}
+	return withSeconds(updated, now), nil
+}
+
+// DeleteEntry removes one of the user's own entries, running or not.
+func (s *TimeTrackingService) DeleteEntry(ctx context.Context, userID, entryID primitive.ObjectID) error {
+	return s.repo.Delete(ctx, userID, entryID)
+}
+
+// Report sums the user's time between two dates (YYYY-MM-DD, inclusive, in the
+// user's timezone; empty means the last 30 days). Entries count on the day they
+// started, and under every label of their todo. Running timers count up to now.
+func (s *TimeTrackingService) Report(ctx context.Context, userID primitive.ObjectID, from, to, groupBy string) (*TimeReport, error) {
+	prefs, err := s.prefs.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := prefs.Location()
+	now := time.Now()
+	start, end, err := reportRange(from, to, now.In(loc))
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.ListByUser(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	todos := map[primitive.ObjectID]*models.Todo{}
+	if groupBy != TimeByDay && len(entries) > 0 {
+		ids := make([]primitive.ObjectID, 0, len(entries))
+		for _, e := range entries {
+			ids = append(ids, e.TodoID)
+		}
+		list, err := s.todos.repo.ListByIDsIncludingDeleted(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range list {
+			todos[t.ID] = t
+		}
+	}
+
+	report := &TimeReport{
+		From:    start.Format(time.DateOnly),
+		To:      end.AddDate(0, 0, -1).Format(time.DateOnly),
+		GroupBy: groupBy,
+		Rows:    []TimeReportRow{},
+	}
+	rows := map[string]*TimeReportRow{}
+	add := func(key string, seconds int64) {
+		row, ok := rows[key]
+		if !ok {
+			row = &TimeReportRow{Key: key}
+			rows[key] = row
+		}
+		row.Seconds += seconds
+		row.Entries++
+	}
+	for _, e := range entries {
+		seconds := int64(e.Duration(now) / time.Second)
+		report.TotalSeconds += seconds
+		todo := todos[e.TodoID]
+		switch groupBy {
+		case TimeByDay:
+			add(e.StartedAt.In(loc).Format(time.DateOnly), seconds)
+		case TimeByProject:
+			key := ""
+			if todo != nil && todo.ProjectID != nil {
+				key = todo.ProjectID.Hex()
+			}
+			add(key, seconds)
+		case TimeByLabel:
+			if todo == nil || len(todo.Labels) == 0 {
+				add("", seconds)
+			}
+			if todo != nil {
+				for _, l := range todo.Labels {
+					add(l, seconds)
+				}
+			}
+		}
+	}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if groupBy != TimeByDay && a.Seconds != b.Seconds {
+			return a.Seconds > b.Seconds
+		}
+		return a.Key < b.Key
+	})
+	return report, nil
+}
+
+// WriteTimeReportCSV writes a report as CSV with a header row.
+func WriteTimeReportCSV(w io.Writer, report *TimeReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{report.GroupBy, "seconds", "hours", "entries"})
+	for _, row := range report.Rows {
+		cw.Write([]string{
+			row.Key,
+			strconv.FormatInt(row.Seconds, 10),
+			strconv.FormatFloat(float64(row.Seconds)/3600, 'f', 2, 64),
+			strconv.Itoa(row.Entries),
+		})
+	}
+	cw.Write([]string{"total", strconv.FormatInt(report.TotalSeconds, 10), strconv.FormatFloat(float64(report.TotalSeconds)/3600, 'f', 2, 64), ""})
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *TimeTrackingService) editableTodo(ctx context.Context, userID, todoID primitive.ObjectID) (*models.Todo, error) {
+	todo, err := s.todos.access(ctx, userID, todoID, models.ShareRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if todo.DeletedAt != nil {
+		return nil, errTodoNotFound()
+	}
+	return todo, nil
+}
+
+// reportRange turns inclusive dates in today's location into [start, end).
+func reportRange(from, to string, today time.Time) (time.Time, time.Time, error) {
+	loc := today.Location()
+	end := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	if to != "" {
+		d, err := time.ParseInLocation(time.DateOnly, to, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, domain.Validation("to must be a date like 2025-01-31").WithCode("invalid_date")
+		}
+		end = d.AddDate(0, 0, 1)
+	}
+	start := end.AddDate(0, 0, -defaultReportDays)
+	if from != "" {
+		d, err := time.ParseInLocation(time.DateOnly, from, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, domain.Validation("from must be a date like 2025-01-01").WithCode("invalid_date")
+		}
+		start = d
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, domain.Validation("from must not be after to").WithCode("invalid_date_range")
+	}
+	if start.AddDate(0, 0, maxReportDays).Before(end) {
+		return time.Time{}, time.Time{}, domain.Validation("reports cover at most %d days", maxReportDays).WithCode("invalid_date_range")
+	}
+	return start, end, nil
+}
+
+func checkTimeRange(start, end, now time.Time) error {
+	switch {
+	case !end.After(start):
+		return domain.Validation("ended_at must be after started_at").WithCode("invalid_time_entry")
+	case end.After(now):
+		return domain.Validation("time entries cannot end in the future").WithCode("invalid_time_entry")
+	case end.Sub(start) > maxTimeEntry:
+		return domain.Validation("a time entry can span at most 24 hours").WithCode("invalid_time_entry")
+	}
+	return nil
+}
+
+func withSeconds(e *models.TimeEntry, now time.Time) *models.TimeEntry {
+	e.Seconds = int64(e.Duration(now) / time.Second)
+	return e
+}
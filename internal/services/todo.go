@@ -2,60 +2,732 @@ package services
 
 import (
 	"context"
+	"errors"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
 	"github.com/group14000/golang-todo/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	maxLabels      = 20
+	maxLabelLength = 40
+)
+
 type TodoService struct {
-	repo database.TodoRepository
+	repo       database.TodoRepository
+	events     database.TodoEventRepository
+	prefs      *PreferencesService
+	shares     database.ShareRepository
+	workspaces database.WorkspaceRepository
+	workflows  database.WorkflowRepository
+	publishers []TodoEventPublisher
 }
 
-func NewTodoService(repo database.TodoRepository) *TodoService {
-	return &TodoService{repo: repo}
+func NewTodoService(repo database.TodoRepository, events database.TodoEventRepository, prefs *PreferencesService, shares database.ShareRepository, workspaces database.WorkspaceRepository, workflows database.WorkflowRepository, publishers ...TodoEventPublisher) *TodoService {
+	return &TodoService{repo: repo, events: events, prefs: prefs, shares: shares, workspaces: workspaces, workflows: workflows, publishers: publishers}
 }
 
-func (s *TodoService) Create(ctx context.Context, userID primitive.ObjectID, title, description string) (*models.Todo, error) {
-	todo := &models.Todo{
-		ID:          primitive.NewObjectID(),
-		UserID:      userID,
-		Title:       title,
-		Description: description,
-		Completed:   false,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+// Subscribe adds a publisher after construction, for services that depend on
+// TodoService themselves.
+func (s *TodoService) Subscribe(p TodoEventPublisher) {
+	s.publishers = append(s.publishers, p)
+}
+
+// TodoInput holds the fields accepted when creating a todo.
+type TodoInput struct {
+	// ID lets offline clients choose the ID up front; zero generates one.
+	ID          primitive.ObjectID
+	Title       string
+	Description string
+	DueAt       *time.Time
+	ProjectID   *primitive.ObjectID
+	AssigneeID  *primitive.ObjectID
+	Labels      []string
+	Priority    string
+	Recurrence  *models.Recurrence
+	// EstimateMinutes of 0 or nil means no estimate.
+	EstimateMinutes *int
+	// BlockedBy is stored as given; only CreateMany uses it, to link todos created together.
+	BlockedBy []primitive.ObjectID
+	CalDAV    *models.TodoCalDAV
+}
+
+// TodoUpdate holds the fields of a partial update; nil means unchanged.
+type TodoUpdate struct {
+	Title       *string
+	Description *string
+	Completed   *bool
+	// Status moves the todo to a workflow column; Completed follows the column.
+	Status        *string
+	DueAt         *time.Time
+	ClearDueAt    bool // removes the due date; ignored when DueAt is set
+	ProjectID     *primitive.ObjectID
+	AssigneeID    *primitive.ObjectID
+	ClearAssignee bool // unassigns; ignored when AssigneeID is set
+	Labels        *[]string
+	// Priority "normal" or "" resets the priority.
+	Priority        *string
+	Recurrence      *models.Recurrence
+	ClearRecurrence bool // stops repeating; ignored when Recurrence is set
+	// EstimateMinutes of 0 removes the estimate.
+	EstimateMinutes *int
+	BlockedBy       *[]primitive.ObjectID
+	// Archived archives (true) or unarchives (false) the todo.
+	Archived *bool
+	// Force skips the workflow checks: open blockers, column transitions and WIP limits.
+	Force bool
+	// IfVersions makes the update conditional on the todo's current version (If-Match).
+	IfVersions []int64
+}
+
+// TodoListQuery holds the list filters as received from the client.
+type TodoListQuery struct {
+	Due       string // one of the Due* window names
+	ProjectID *primitive.ObjectID
+	Completed *bool
+	Label     string
+	Sort      string // empty uses the user's default sort
+	// Filter is an expression in the filter language (see compileFilter).
+	Filter string
+}
+
+// Create adds a todo to the ctx tenant; in a workspace it needs the member role.
+func (s *TodoService) Create(ctx context.Context, userID primitive.ObjectID, in TodoInput) (*models.Todo, error) {
+	todo, err := s.newTodo(ctx, userID, in)
+	if err != nil {
+		return nil, err
 	}
 	if err := s.repo.Create(ctx, todo); err != nil {
 		return nil, err
 	}
+	s.record(ctx, todo, todo.Version, userID, models.TodoEventCreated, nil)
 	return todo, nil
 }
 
-func (s *TodoService) List(ctx context.Context, userID primitive.ObjectID) ([]*models.Todo, error) {
-	return s.repo.ListByUser(ctx, userID)
+// CreateMany creates several todos at once: either all of them are stored or
+// none is. Inputs may block each other through BlockedBy and preset IDs.
+func (s *TodoService) CreateMany(ctx context.Context, userID primitive.ObjectID, inputs []TodoInput) ([]*models.Todo, error) {
+	todos := make([]*models.Todo, len(inputs))
+	for i, in := range inputs {
+		todo, err := s.newTodo(ctx, userID, in)
+		if err != nil {
+			return nil, err
+		}
+		todos[i] = todo
+	}
+	if err := s.repo.CreateMany(ctx, todos); err != nil {
+		return nil, err
+	}
+	for _, todo := range todos {
+		s.record(ctx, todo, todo.Version, userID, models.TodoEventCreated, nil)
+	}
+	return todos, nil
+}
+
+// newTodo checks the caller may create in and builds the todo to insert.
+func (s *TodoService) newTodo(ctx context.Context, userID primitive.ObjectID, in TodoInput) (*models.Todo, error) {
+	scope, inWorkspace := database.WorkspaceFrom(ctx)
+	if inWorkspace {
+		if err := checkRole(scope.Role.TodoRole(), models.ShareRoleEditor); err != nil {
+			return nil, err
+		}
+	}
+	projectID := in.ProjectID
+	// The default project is a personal preference; it does not apply to workspace todos.
+	if projectID == nil && !inWorkspace {
+		prefs, err := s.prefs.Get(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		projectID = prefs.DefaultProjectID
+	}
+	workflow, err := s.workflowFor(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := normalizeLabels(in.Labels)
+	if err != nil {
+		return nil, err
+	}
+	priority, err := normalizePriority(in.Priority)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRecurrence(in.Recurrence); err != nil {
+		return nil, err
+	}
+
+	id := in.ID
+	if id.IsZero() {
+		id = primitive.NewObjectID()
+	}
+	todo := &models.Todo{
+		ID:              id,
+		UserID:          userID,
+		Title:           in.Title,
+		Description:     in.Description,
+		Completed:       false,
+		Status:          workflow.StatusFor("", false),
+		DueAt:           in.DueAt,
+		ProjectID:       projectID,
+		AssigneeID:      in.AssigneeID,
+		Labels:          labels,
+		Priority:        priority,
+		Recurrence:      in.Recurrence,
+		EstimateMinutes: optionalMinutes(in.EstimateMinutes),
+		BlockedBy:       in.BlockedBy,
+		CalDAV:          in.CalDAV,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+		Version:         1,
+	}
+	if todo.AssigneeID != nil {
+		if err := s.checkAssignee(ctx, todo, *todo.AssigneeID); err != nil {
+			return nil, err
+		}
+	}
+	return todo, nil
+}
+
+func (s *TodoService) List(ctx context.Context, userID primitive.ObjectID, q TodoListQuery) ([]*models.Todo, error) {
+	opts, err := s.listOptions(ctx, userID, q)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := s.repo.ListByUser(ctx, userID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return todos, s.annotate(ctx, todos...)
+}
+
+// ListAssigned returns the todos assigned to userID that they can still see:
+// their own and other users' todos shared with them, or any in the ctx workspace.
+func (s *TodoService) ListAssigned(ctx context.Context, userID primitive.ObjectID, q TodoListQuery) ([]*models.Todo, error) {
+	opts, err := s.listOptions(ctx, userID, q)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := s.repo.ListAssigned(ctx, userID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := database.WorkspaceFrom(ctx); ok {
+		return todos, s.annotate(ctx, todos...)
+	}
+	shares, err := s.shares.ListAccepted(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	visible := todos[:0]
+	for _, t := range todos {
+		if t.UserID == userID || shareRole(shares, t) != "" {
+			visible = append(visible, t)
+		}
+	}
+	return visible, s.annotate(ctx, visible...)
+}
+
+func (s *TodoService) listOptions(ctx context.Context, userID primitive.ObjectID, q TodoListQuery) (database.TodoListOptions, error) {
+	prefs, err := s.prefs.Get(ctx, userID)
+	if err != nil {
+		return database.TodoListOptions{}, err
+	}
+
+	opts := database.TodoListOptions{ProjectID: q.ProjectID, Label: q.Label, Completed: q.Completed, Sort: q.Sort}
+	if opts.Sort == "" {
+		opts.Sort = prefs.DefaultSort
+	}
+	if q.Due != "" {
+		opts.DueFrom, opts.DueBefore, err = dueWindow(q.Due, time.Now(), prefs.Location(), prefs.FirstWeekday())
+		if err != nil {
+			return database.TodoListOptions{}, err
+		}
+		if q.Due == DueOverdue && opts.Completed == nil {
+			open := false
+			opts.Completed = &open
+		}
+	}
+	if q.Filter != "" {
+		if opts.Match, err = compileFilter(q.Filter, time.Now(), prefs.Location(), prefs.FirstWeekday()); err != nil {
+			return database.TodoListOptions{}, err
+		}
+	}
+	return opts, nil
 }
 
+// Get returns a todo the user owns or that is shared with them.
 func (s *TodoService) Get(ctx context.Context, userID, todoID primitive.ObjectID) (*models.Todo, error) {
-	return s.repo.GetByID(ctx, userID, todoID)
+	todo, err := s.access(ctx, userID, todoID, models.ShareRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	if todo.DeletedAt != nil {
+		return nil, errTodoNotFound()
+	}
+	return todo, s.annotate(ctx, todo)
 }
 
-func (s *TodoService) Update(ctx context.Context, userID, todoID primitive.ObjectID, title, description *string, completed *bool) error {
-	update := bson.M{"updated_at": time.Now()}
-	if title != nil {
-		update["title"] = *title
+// Update applies u and returns the updated todo. Collaborators need the editor role.
+// Status and completion are kept in step (see resolveStatus). Unless u.Force is
+// set, completing a todo whose blockers are still open is refused.
+func (s *TodoService) Update(ctx context.Context, userID, todoID primitive.ObjectID, u TodoUpdate) (*models.Todo, error) {
+	current, err := s.access(ctx, userID, todoID, models.ShareRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.resolveStatus(ctx, current, &u); err != nil {
+		return nil, err
 	}
-	if description != nil {
-		update["description"] = *description
+	if u.Completed != nil && *u.Completed == current.Completed {
+		// Completing a completed todo again must not move completed_at, which the stats rely on.
+		u.Completed = nil
+	}
+	if u.Archived != nil && *u.Archived == (current.ArchivedAt != nil) {
+		u.Archived = nil
+	}
+	if u.Completed != nil && !*u.Completed && current.ArchivedAt != nil && u.Archived == nil {
+		// Reopening an archived todo brings it back to the lists.
+		unarchive := false
+		u.Archived = &unarchive
+	}
+	if u.Labels != nil {
+		labels, err := normalizeLabels(*u.Labels)
+		if err != nil {
+			return nil, err
+		}
+		u.Labels = &labels
+	}
+	if u.Priority != nil {
+		priority, err := normalizePriority(*u.Priority)
+		if err != nil {
+			return nil, err
+		}
+		u.Priority = &priority
+	}
+	if err := validateRecurrence(u.Recurrence); err != nil {
+		return nil, err
 	}
-	if completed != nil {
-		update["completed"] = *completed
+	if u.Completed != nil && *u.Completed && !current.Completed && !u.Force {
+		open, err := s.openBlockers(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		if len(open) > 0 {
+			return nil, domain.Conflict("todo is blocked by %d open todo(s)", len(open)).WithCode("todo_blocked")
+		}
 	}
-	return s.repo.Update(ctx, userID, todoID, update)
+	now := time.Now()
+	if u.AssigneeID != nil {
+		// Check against the todo as it will be, since a project change can change who has access.
+		if err := s.checkAssignee(ctx, applyTodoUpdate(current, u, now), *u.AssigneeID); err != nil {
+			return nil, err
+		}
+	}
+
+	update := bson.M{"updated_at": now}
+	if u.Title != nil {
+		update["title"] = *u.Title
+	}
+	if u.Description != nil {
+		update["description"] = *u.Description
+	}
+	if u.Completed != nil {
+		update["completed"] = *u.Completed
+		if *u.Completed {
+			update["completed_at"] = now
+		} else {
+			update["completed_at"] = nil
+		}
+	}
+	if u.Status != nil {
+		update["status"] = *u.Status
+	}
+	if u.DueAt != nil {
+		update["due_at"] = *u.DueAt
+	} else if u.ClearDueAt {
+		update["due_at"] = nil
+	}
+	if u.ProjectID != nil {
+		update["project_id"] = *u.ProjectID
+	}
+	if u.AssigneeID != nil {
+		update["assignee_id"] = *u.AssigneeID
+	} else if u.ClearAssignee {
+		update["assignee_id"] = nil
+	}
+	if u.Labels != nil {
+		update["labels"] = *u.Labels
+	}
+	if u.Priority != nil {
+		update["priority"] = *u.Priority
+	}
+	if u.Recurrence != nil {
+		update["recurrence"] = u.Recurrence
+	} else if u.ClearRecurrence {
+		update["recurrence"] = nil
+	}
+	if u.EstimateMinutes != nil {
+		update["estimate_minutes"] = optionalInt(optionalMinutes(u.EstimateMinutes))
+	}
+	if u.BlockedBy != nil {
+		update["blocked_by"] = *u.BlockedBy
+	}
+	if u.Archived != nil {
+		update["archived_at"] = nil
+		if *u.Archived {
+			update["archived_at"] = now
+		}
+	}
+
+	before, err := s.repo.Update(ctx, current.UserID, todoID, update, u.IfVersions)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffTodo(before, u, now)
+	if len(changes) > 0 {
+		eventType := models.TodoEventUpdated
+		if u.Completed != nil && *u.Completed != before.Completed {
+			eventType = models.TodoEventReopened
+			if *u.Completed {
+				eventType = models.TodoEventCompleted
+			}
+		} else if u.Archived != nil && *u.Archived != (before.ArchivedAt != nil) {
+			eventType = models.TodoEventUnarchived
+			if *u.Archived {
+				eventType = models.TodoEventArchived
+			}
+		}
+		s.record(ctx, before, before.Version+1, userID, eventType, changes)
+	}
+	after := applyTodoUpdate(before, u, now)
+	s.annotateAfterWrite(ctx, after)
+	if u.Completed != nil && *u.Completed && !before.Completed {
+		s.repeat(ctx, userID, after)
+	}
+	return after, nil
 }
 
-func (s *TodoService) Delete(ctx context.Context, userID, todoID primitive.ObjectID) error {
-	return s.repo.Delete(ctx, userID, todoID)
+// Delete soft-deletes a todo. A non-nil ifVersions makes it conditional (If-Match).
+// Collaborators need the owner role.
+func (s *TodoService) Delete(ctx context.Context, userID, todoID primitive.ObjectID, ifVersions []int64) error {
+	current, err := s.access(ctx, userID, todoID, models.ShareRoleOwner)
+	if err != nil {
+		return err
+	}
+	before, err := s.repo.Delete(ctx, current.UserID, todoID, ifVersions)
+	if err != nil {
+		return err
+	}
+	s.record(ctx, before, before.Version+1, userID, models.TodoEventDeleted, nil)
+	return nil
+}
+
+// Restore brings back a soft-deleted todo.
+func (s *TodoService) Restore(ctx context.Context, userID, todoID primitive.ObjectID) (*models.Todo, error) {
+	current, err := s.access(ctx, userID, todoID, models.ShareRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.repo.Restore(ctx, current.UserID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, todo, todo.Version, userID, models.TodoEventRestored, nil)
+	s.annotateAfterWrite(ctx, todo)
+	return todo, nil
+}
+
+// access loads a todo, deleted or not, if userID owns it or holds a share
+// granting at least need. In a workspace the member's role decides instead.
+// Todos the user cannot see are reported as not found.
+func (s *TodoService) access(ctx context.Context, userID, todoID primitive.ObjectID, need models.ShareRole) (*models.Todo, error) {
+	todo, err := s.repo.FindByID(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if scope, ok := database.WorkspaceFrom(ctx); ok {
+		// FindByID only finds this workspace's todos, and membership was checked by the middleware.
+		if err := checkRole(scope.Role.TodoRole(), need); err != nil {
+			return nil, err
+		}
+		return todo, nil
+	}
+	if todo.UserID == userID {
+		return todo, nil
+	}
+	shares, err := s.shares.ListForTodo(ctx, userID, todo.UserID, todo.ID, todo.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	role := shareRole(shares, todo)
+	if role == "" {
+		return nil, errTodoNotFound()
+	}
+	if err := checkRole(role, need); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func checkRole(role, need models.ShareRole) error {
+	if !role.Allows(need) {
+		return domain.Forbidden("%s access does not allow this", role).WithCode("insufficient_permission")
+	}
+	return nil
+}
+
+// checkAssignee allows assigning a todo to its owner or to a collaborator with
+// access to it; workspace todos can be assigned to any member.
+func (s *TodoService) checkAssignee(ctx context.Context, todo *models.Todo, assigneeID primitive.ObjectID) error {
+	ok, err := s.canSee(ctx, todo, assigneeID)
+	if err != nil || ok {
+		return err
+	}
+	if _, inWorkspace := database.WorkspaceFrom(ctx); inWorkspace {
+		return domain.Validation("assignee must be a member of this workspace").WithCode("invalid_assignee")
+	}
+	return domain.Validation("assignee must be the owner or a collaborator on this todo").WithCode("invalid_assignee")
+}
+
+// canSee reports whether userID (not necessarily the caller) has any access to
+// todo: as a member of the ctx workspace, or as its owner or a collaborator.
+func (s *TodoService) canSee(ctx context.Context, todo *models.Todo, userID primitive.ObjectID) (bool, error) {
+	if scope, ok := database.WorkspaceFrom(ctx); ok {
+		_, err := s.workspaces.GetForMember(ctx, scope.ID, userID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if userID == todo.UserID {
+		return true, nil
+	}
+	shares, err := s.shares.ListForTodo(ctx, userID, todo.UserID, todo.ID, todo.ProjectID)
+	if err != nil {
+		return false, err
+	}
+	return shareRole(shares, todo) != "", nil
+}
+
+func errTodoNotFound() error {
+	return domain.NotFound("todo not found").WithCode("todo_not_found")
+}
+
+// record appends an audit event for the write that produced version. The todo write
+// has already succeeded, so a failure here is logged rather than surfaced to the client.
+func (s *TodoService) record(ctx context.Context, todo *models.Todo, version int64, actorID primitive.ObjectID, eventType models.TodoEventType, changes []models.FieldChange) {
+	event := newTodoEvent(todo, actorID, eventType)
+	event.Changes = changes
+	event.Version = version
+	s.emit(ctx, event)
+}
+
+// recordComment adds a commented event to the todo's activity. Comments do not
+// change the todo, so the event carries no version.
+func (s *TodoService) recordComment(ctx context.Context, todo *models.Todo, comment *models.Comment) {
+	event := newTodoEvent(todo, comment.AuthorID, models.TodoEventCommented)
+	event.CommentID = &comment.ID
+	s.emit(ctx, event)
+}
+
+func newTodoEvent(todo *models.Todo, actorID primitive.ObjectID, eventType models.TodoEventType) *models.TodoEvent {
+	return &models.TodoEvent{
+		ID:          primitive.NewObjectID(),
+		TodoID:      todo.ID,
+		UserID:      todo.UserID,
+		WorkspaceID: todo.WorkspaceID,
+		ProjectID:   todo.ProjectID,
+		ActorID:     actorID,
+		Type:        eventType,
+		Title:       todo.Title,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// emit stores event and hands it to the publishers.
+func (s *TodoService) emit(ctx context.Context, event *models.TodoEvent) {
+	if err := s.events.Create(ctx, event); err != nil {
+		log.Printf("todo events: record %s for %s: %v", event.Type, event.TodoID.Hex(), err)
+		return
+	}
+	for _, p := range s.publishers {
+		p.Publish(event)
+	}
+}
+
+// diffTodo lists the fields in u whose value differs from before.
+func diffTodo(before *models.Todo, u TodoUpdate, now time.Time) []models.FieldChange {
+	var changes []models.FieldChange
+	add := func(field string, from, to any) {
+		changes = append(changes, models.FieldChange{Field: field, Before: from, After: to})
+	}
+	if u.Title != nil && *u.Title != before.Title {
+		add("title", before.Title, *u.Title)
+	}
+	if u.Description != nil && *u.Description != before.Description {
+		add("description", before.Description, *u.Description)
+	}
+	if u.Completed != nil && *u.Completed != before.Completed {
+		add("completed", before.Completed, *u.Completed)
+	}
+	if u.Status != nil && *u.Status != before.Status {
+		add("status", before.Status, *u.Status)
+	}
+	if u.DueAt != nil && (before.DueAt == nil || !before.DueAt.Equal(*u.DueAt)) {
+		add("due_at", optionalTime(before.DueAt), *u.DueAt)
+	} else if u.DueAt == nil && u.ClearDueAt && before.DueAt != nil {
+		add("due_at", *before.DueAt, nil)
+	}
+	if u.ProjectID != nil && (before.ProjectID == nil || *before.ProjectID != *u.ProjectID) {
+		add("project_id", optionalObjectID(before.ProjectID), *u.ProjectID)
+	}
+	if u.AssigneeID != nil && (before.AssigneeID == nil || *before.AssigneeID != *u.AssigneeID) {
+		add("assignee_id", optionalObjectID(before.AssigneeID), *u.AssigneeID)
+	} else if u.AssigneeID == nil && u.ClearAssignee && before.AssigneeID != nil {
+		add("assignee_id", *before.AssigneeID, nil)
+	}
+	if u.Labels != nil && !slices.Equal(before.Labels, *u.Labels) {
+		add("labels", before.Labels, *u.Labels)
+	}
+	if u.Priority != nil && *u.Priority != before.Priority {
+		add("priority", before.Priority, *u.Priority)
+	}
+	if (u.Recurrence != nil || u.ClearRecurrence) && !reflect.DeepEqual(before.Recurrence, u.Recurrence) {
+		add("recurrence", before.Recurrence, u.Recurrence)
+	}
+	if u.EstimateMinutes != nil {
+		if from, to := optionalInt(before.EstimateMinutes), optionalInt(optionalMinutes(u.EstimateMinutes)); from != to {
+			add("estimate_minutes", from, to)
+		}
+	}
+	if u.BlockedBy != nil && !slices.Equal(before.BlockedBy, *u.BlockedBy) {
+		add("blocked_by", before.BlockedBy, *u.BlockedBy)
+	}
+	if u.Archived != nil && *u.Archived != (before.ArchivedAt != nil) {
+		var to any
+		if *u.Archived {
+			to = now
+		}
+		add("archived_at", optionalTime(before.ArchivedAt), to)
+	}
+	return changes
+}
+
+// applyTodoUpdate returns the state the repository wrote, derived from the prior
+// document, so callers get the updated todo without a second read.
+func applyTodoUpdate(before *models.Todo, u TodoUpdate, now time.Time) *models.Todo {
+	after := *before
+	if u.Title != nil {
+		after.Title = *u.Title
+	}
+	if u.Description != nil {
+		after.Description = *u.Description
+	}
+	if u.Completed != nil {
+		after.Completed = *u.Completed
+		after.CompletedAt = nil
+		if *u.Completed {
+			after.CompletedAt = &now
+		}
+	}
+	if u.Status != nil {
+		after.Status = *u.Status
+	}
+	if u.DueAt != nil {
+		after.DueAt = u.DueAt
+	} else if u.ClearDueAt {
+		after.DueAt = nil
+	}
+	if u.ProjectID != nil {
+		after.ProjectID = u.ProjectID
+	}
+	if u.AssigneeID != nil {
+		after.AssigneeID = u.AssigneeID
+	} else if u.ClearAssignee {
+		after.AssigneeID = nil
+	}
+	if u.Labels != nil {
+		after.Labels = *u.Labels
+	}
+	if u.Priority != nil {
+		after.Priority = *u.Priority
+	}
+	if u.Recurrence != nil || u.ClearRecurrence {
+		after.Recurrence = u.Recurrence
+	}
+	if u.EstimateMinutes != nil {
+		after.EstimateMinutes = optionalMinutes(u.EstimateMinutes)
+	}
+	if u.BlockedBy != nil {
+		after.BlockedBy = *u.BlockedBy
+	}
+	if u.Archived != nil {
+		after.ArchivedAt = nil
+		if *u.Archived {
+			after.ArchivedAt = &now
+		}
+	}
+	after.UpdatedAt = now
+	after.Version = before.Version + 1
+	return &after
+}
+
+// optionalTime and optionalObjectID unwrap pointers so nil is stored as null, not a typed nil.
+func optionalTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+func optionalObjectID(id *primitive.ObjectID) any {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+func optionalInt(n *int) any {
+	if n == nil {
+		return nil
+	}
+	return *n
+}
+
+// optionalMinutes maps an estimate of 0 to none.
+func optionalMinutes(n *int) *int {
+	if n == nil || *n == 0 {
+		return nil
+	}
+	return n
+}
+
+// normalizeLabels trims and lowercases labels and drops empty and repeated ones.
+func normalizeLabels(labels []string) ([]string, error) {
+	out := make([]string, 0, len(labels))
+	for _, l := range labels {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if l == "" || slices.Contains(out, l) {
+			continue
+		}
+		if utf8.RuneCountInString(l) > maxLabelLength {
+			return nil, domain.Validation("labels are limited to %d characters", maxLabelLength).WithCode("invalid_labels")
+		}
+		out = append(out, l)
+	}
+	if len(out) > maxLabels {
+		return nil, domain.Validation("a todo can have at most %d labels", maxLabels).WithCode("invalid_labels")
+	}
+	return out, nil
 }
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultArchiveLimit = 50
+	maxArchiveLimit     = 200
+	autoArchiveTick     = time.Hour
+)
+
+// ArchivePage is one page of archived todos.
+type ArchivePage struct {
+	Todos []*models.Todo `json:"todos"`
+	// NextCursor fetches the following page; it is empty on the last one.
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJhcmNoaXZlZF9hdCI6IjIwMjUtMDEtMzFUMTc6MDA6MDBaIn0"`
+}
+
+// SetArchived archives or unarchives a todo. Archived todos keep everything but
+// are left out of the default lists. Collaborators need the editor role.
+func (s *TodoService) SetArchived(ctx context.Context, userID, todoID primitive.ObjectID, archived bool, ifVersions []int64) (*models.Todo, error) {
+	return s.Update(ctx, userID, todoID, TodoUpdate{Archived: &archived, IfVersions: ifVersions})
+}
+
+// ListArchived returns up to limit archived todos (0 means 50), most recently
+// archived first, continuing after cursor.
+func (s *TodoService) ListArchived(ctx context.Context, userID primitive.ObjectID, cursor string, limit int64) (*ArchivePage, error) {
+	if limit == 0 {
+		limit = defaultArchiveLimit
+	}
+	if limit < 1 || limit > maxArchiveLimit {
+		return nil, domain.Validation("limit must be between 1 and %d", maxArchiveLimit).WithCode("invalid_limit")
+	}
+	var after *database.ArchiveCursor
+	if cursor != "" {
+		after = &database.ArchiveCursor{}
+		b, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || json.Unmarshal(b, after) != nil {
+			return nil, domain.Validation("invalid cursor").WithCode("invalid_cursor")
+		}
+	}
+
+	todos, err := s.repo.ListArchived(ctx, userID, database.TodoArchiveQuery{After: after, Limit: limit + 1})
+	if err != nil {
+		return nil, err
+	}
+	page := &ArchivePage{Todos: todos}
+	if int64(len(todos)) > limit {
+		page.Todos = todos[:limit]
+		last := page.Todos[limit-1]
+		b, _ := json.Marshal(database.ArchiveCursor{ArchivedAt: *last.ArchivedAt, ID: last.ID})
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return page, s.annotate(ctx, page.Todos...)
+}
+
+// AutoArchiveService archives each user's personal todos once they have been
+// completed for the number of days in the user's auto_archive_days preference,
+// for users who turned it on.
+type AutoArchiveService struct {
+	users database.UserRepository
+	todos *TodoService
+}
+
+func NewAutoArchiveService(users database.UserRepository, todos *TodoService) *AutoArchiveService {
+	return &AutoArchiveService{users: users, todos: todos}
+}
+
+// Start runs the job until ctx is cancelled.
+func (s *AutoArchiveService) Start(ctx context.Context) {
+	ticker := time.NewTicker(autoArchiveTick)
+	defer ticker.Stop()
+	for {
+		s.RunOnce(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce archives every todo that is due for it at now. Failures are logged per user.
+func (s *AutoArchiveService) RunOnce(ctx context.Context, now time.Time) {
+	users, err := s.users.ListAutoArchivers(ctx)
+	if err != nil {
+		log.Printf("auto-archive: list users: %v", err)
+		return
+	}
+	for _, u := range users {
+		if err := s.archive(ctx, u, now); err != nil {
+			log.Printf("auto-archive: archive for %s: %v", u.ID.Hex(), err)
+		}
+	}
+}
+
+func (s *AutoArchiveService) archive(ctx context.Context, u *models.User, now time.Time) error {
+	days := u.Preferences.AutoArchiveDays
+	if days <= 0 {
+		return nil
+	}
+	cutoff := now.AddDate(0, 0, -days)
+	done := true
+	todos, err := s.todos.repo.ListByUser(ctx, u.ID, database.TodoListOptions{Completed: &done, CompletedBefore: &cutoff})
+	if err != nil {
+		return err
+	}
+	for _, t := range todos {
+		// Conditional on the version read, so a todo changed meanwhile is left for the next run.
+		_, err := s.todos.SetArchived(ctx, u.ID, t.ID, true, []int64{t.Version})
+		if errors.Is(err, domain.ErrPreconditionFailed) || errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxBlockers caps how many todos a single todo can be blocked by.
+	maxBlockers = 50
+	// maxGraphNodes bounds the todos returned by Graph.
+	maxGraphNodes = 500
+)
+
+// TodoGraph is the dependency graph around a todo: the todos it transitively
+// waits on and the todos transitively waiting on it. Edges point from a blocker
+// to the todo it blocks, so the graph is a DAG.
+type TodoGraph struct {
+	Nodes []TodoGraphNode `json:"nodes"`
+	Edges []TodoGraphEdge `json:"edges"`
+	// Truncated is set when the graph was cut off at its size limit.
+	Truncated bool `json:"truncated"`
+}
+
+type TodoGraphNode struct {
+	ID        primitive.ObjectID `json:"id" swaggertype:"string" example:"64f1c2a7e1b2c3d4e5f60718"`
+	Title     string             `json:"title" example:"Buy paint"`
+	Completed bool               `json:"completed"`
+	Blocked   bool               `json:"blocked"`
+}
+
+type TodoGraphEdge struct {
+	From primitive.ObjectID `json:"from" swaggertype:"string" example:"64f1c2a7e1b2c3d4e5f60718"`
+	To   primitive.ObjectID `json:"to" swaggertype:"string" example:"64f1c2a7e1b2c3d4e5f60719"`
+}
+
+// AddBlocker records that todoID cannot be completed before blockerID. The
+// caller needs the editor role on todoID and access to blockerID, and both must
+// belong to the same owner or workspace. Adding an existing edge is a no-op.
+func (s *TodoService) AddBlocker(ctx context.Context, userID, todoID, blockerID primitive.ObjectID) (*models.Todo, error) {
+	todo, err := s.access(ctx, userID, todoID, models.ShareRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if todo.DeletedAt != nil {
+		return nil, errTodoNotFound()
+	}
+	if blockerID == todo.ID {
+		return nil, domain.Validation("a todo cannot block itself").WithCode("invalid_dependency")
+	}
+	blocker, err := s.Get(ctx, userID, blockerID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.NotFound("blocking todo not found").WithCode("blocker_not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, inWorkspace := database.WorkspaceFrom(ctx); !inWorkspace && blocker.UserID != todo.UserID {
+		return nil, domain.Validation("a todo can only be blocked by todos of the same owner").WithCode("invalid_dependency")
+	}
+
+	if slices.Contains(todo.BlockedBy, blockerID) {
+		return todo, s.annotate(ctx, todo)
+	}
+	if len(todo.BlockedBy) >= maxBlockers {
+		return nil, domain.Validation("a todo can be blocked by at most %d todos", maxBlockers).WithCode("too_many_dependencies")
+	}
+	cycle, err := s.dependsOn(ctx, blocker, todo.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, domain.Conflict("%q already waits on this todo; the dependency would create a cycle", blocker.Title).WithCode("dependency_cycle")
+	}
+
+	blockedBy := append(slices.Clone(todo.BlockedBy), blockerID)
+	// Conditional on the version read, so concurrent edits of the list are not lost.
+	return s.Update(ctx, userID, todoID, TodoUpdate{BlockedBy: &blockedBy, IfVersions: []int64{todo.Version}})
+}
+
+// RemoveBlocker deletes the edge from blockerID to todoID. It works even if the
+// blocker has since been deleted.
+func (s *TodoService) RemoveBlocker(ctx context.Context, userID, todoID, blockerID primitive.ObjectID) (*models.Todo, error) {
+	todo, err := s.access(ctx, userID, todoID, models.ShareRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if todo.DeletedAt != nil {
+		return nil, errTodoNotFound()
+	}
+	i := slices.Index(todo.BlockedBy, blockerID)
+	if i < 0 {
+		return nil, domain.NotFound("dependency not found").WithCode("dependency_not_found")
+	}
+	blockedBy := slices.Delete(slices.Clone(todo.BlockedBy), i, i+1)
+	return s.Update(ctx, userID, todoID, TodoUpdate{BlockedBy: &blockedBy, IfVersions: []int64{todo.Version}})
+}
+
+// Graph returns the dependency graph around a todo. Collaborators only see the
+// todos shared with them; edges through other todos are left out.
+func (s *TodoService) Graph(ctx context.Context, userID, todoID primitive.ObjectID) (*TodoGraph, error) {
+	root, err := s.Get(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &TodoGraph{Nodes: []TodoGraphNode{}, Edges: []TodoGraphEdge{}}
+	found := map[primitive.ObjectID]*models.Todo{root.ID: root}
+	order := []*models.Todo{root}
+	add := func(todos []*models.Todo) []primitive.ObjectID {
+		var added []primitive.ObjectID
+		for _, t := range todos {
+			if found[t.ID] != nil {
+				continue
+			}
+			if len(order) >= maxGraphNodes {
+				graph.Truncated = true
+				break
+			}
+			found[t.ID] = t
+			order = append(order, t)
+			added = append(added, t.ID)
+		}
+		return added
+	}
+
+	// Upstream: what the todo waits on.
+	for next := root.BlockedBy; len(next) > 0 && !graph.Truncated; {
+		todos, err := s.repo.ListByIDs(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		next = nil
+		for _, id := range add(todos) {
+			for _, b := range found[id].BlockedBy {
+				if found[b] == nil && !slices.Contains(next, b) {
+					next = append(next, b)
+				}
+			}
+		}
+	}
+	// Downstream: what waits on the todo.
+	for next := []primitive.ObjectID{root.ID}; len(next) > 0 && !graph.Truncated; {
+		todos, err := s.repo.ListDependents(ctx, root.UserID, next)
+		if err != nil {
+			return nil, err
+		}
+		next = add(todos)
+	}
+
+	visible, err := s.visibleTo(ctx, userID, order)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.annotate(ctx, visible...); err != nil {
+		return nil, err
+	}
+	shown := map[primitive.ObjectID]bool{}
+	for _, t := range visible {
+		shown[t.ID] = true
+		graph.Nodes = append(graph.Nodes, TodoGraphNode{ID: t.ID, Title: t.Title, Completed: t.Completed, Blocked: t.Blocked})
+	}
+	for _, t := range visible {
+		for _, b := range t.BlockedBy {
+			if shown[b] {
+				graph.Edges = append(graph.Edges, TodoGraphEdge{From: b, To: t.ID})
+			}
+		}
+	}
+	return graph, nil
+}
+
+// dependsOn reports whether todo transitively waits on target, following
+// BlockedBy through live todos.
+func (s *TodoService) dependsOn(ctx context.Context, todo *models.Todo, target primitive.ObjectID) (bool, error) {
+	seen := map[primitive.ObjectID]bool{todo.ID: true}
+	next := todo.BlockedBy
+	for len(next) > 0 {
+		if slices.Contains(next, target) {
+			return true, nil
+		}
+		for _, id := range next {
+			seen[id] = true
+		}
+		todos, err := s.repo.ListByIDs(ctx, next)
+		if err != nil {
+			return false, err
+		}
+		next = nil
+		for _, t := range todos {
+			for _, b := range t.BlockedBy {
+				if !seen[b] && !slices.Contains(next, b) {
+					next = append(next, b)
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// visibleTo keeps the todos userID may see. Everything in a workspace and
+// everything the user owns is visible; other todos need an accepted share.
+func (s *TodoService) visibleTo(ctx context.Context, userID primitive.ObjectID, todos []*models.Todo) ([]*models.Todo, error) {
+	if _, ok := database.WorkspaceFrom(ctx); ok {
+		return todos, nil
+	}
+	var shares []*models.Share
+	visible := make([]*models.Todo, 0, len(todos))
+	for _, t := range todos {
+		if t.UserID != userID {
+			if shares == nil {
+				var err error
+				if shares, err = s.shares.ListAccepted(ctx, userID); err != nil {
+					return nil, err
+				}
+			}
+			if shareRole(shares, t) == "" {
+				continue
+			}
+		}
+		visible = append(visible, t)
+	}
+	return visible, nil
+}
+
+// openBlockers returns the live, incomplete todos blocking todo. Deleted
+// blockers no longer block.
+func (s *TodoService) openBlockers(ctx context.Context, todo *models.Todo) ([]*models.Todo, error) {
+	if len(todo.BlockedBy) == 0 {
+		return nil, nil
+	}
+	blockers, err := s.repo.ListByIDs(ctx, todo.BlockedBy)
+	if err != nil {
+		return nil, err
+	}
+	open := blockers[:0]
+	for _, b := range blockers {
+		if !b.Completed {
+			open = append(open, b)
+		}
+	}
+	return open, nil
+}
+
+// markBlocked computes the Blocked flag of todos with one lookup of all their blockers.
+func (s *TodoService) markBlocked(ctx context.Context, todos ...*models.Todo) error {
+	var ids []primitive.ObjectID
+	seen := map[primitive.ObjectID]bool{}
+	for _, t := range todos {
+		for _, id := range t.BlockedBy {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	blockers, err := s.repo.ListByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	open := map[primitive.ObjectID]bool{}
+	for _, b := range blockers {
+		open[b.ID] = !b.Completed
+	}
+	for _, t := range todos {
+		t.Blocked = slices.ContainsFunc(t.BlockedBy, func(id primitive.ObjectID) bool { return open[id] })
+	}
+	return nil
+}
+
+// annotate fills the fields of todos that are computed on read: the status of
+// todos written before statuses existed, and Blocked.
+func (s *TodoService) annotate(ctx context.Context, todos ...*models.Todo) error {
+	for _, t := range todos {
+		t.Status = statusOf(t)
+	}
+	return s.markBlocked(ctx, todos...)
+}
+
+// annotateAfterWrite is annotate for a write that already succeeded; a failure
+// leaves Blocked unset rather than failing the request.
+func (s *TodoService) annotateAfterWrite(ctx context.Context, todo *models.Todo) {
+	if err := s.annotate(ctx, todo); err != nil {
+		log.Printf("todos: compute blocked for %s: %v", todo.ID.Hex(), err)
+	}
+}
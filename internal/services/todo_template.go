@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxSubtasks keeps a template's main todo within the blocker limit.
+const maxSubtasks = maxBlockers
+
+// templateVariable matches {{name}}, allowing spaces inside the braces.
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// TodoTemplateService manages todo templates and turns them into todos.
+type TodoTemplateService struct {
+	repo  database.TodoTemplateRepository
+	todos *TodoService
+	prefs *PreferencesService
+}
+
+func NewTodoTemplateService(repo database.TodoTemplateRepository, todos *TodoService, prefs *PreferencesService) *TodoTemplateService {
+	return &TodoTemplateService{repo: repo, todos: todos, prefs: prefs}
+}
+
+// TodoTemplateInput holds the fields of a template; updates replace all of them.
+type TodoTemplateInput struct {
+	Name          string
+	Title         string
+	Description   string
+	Labels        []string
+	DueOffsetDays *int
+	Subtasks      []models.TemplateSubtask
+}
+
+// InstantiateInput fills in a template. Date (YYYY-MM-DD, default today in the
+// user's timezone) is the {{date}} variable and the base of the due offsets.
+type InstantiateInput struct {
+	Variables map[string]string
+	Date      string
+	ProjectID *primitive.ObjectID
+}
+
+// TodoTemplateView is a template with the variables its text uses.
+type TodoTemplateView struct {
+	*models.TodoTemplate
+	// Variables lists the {{variables}} to fill in, besides the built-in date.
+	Variables []string `json:"variables" example:"name"`
+}
+
+func (s *TodoTemplateService) Create(ctx context.Context, userID primitive.ObjectID, in TodoTemplateInput) (*TodoTemplateView, error) {
+	if err := checkWorkspaceEditor(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateTemplate(in); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tmpl := &models.TodoTemplate{
+		ID:            primitive.NewObjectID(),
+		UserID:        userID,
+		Name:          in.Name,
+		Title:         in.Title,
+		Description:   in.Description,
+		Labels:        in.Labels,
+		DueOffsetDays: in.DueOffsetDays,
+		Subtasks:      in.Subtasks,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.repo.Create(ctx, tmpl); err != nil {
+		return nil, err
+	}
+	return viewTemplate(tmpl), nil
+}
+
+func (s *TodoTemplateService) List(ctx context.Context, userID primitive.ObjectID) ([]*TodoTemplateView, error) {
+	templates, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]*TodoTemplateView, len(templates))
+	for i, tmpl := range templates {
+		views[i] = viewTemplate(tmpl)
+	}
+	return views, nil
+}
+
+func (s *TodoTemplateService) Get(ctx context.Context, userID, templateID primitive.ObjectID) (*TodoTemplateView, error) {
+	tmpl, err := s.repo.Get(ctx, userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	return viewTemplate(tmpl), nil
+}
+
+// Update replaces every field of the template.
+func (s *TodoTemplateService) Update(ctx context.Context, userID, templateID primitive.ObjectID, in TodoTemplateInput) (*TodoTemplateView, error) {
+	if err := checkWorkspaceEditor(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateTemplate(in); err != nil {
+		return nil, err
+	}
+	tmpl, err := s.repo.Update(ctx, userID, templateID, bson.M{
+		"name":            in.Name,
+		"title":           in.Title,
+		"description":     in.Description,
+		"labels":          in.Labels,
+		"due_offset_days": in.DueOffsetDays,
+		"subtasks":        in.Subtasks,
+		"updated_at":      time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return viewTemplate(tmpl), nil
+}
+
+func (s *TodoTemplateService) Delete(ctx context.Context, userID, templateID primitive.ObjectID) error {
+	if err := checkWorkspaceEditor(ctx); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, userID, templateID)
+}
+
+// Instantiate creates the template's main todo and its subtasks in one
+// transaction and returns them, main todo first. Every subtask blocks the main
+// todo. A variable without a value fails with missing_variables.
+func (s *TodoTemplateService) Instantiate(ctx context.Context, userID, templateID primitive.ObjectID, in InstantiateInput) ([]*models.Todo, error) {
+	tmpl, err := s.repo.Get(ctx, userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := s.prefs.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := prefs.Location()
+	base := time.Now().In(loc)
+	base = time.Date(base.Year(), base.Month(), base.Day(), 0, 0, 0, 0, loc)
+	if in.Date != "" {
+		if base, err = time.ParseInLocation(time.DateOnly, in.Date, loc); err != nil {
+			return nil, domain.Validation("date must be a date like 2025-01-31").WithCode("invalid_date")
+		}
+	}
+
+	values := map[string]string{}
+	for k, v := range in.Variables {
+		values[k] = v
+	}
+	values["date"] = base.Format(time.DateOnly)
+	var missing []string
+	fill := func(text string) string {
+		return templateVariable.ReplaceAllStringFunc(text, func(m string) string {
+			name := templateVariable.FindStringSubmatch(m)[1]
+			v, ok := values[name]
+			if !ok && !slices.Contains(missing, name) {
+				missing = append(missing, name)
+			}
+			return v
+		})
+	}
+	fillAll := func(texts []string) []string {
+		out := make([]string, len(texts))
+		for i, t := range texts {
+			out[i] = fill(t)
+		}
+		return out
+	}
+	due := func(offset *int) *time.Time {
+		if offset == nil {
+			return nil
+		}
+		t := base.AddDate(0, 0, *offset)
+		return &t
+	}
+
+	inputs := make([]TodoInput, 0, len(tmpl.Subtasks)+1)
+	inputs = append(inputs, TodoInput{
+		ID:          primitive.NewObjectID(),
+		Title:       fill(tmpl.Title),
+		Description: fill(tmpl.Description),
+		Labels:      fillAll(tmpl.Labels),
+		DueAt:       due(tmpl.DueOffsetDays),
+		ProjectID:   in.ProjectID,
+	})
+	for _, st := range tmpl.Subtasks {
+		sub := TodoInput{
+			ID:          primitive.NewObjectID(),
+			Title:       fill(st.Title),
+			Description: fill(st.Description),
+			Labels:      fillAll(st.Labels),
+			DueAt:       due(st.DueOffsetDays),
+			ProjectID:   in.ProjectID,
+		}
+		inputs[0].BlockedBy = append(inputs[0].BlockedBy, sub.ID)
+		inputs = append(inputs, sub)
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, domain.Validation("missing values for variables: %s", strings.Join(missing, ", ")).WithCode("missing_variables")
+	}
+	for _, input := range inputs {
+		if strings.TrimSpace(input.Title) == "" {
+			return nil, domain.Validation("a filled-in title is empty").WithCode("invalid_template")
+		}
+	}
+
+	todos, err := s.todos.CreateMany(ctx, userID, inputs)
+	if err != nil {
+		return nil, err
+	}
+	for _, todo := range todos {
+		s.todos.annotateAfterWrite(ctx, todo)
+	}
+	return todos, nil
+}
+
+// checkWorkspaceEditor lets workspace viewers see shared setup such as
+// templates and saved filters, but not change it.
+func checkWorkspaceEditor(ctx context.Context) error {
+	if scope, ok := database.WorkspaceFrom(ctx); ok {
+		return checkRole(scope.Role.TodoRole(), models.ShareRoleEditor)
+	}
+	return nil
+}
+
+func validateTemplate(in TodoTemplateInput) error {
+	if len(in.Subtasks) > maxSubtasks {
+		return domain.Validation("a template has at most %d subtasks", maxSubtasks).WithCode("invalid_template")
+	}
+	for _, text := range templateTexts(in.Title, in.Description, in.Labels, in.Subtasks) {
+		// A lone "{{" is almost always a typo in a variable name, which would otherwise be copied verbatim.
+		if strings.Count(text, "{{") != len(templateVariable.FindAllString(text, -1)) {
+			return domain.Validation("malformed variable in %q; use {{name}}", text).WithCode("invalid_template")
+		}
+	}
+	return nil
+}
+
+func viewTemplate(tmpl *models.TodoTemplate) *TodoTemplateView {
+	vars := []string{}
+	for _, text := range templateTexts(tmpl.Title, tmpl.Description, tmpl.Labels, tmpl.Subtasks) {
+		for _, m := range templateVariable.FindAllStringSubmatch(text, -1) {
+			if name := m[1]; name != "date" && !slices.Contains(vars, name) {
+				vars = append(vars, name)
+			}
+		}
+	}
+	sort.Strings(vars)
+	if tmpl.Subtasks == nil {
+		tmpl.Subtasks = []models.TemplateSubtask{}
+	}
+	return &TodoTemplateView{TodoTemplate: tmpl, Variables: vars}
+}
+
+func templateTexts(title, description string, labels []string, subtasks []models.TemplateSubtask) []string {
+	texts := append([]string{title, description}, labels...)
+	for _, st := range subtasks {
+		texts = append(texts, st.Title, st.Description)
+		texts = append(texts, st.Labels...)
+	}
+	return texts
+}
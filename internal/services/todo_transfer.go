@@ -0,0 +1,671 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TransferFormat is a file format for todo import and export.
+type TransferFormat string
+
+const (
+	FormatCSV      TransferFormat = "csv"
+	FormatJSON     TransferFormat = "json"
+	FormatTodoTxt  TransferFormat = "todotxt"
+	FormatMarkdown TransferFormat = "markdown"
+	FormatICal     TransferFormat = "ical"
+)
+
+// MaxImportRows caps the todos accepted by one import.
+const MaxImportRows = 5000
+
+// Import row statuses.
+const (
+	ImportCreated     = "created"
+	ImportWouldCreate = "would_create" // dry run
+	ImportDuplicate   = "duplicate"
+	ImportError       = "error"
+)
+
+// ParseTransferFormat resolves a format name, falling back to the file
+// extension (.csv, .json, .txt, .md, .ics) when name is empty.
+func ParseTransferFormat(name, filename string) (TransferFormat, bool) {
+	if name == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".csv":
+			return FormatCSV, true
+		case ".json":
+			return FormatJSON, true
+		case ".txt":
+			return FormatTodoTxt, true
+		case ".md", ".markdown":
+			return FormatMarkdown, true
+		case ".ics":
+			return FormatICal, true
+		}
+		return "", false
+	}
+	switch f := TransferFormat(name); f {
+	case FormatCSV, FormatJSON, FormatTodoTxt, FormatMarkdown, FormatICal:
+		return f, true
+	}
+	return "", false
+}
+
+func (f TransferFormat) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSON:
+		return "application/json; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatICal:
+		return "text/calendar; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+func (f TransferFormat) FileName() string {
+	switch f {
+	case FormatCSV:
+		return "todos.csv"
+	case FormatJSON:
+		return "todos.json"
+	case FormatMarkdown:
+		return "todos.md"
+	case FormatICal:
+		return "todos.ics"
+	}
+	return "todo.txt"
+}
+
+// Export writes all of the user's live todos to w, oldest first, without
+// loading them into memory. Todo.txt and Markdown carry title, completion and
+// due date (as a date in the user's timezone when it falls on midnight);
+// Markdown also carries the description as indented lines.
+func (s *TodoService) Export(ctx context.Context, userID primitive.ObjectID, format TransferFormat, w io.Writer) error {
+	prefs, err := s.prefs.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	out, err := newTodoWriter(format, w, prefs.Location())
+	if err != nil {
+		return err
+	}
+	err = s.repo.EachByUser(ctx, userID, database.TodoListOptions{Sort: "created_at", Archived: database.IncludeArchived}, out.Write)
+	if err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// ImportOptions controls an import.
+type ImportOptions struct {
+	Format TransferFormat
+	// DryRun reports what would happen without creating anything.
+	DryRun bool
+	// AllowDuplicates creates rows that match an existing todo (or an earlier
+	// row) by title and due date instead of skipping them.
+	AllowDuplicates bool
+}
+
+// ImportReport summarises an import; Rows has one entry per parsed row.
+type ImportReport struct {
+	Format     TransferFormat `json:"format" example:"csv"`
+	DryRun     bool           `json:"dry_run"`
+	Total      int            `json:"total" example:"3"`
+	Created    int            `json:"created" example:"1"`
+	Duplicates int            `json:"duplicates" example:"1"`
+	Failed     int            `json:"failed" example:"1"`
+	Rows       []ImportRow    `json:"rows"`
+}
+
+// ImportRow is the outcome for one row. Row is the line number for CSV,
+// Todo.txt and Markdown, and the 1-based array index for JSON.
+type ImportRow struct {
+	Row         int                 `json:"row" example:"2"`
+	Status      string              `json:"status" example:"created" enums:"created,would_create,duplicate,error"`
+	Title       string              `json:"title,omitempty" example:"Buy milk"`
+	TodoID      *primitive.ObjectID `json:"todo_id,omitempty" swaggertype:"string"`
+	DuplicateOf *primitive.ObjectID `json:"duplicate_of,omitempty" swaggertype:"string"`
+	Code        string              `json:"code,omitempty" example:"invalid_due_at"`
+	Error       string              `json:"error,omitempty"`
+}
+
+// importRecord is one parsed row; Err marks a row that could not be parsed.
+type importRecord struct {
+	Row         int
+	Title       string
+	Description string
+	Completed   bool
+	DueAt       *time.Time
+	ProjectID   *primitive.ObjectID
+	UID         string // iCalendar only
+	Err         error
+}
+
+// Import parses r and creates a todo per row through Create, so imported todos
+// get fresh IDs, timestamps, events and the default project. Rows with errors
+// are reported and skipped; the rest of the file is still imported.
+func (s *TodoService) Import(ctx context.Context, userID primitive.ObjectID, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	prefs, err := s.prefs.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	records, err := parseImport(opts.Format, r, prefs.Location())
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > MaxImportRows {
+		return nil, domain.Validation("imports are limited to %d rows", MaxImportRows).WithCode("import_too_large")
+	}
+
+	existing, err := s.repo.ListByUser(ctx, userID, database.TodoListOptions{Archived: database.IncludeArchived})
+	if err != nil {
+		return nil, err
+	}
+	// Placeholder IDs mark rows of this file in seen during a dry run.
+	seen := make(map[string]primitive.ObjectID, len(existing)+len(records))
+	for _, t := range existing {
+		seen[duplicateKey(t.Title, t.DueAt)] = t.ID
+	}
+
+	report := &ImportReport{Format: opts.Format, DryRun: opts.DryRun, Total: len(records), Rows: make([]ImportRow, 0, len(records))}
+	for _, rec := range records {
+		row := ImportRow{Row: rec.Row, Title: rec.Title}
+		if rec.Err == nil && strings.TrimSpace(rec.Title) == "" {
+			rec.Err = domain.Validation("title is required").WithCode("title_required")
+		}
+		if rec.Err != nil {
+			row.Status, row.Code, row.Error = ImportError, domain.Code(rec.Err), domain.Message(rec.Err)
+			report.Failed++
+			report.Rows = append(report.Rows, row)
+			continue
+		}
+
+		key := duplicateKey(rec.Title, rec.DueAt)
+		if id, dup := seen[key]; dup && !opts.AllowDuplicates {
+			row.Status = ImportDuplicate
+			if !id.IsZero() {
+				row.DuplicateOf = &id
+			}
+			report.Duplicates++
+			report.Rows = append(report.Rows, row)
+			continue
+		}
+
+		if opts.DryRun {
+			seen[key] = primitive.NilObjectID
+			row.Status = ImportWouldCreate
+			report.Created++
+			report.Rows = append(report.Rows, row)
+			continue
+		}
+
+		todo, err := s.importRecord(ctx, userID, rec)
+		if todo != nil {
+			seen[key] = todo.ID
+			row.TodoID = &todo.ID
+		}
+		if err != nil {
+			row.Status, row.Code, row.Error = ImportError, domain.Code(err), domain.Message(err)
+			if row.Error == "" {
+				log.Printf("todo import: row %d for %s: %v", rec.Row, userID.Hex(), err)
+				row.Error = "could not save todo"
+			}
+			report.Failed++
+			report.Rows = append(report.Rows, row)
+			continue
+		}
+		row.Status = ImportCreated
+		report.Created++
+		report.Rows = append(report.Rows, row)
+	}
+	return report, nil
+}
+
+func (s *TodoService) importRecord(ctx context.Context, userID primitive.ObjectID, rec importRecord) (*models.Todo, error) {
+	todo, err := s.Create(ctx, userID, TodoInput{
+		Title:       rec.Title,
+		Description: rec.Description,
+		DueAt:       rec.DueAt,
+		ProjectID:   rec.ProjectID,
+	})
+	if err != nil || !rec.Completed {
+		return todo, err
+	}
+	completed := true
+	done, err := s.Update(ctx, userID, todo.ID, TodoUpdate{Completed: &completed})
+	if err != nil {
+		return todo, err
+	}
+	return done, nil
+}
+
+// duplicateKey matches todos by case- and whitespace-insensitive title and due instant.
+func duplicateKey(title string, dueAt *time.Time) string {
+	key := strings.ToLower(strings.Join(strings.Fields(title), " "))
+	if dueAt != nil {
+		key += "|" + dueAt.UTC().Format(time.RFC3339)
+	}
+	return key
+}
+
+func invalidImportFile(format string, args ...any) error {
+	return domain.Validation(format, args...).WithCode("invalid_import_file")
+}
+
+func parseImport(format TransferFormat, r io.Reader, loc *time.Location) ([]importRecord, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSVImport(r, loc)
+	case FormatJSON:
+		return parseJSONImport(r)
+	case FormatTodoTxt:
+		return parseTodoTxtImport(r, loc)
+	case FormatMarkdown:
+		return parseMarkdownImport(r, loc)
+	case FormatICal:
+		return parseICalImport(r, loc)
+	}
+	return nil, domain.Validation("unsupported format %q", format).WithCode("invalid_format")
+}
+
+// parseCSVImport reads a CSV file whose header names the columns. title is
+// required; description, completed, due_at and project_id are optional and any
+// other column (such as the id and timestamps of an export) is ignored.
+func parseCSVImport(r io.Reader, loc *time.Location) ([]importRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, invalidImportFile("CSV file is empty")
+	}
+	if err != nil {
+		return nil, invalidImportFile("CSV header: %v", err)
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	if _, ok := cols["title"]; !ok {
+		return nil, invalidImportFile("CSV header must include a title column")
+	}
+
+	var records []importRecord
+	for {
+		fields, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			records = append(records, importRecord{Row: perr.Line, Err: domain.Validation("%v", perr.Err).WithCode("invalid_row")})
+			continue
+		}
+		if err != nil {
+			return nil, invalidImportFile("CSV: %v", err)
+		}
+		line, _ := cr.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		rec := importRecord{Row: line, Title: field("title"), Description: field("description")}
+		rec.Completed, rec.Err = parseImportBool(field("completed"))
+		if rec.Err == nil {
+			rec.DueAt, rec.Err = parseImportDate(field("due_at"), loc)
+		}
+		if rec.Err == nil {
+			rec.ProjectID, rec.Err = parseImportProjectID(field("project_id"))
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// importJSONTodo is one element of a JSON import; an export is valid input.
+type importJSONTodo struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	DueAt       *time.Time `json:"due_at"`
+	ProjectID   string     `json:"project_id"`
+}
+
+func parseJSONImport(r io.Reader) ([]importRecord, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, invalidImportFile("JSON import must be an array of todos: %v", err)
+	}
+	records := make([]importRecord, len(items))
+	for i, raw := range items {
+		rec := importRecord{Row: i + 1}
+		var item importJSONTodo
+		if err := json.Unmarshal(raw, &item); err != nil {
+			rec.Err = domain.Validation("%v", err).WithCode("invalid_row")
+		} else {
+			rec.Title, rec.Description, rec.Completed, rec.DueAt = item.Title, item.Description, item.Completed, item.DueAt
+			rec.ProjectID, rec.Err = parseImportProjectID(item.ProjectID)
+		}
+		records[i] = rec
+	}
+	return records, nil
+}
+
+// parseTodoTxtImport reads todo.txt lines: an optional "x" completion marker,
+// completion and creation dates, and a "(A)" priority, which are dropped; a
+// due:YYYY-MM-DD tag sets the due date. Everything else, including +project
+// and @context tags, stays in the title.
+func parseTodoTxtImport(r io.Reader, loc *time.Location) ([]importRecord, error) {
+	var records []importRecord
+	err := scanLines(r, func(line int, text string) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return
+		}
+		rec := importRecord{Row: line}
+		words := strings.Fields(text)
+		if words[0] == "x" {
+			rec.Completed = true
+			words = words[1:]
+		}
+		if len(words) > 0 && isTodoTxtPriority(words[0]) {
+			words = words[1:]
+		}
+		for i := 0; i < 2 && len(words) > 0 && isDate(words[0]); i++ {
+			words = words[1:]
+		}
+
+		title := words[:0]
+		for _, w := range words {
+			if v, ok := strings.CutPrefix(w, "due:"); ok && rec.Err == nil {
+				rec.DueAt, rec.Err = parseImportDate(v, loc)
+				continue
+			}
+			title = append(title, w)
+		}
+		rec.Title = strings.Join(title, " ")
+		records = append(records, rec)
+	})
+	return records, err
+}
+
+// parseMarkdownImport reads list items ("- [ ] title", "- [x] title" or
+// "- title"), with an optional due:YYYY-MM-DD tag. Indented lines that follow
+// an item become its description; headings and other text are ignored.
+func parseMarkdownImport(r io.Reader, loc *time.Location) ([]importRecord, error) {
+	var records []importRecord
+	var current *importRecord
+	err := scanLines(r, func(line int, text string) {
+		if current != nil && (strings.HasPrefix(text, "  ") || strings.HasPrefix(text, "\t")) && strings.TrimSpace(text) != "" {
+			if current.Description != "" {
+				current.Description += "\n"
+			}
+			current.Description += strings.TrimSpace(text)
+			return
+		}
+		current = nil
+
+		item, ok := strings.CutPrefix(text, "- ")
+		if !ok {
+			item, ok = strings.CutPrefix(text, "* ")
+		}
+		if !ok {
+			return
+		}
+		rec := importRecord{Row: line}
+		switch {
+		case strings.HasPrefix(item, "[ ] "):
+			item = item[4:]
+		case strings.HasPrefix(item, "[x] "), strings.HasPrefix(item, "[X] "):
+			rec.Completed = true
+			item = item[4:]
+		}
+
+		var title []string
+		for _, w := range strings.Fields(item) {
+			if v, ok := strings.CutPrefix(w, "due:"); ok && rec.Err == nil {
+				rec.DueAt, rec.Err = parseImportDate(v, loc)
+				continue
+			}
+			title = append(title, w)
+		}
+		rec.Title = strings.Join(title, " ")
+		records = append(records, rec)
+		current = &records[len(records)-1]
+	})
+	return records, err
+}
+
+func scanLines(r io.Reader, fn func(line int, text string)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		fn(line, strings.TrimRight(text, "\r"))
+	}
+	if err := sc.Err(); err != nil {
+		return invalidImportFile("%v", err)
+	}
+	return nil
+}
+
+func isTodoTxtPriority(w string) bool {
+	return len(w) == 3 && w[0] == '(' && w[1] >= 'A' && w[1] <= 'Z' && w[2] == ')'
+}
+
+func isDate(w string) bool {
+	_, err := time.Parse(time.DateOnly, w)
+	return err == nil
+}
+
+func parseImportBool(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "", "0", "false", "no", "n":
+		return false, nil
+	case "1", "true", "yes", "y", "x":
+		return true, nil
+	}
+	return false, domain.Validation("completed %q must be true or false", v).WithCode("invalid_completed")
+}
+
+// parseImportDate accepts RFC 3339 or a bare date, which means midnight in loc.
+func parseImportDate(v string, loc *time.Location) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, v, loc); err == nil {
+		return &t, nil
+	}
+	return nil, domain.Validation("due date %q must be YYYY-MM-DD or RFC 3339", v).WithCode("invalid_due_at")
+}
+
+func parseImportProjectID(v string) (*primitive.ObjectID, error) {
+	if v == "" {
+		return nil, nil
+	}
+	id, err := primitive.ObjectIDFromHex(v)
+	if err != nil {
+		return nil, domain.Validation("project_id %q must be a project ID", v).WithCode("invalid_project_id")
+	}
+	return &id, nil
+}
+
+// todoWriter encodes todos one at a time for Export.
+type todoWriter interface {
+	Write(t *models.Todo) error
+	Close() error
+}
+
+func newTodoWriter(format TransferFormat, w io.Writer, loc *time.Location) (todoWriter, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		err := cw.Write([]string{"id", "title", "description", "completed", "completed_at", "due_at", "project_id", "created_at", "updated_at"})
+		return &csvTodoWriter{w: cw}, err
+	case FormatJSON:
+		return &jsonTodoWriter{w: bufio.NewWriter(w)}, nil
+	case FormatTodoTxt:
+		return &todoTxtWriter{w: bufio.NewWriter(w), loc: loc}, nil
+	case FormatMarkdown:
+		bw := bufio.NewWriter(w)
+		_, err := bw.WriteString("# Todos\n\n")
+		return &markdownTodoWriter{w: bw, loc: loc}, err
+	case FormatICal:
+		return newICalWriter(w, loc, "Todos", true, false), nil
+	}
+	return nil, domain.Validation("unsupported format %q", format).WithCode("invalid_format")
+}
+
+type csvTodoWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvTodoWriter) Write(t *models.Todo) error {
+	projectID := ""
+	if t.ProjectID != nil {
+		projectID = t.ProjectID.Hex()
+	}
+	return c.w.Write([]string{
+		t.ID.Hex(), t.Title, t.Description, strconv.FormatBool(t.Completed),
+		formatRFC3339(t.CompletedAt), formatRFC3339(t.DueAt), projectID,
+		t.CreatedAt.UTC().Format(time.RFC3339), t.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (c *csvTodoWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonTodoWriter writes a JSON array of todos, as returned by GET /todos.
+type jsonTodoWriter struct {
+	w *bufio.Writer
+	n int
+}
+
+func (j *jsonTodoWriter) Write(t *models.Todo) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if j.n == 0 {
+		sep = "[\n"
+	}
+	j.n++
+	j.w.WriteString(sep)
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonTodoWriter) Close() error {
+	if j.n == 0 {
+		j.w.WriteString("[")
+	}
+	j.w.WriteString("\n]\n")
+	return j.w.Flush()
+}
+
+type todoTxtWriter struct {
+	w   *bufio.Writer
+	loc *time.Location
+}
+
+func (x *todoTxtWriter) Write(t *models.Todo) error {
+	var parts []string
+	if t.Completed {
+		completedAt := t.CreatedAt
+		if t.CompletedAt != nil {
+			completedAt = *t.CompletedAt
+		}
+		parts = append(parts, "x", completedAt.In(x.loc).Format(time.DateOnly))
+	}
+	parts = append(parts, t.CreatedAt.In(x.loc).Format(time.DateOnly), strings.Join(strings.Fields(t.Title), " "))
+	if t.DueAt != nil {
+		parts = append(parts, "due:"+formatTransferDate(*t.DueAt, x.loc))
+	}
+	_, err := x.w.WriteString(strings.Join(parts, " ") + "\n")
+	return err
+}
+
+func (x *todoTxtWriter) Close() error {
+	return x.w.Flush()
+}
+
+type markdownTodoWriter struct {
+	w   *bufio.Writer
+	loc *time.Location
+}
+
+func (m *markdownTodoWriter) Write(t *models.Todo) error {
+	box := "[ ]"
+	if t.Completed {
+		box = "[x]"
+	}
+	line := "- " + box + " " + strings.Join(strings.Fields(t.Title), " ")
+	if t.DueAt != nil {
+		line += " due:" + formatTransferDate(*t.DueAt, m.loc)
+	}
+	m.w.WriteString(line + "\n")
+	for _, l := range strings.Split(strings.TrimSpace(t.Description), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			m.w.WriteString("  " + l + "\n")
+		}
+	}
+	return nil
+}
+
+func (m *markdownTodoWriter) Close() error {
+	return m.w.Flush()
+}
+
+func formatRFC3339(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatTransferDate writes a bare date when t is midnight in loc, so it
+// round-trips through parseImportDate, and RFC 3339 otherwise.
+func formatTransferDate(t time.Time, loc *time.Location) string {
+	if isLocalMidnight(t, loc) {
+		return t.In(loc).Format(time.DateOnly)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// isLocalMidnight reports whether t is the start of a day in loc, which the
+// text formats treat as a date without a time.
+func isLocalMidnight(t time.Time, loc *time.Location) bool {
+	local := t.In(loc)
+	return local.Hour() == 0 && local.Minute() == 0 && local.Second() == 0 && local.Nanosecond() == 0
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	trashPurgeTick  = time.Hour
+	trashPurgeBatch = 100
+)
+
+// TodoPurger removes what it keeps for a todo once the todo is purged.
+type TodoPurger interface {
+	PurgeTodo(ctx context.Context, todoID primitive.ObjectID) error
+}
+
+// TrashPurgeService permanently removes todos that have been soft-deleted for
+// longer than the retention period, along with what the purgers keep for them
+// (attachments). Until then a delete can be undone with restore; the history
+// in todo_events is kept.
+type TrashPurgeService struct {
+	todos     database.TodoRepository
+	retention time.Duration
+	purgers   []TodoPurger
+}
+
+// NewTrashPurgeService purges todos deleted more than retentionDays ago; 0 keeps them forever.
+func NewTrashPurgeService(todos database.TodoRepository, retentionDays int, purgers ...TodoPurger) *TrashPurgeService {
+	return &TrashPurgeService{
+		todos:     todos,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+		purgers:   purgers,
+	}
+}
+
+// Start runs the job until ctx is cancelled.
+func (s *TrashPurgeService) Start(ctx context.Context) {
+	if s.retention <= 0 {
+		log.Println("trash purge: disabled")
+		return
+	}
+	ticker := time.NewTicker(trashPurgeTick)
+	defer ticker.Stop()
+	for {
+		s.RunOnce(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce purges every todo whose retention has run out at now. Failures are
+// logged per todo and retried on the next run.
+func (s *TrashPurgeService) RunOnce(ctx context.Context, now time.Time) {
+	cutoff := now.Add(-s.retention)
+	after := primitive.NilObjectID
+	for {
+		todos, err := s.todos.ListDeletedBefore(ctx, cutoff, after, trashPurgeBatch)
+		if err != nil {
+			log.Printf("trash purge: list: %v", err)
+			return
+		}
+		for _, t := range todos {
+			s.purge(ctx, t.ID, cutoff)
+			after = t.ID
+		}
+		if len(todos) < trashPurgeBatch {
+			return
+		}
+	}
+}
+
+// purge runs the purgers before removing the todo, so if one fails the todo
+// is still in the trash and the whole purge is retried on the next run.
+func (s *TrashPurgeService) purge(ctx context.Context, todoID primitive.ObjectID, cutoff time.Time) {
+	for _, p := range s.purgers {
+		if err := p.PurgeTodo(ctx, todoID); err != nil {
+			log.Printf("trash purge: purge data of %s: %v", todoID.Hex(), err)
+			return
+		}
+	}
+	if err := s.todos.Purge(ctx, todoID, cutoff); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		log.Printf("trash purge: purge %s: %v", todoID.Hex(), err)
+	}
+}
@@ -0,0 +1,510 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// WebhookMaxFailures consecutive failed attempts disable a webhook.
+	WebhookMaxFailures = 10
+
+	webhookTimeout      = 10 * time.Second
+	webhookLease        = time.Minute
+	webhookPollInterval = 5 * time.Second
+	webhookWorkers      = 4
+	webhookBodyLimit    = 1024
+	// WebhookPingEvent is the event name of test deliveries.
+	WebhookPingEvent = "ping"
+)
+
+// webhookBackoff is the wait before each retry; a delivery gets len+1 attempts.
+var webhookBackoff = []time.Duration{
+	30 * time.Second, 2 * time.Minute, 10 * time.Minute, 30 * time.Minute, time.Hour, 3 * time.Hour,
+}
+
+// Signature headers sent with every delivery. X-Webhook-Signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookIDHeader        = "X-Webhook-ID"
+)
+
+// WebhookService manages webhook registrations and delivers todo events to
+// them. Deliveries are queued in Mongo by Publish and sent by background
+// workers with exponential backoff, so retries survive restarts and several
+// instances can share the queue.
+type WebhookService struct {
+	hooks      database.WebhookRepository
+	deliveries database.WebhookDeliveryRepository
+	client     *http.Client
+	wake       chan struct{}
+}
+
+func NewWebhookService(hooks database.WebhookRepository, deliveries database.WebhookDeliveryRepository, allowPrivate bool) *WebhookService {
+	return &WebhookService{
+		hooks:      hooks,
+		deliveries: deliveries,
+		client:     newWebhookClient(allowPrivate),
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// newWebhookClient builds the delivery client. Unless allowPrivate is set it
+// refuses to connect to loopback, private and link-local addresses (checked
+// after DNS resolution) so webhooks cannot be used to probe internal services.
+func newWebhookClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = denyNonPublicAddress
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func denyNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
+
+// WebhookInput holds the fields accepted when registering a webhook.
+type WebhookInput struct {
+	URL    string
+	Secret string // empty generates one
+	Events []string
+}
+
+// WebhookPatch holds a partial update; nil means unchanged. Setting Active
+// re-enables a webhook that was disabled after repeated failures.
+type WebhookPatch struct {
+	URL    *string
+	Secret *string
+	Events *[]string
+	Active *bool
+}
+
+// WebhookPayload is the JSON body POSTed to webhook URLs.
+type WebhookPayload struct {
+	ID        string    `json:"id" example:"66f0c2a1e4b0a1b2c3d4e5f6"` // delivery ID, stable across retries
+	Event     string    `json:"event" example:"todo.completed"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Create registers a webhook and returns it with its signing secret, which is
+// not shown again.
+func (s *WebhookService) Create(ctx context.Context, userID primitive.ObjectID, in WebhookInput) (*models.Webhook, string, error) {
+	if err := checkWorkspaceAdmin(ctx); err != nil {
+		return nil, "", err
+	}
+	if err := validateWebhookURL(in.URL); err != nil {
+		return nil, "", err
+	}
+	if err := validateWebhookEvents(in.Events); err != nil {
+		return nil, "", err
+	}
+	secret := in.Secret
+	if secret == "" {
+		secret = newWebhookSecret()
+	}
+	events := in.Events
+	if events == nil {
+		events = []string{}
+	}
+
+	now := time.Now()
+	hook := &models.Webhook{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		URL:       in.URL,
+		Secret:    secret,
+		Events:    events,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.hooks.Create(ctx, hook); err != nil {
+		return nil, "", err
+	}
+	return hook, secret, nil
+}
+
+func (s *WebhookService) List(ctx context.Context, userID primitive.ObjectID) ([]*models.Webhook, error) {
+	return s.hooks.ListByUser(ctx, userID)
+}
+
+func (s *WebhookService) Get(ctx context.Context, userID, hookID primitive.ObjectID) (*models.Webhook, error) {
+	return s.hooks.GetByID(ctx, userID, hookID)
+}
+
+func (s *WebhookService) Update(ctx context.Context, userID, hookID primitive.ObjectID, p WebhookPatch) (*models.Webhook, error) {
+	if err := checkWorkspaceAdmin(ctx); err != nil {
+		return nil, err
+	}
+	update := bson.M{"updated_at": time.Now()}
+	if p.URL != nil {
+		if err := validateWebhookURL(*p.URL); err != nil {
+			return nil, err
+		}
+		update["url"] = *p.URL
+	}
+	if p.Secret != nil {
+		update["secret"] = *p.Secret
+	}
+	if p.Events != nil {
+		if err := validateWebhookEvents(*p.Events); err != nil {
+			return nil, err
+		}
+		update["events"] = *p.Events
+	}
+	if p.Active != nil {
+		update["active"] = *p.Active
+		if *p.Active {
+			update["consecutive_failures"] = 0
+			update["disabled_at"] = nil
+			update["disabled_reason"] = ""
+		}
+	}
+	return s.hooks.Update(ctx, userID, hookID, update)
+}
+
+func (s *WebhookService) Delete(ctx context.Context, userID, hookID primitive.ObjectID) error {
+	if err := checkWorkspaceAdmin(ctx); err != nil {
+		return err
+	}
+	return s.hooks.Delete(ctx, userID, hookID)
+}
+
+// Deliveries returns the webhook's delivery log, newest first.
+func (s *WebhookService) Deliveries(ctx context.Context, userID, hookID, before primitive.ObjectID, limit int64) ([]*models.WebhookDelivery, error) {
+	if _, err := s.hooks.GetByID(ctx, userID, hookID); err != nil {
+		return nil, err
+	}
+	return s.deliveries.ListByWebhook(ctx, hookID, database.EventPage{Before: before, Limit: clampActivityLimit(limit)})
+}
+
+// Test sends a ping event right away, even to a disabled webhook, and returns
+// the logged delivery. It is not retried and does not count towards auto-disable.
+func (s *WebhookService) Test(ctx context.Context, userID, hookID primitive.ObjectID) (*models.WebhookDelivery, error) {
+	if err := checkWorkspaceAdmin(ctx); err != nil {
+		return nil, err
+	}
+	hook, err := s.hooks.GetByID(ctx, userID, hookID)
+	if err != nil {
+		return nil, err
+	}
+	d, err := newWebhookDelivery(hook, WebhookPingEvent, map[string]any{
+		"webhook_id": hook.ID,
+		"message":    "Test event from the todo API",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := s.attempt(ctx, hook, d)
+	if res.Status == models.WebhookDeliveryPending {
+		res.Status, res.NextAttemptAt = models.WebhookDeliveryFailed, nil
+	}
+	res.applyTo(d)
+	d.Attempts = 1
+	if err := s.deliveries.Create(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Publish queues a delivery for each active webhook that subscribes to the
+// event: the workspace's webhooks for workspace todos, otherwise the owner's
+// personal ones. It runs on the writing request, once per event.
+func (s *WebhookService) Publish(event *models.TodoEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hooks, err := s.hooks.ListActive(ctx, event.UserID, event.WorkspaceID)
+	if err != nil {
+		log.Printf("webhooks: list for %s: %v", event.UserID.Hex(), err)
+		return
+	}
+	queued := false
+	for _, hook := range hooks {
+		if !hook.Wants(event.Type.Name()) {
+			continue
+		}
+		d, err := newWebhookDelivery(hook, event.Type.Name(), event)
+		if err == nil {
+			err = s.deliveries.Create(ctx, d)
+		}
+		if err != nil {
+			log.Printf("webhooks: queue %s for %s: %v", event.Type.Name(), hook.ID.Hex(), err)
+			continue
+		}
+		queued = true
+	}
+	if queued {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Start runs the delivery workers until ctx is done.
+func (s *WebhookService) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < webhookWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *WebhookService) work(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			d, err := s.deliveries.Claim(ctx, time.Now(), webhookLease)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("webhooks: claim: %v", err)
+				}
+				break
+			}
+			if d == nil {
+				break
+			}
+			s.process(ctx, d)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// process makes one attempt at a claimed delivery and schedules the next one
+// or records the final outcome.
+func (s *WebhookService) process(ctx context.Context, d *models.WebhookDelivery) {
+	var update bson.M
+	hook, err := s.hooks.FindByID(ctx, d.WebhookID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		update = bson.M{"status": models.WebhookDeliveryFailed, "error": "webhook deleted", "next_attempt_at": nil}
+	case err != nil:
+		log.Printf("webhooks: load %s: %v", d.WebhookID.Hex(), err)
+		return // the lease expires and the delivery is retried
+	case !hook.Active:
+		update = bson.M{"status": models.WebhookDeliveryFailed, "error": "webhook disabled", "next_attempt_at": nil}
+	default:
+		res := s.attempt(ctx, hook, d)
+		update = res.update()
+		disabled, err := s.hooks.RecordAttempt(ctx, hook.ID, res.Status == models.WebhookDeliverySucceeded, WebhookMaxFailures)
+		if err != nil {
+			log.Printf("webhooks: record attempt for %s: %v", hook.ID.Hex(), err)
+		}
+		if disabled {
+			log.Printf("webhooks: disabled %s after %d consecutive failures", hook.ID.Hex(), WebhookMaxFailures)
+		}
+	}
+	if err := s.deliveries.Finish(ctx, d.ID, update); err != nil {
+		log.Printf("webhooks: finish delivery %s: %v", d.ID.Hex(), err)
+	}
+}
+
+// webhookAttempt is the outcome of one delivery attempt. Status is pending
+// (with NextAttemptAt) when the delivery should be retried.
+type webhookAttempt struct {
+	Status        models.WebhookDeliveryStatus
+	ResponseCode  int
+	ResponseBody  string
+	Error         string
+	DurationMS    int64
+	At            time.Time
+	NextAttemptAt *time.Time
+}
+
+func (a webhookAttempt) update() bson.M {
+	return bson.M{
+		"status":          a.Status,
+		"response_code":   a.ResponseCode,
+		"response_body":   a.ResponseBody,
+		"error":           a.Error,
+		"duration_ms":     a.DurationMS,
+		"last_attempt_at": a.At,
+		"next_attempt_at": a.NextAttemptAt,
+	}
+}
+
+func (a webhookAttempt) applyTo(d *models.WebhookDelivery) {
+	d.Status = a.Status
+	d.ResponseCode = a.ResponseCode
+	d.ResponseBody = a.ResponseBody
+	d.Error = a.Error
+	d.DurationMS = a.DurationMS
+	d.LastAttemptAt = &a.At
+	d.NextAttemptAt = a.NextAttemptAt
+}
+
+// attempt POSTs the delivery once and decides whether it needs a retry.
+func (s *WebhookService) attempt(ctx context.Context, hook *models.Webhook, d *models.WebhookDelivery) webhookAttempt {
+	res := webhookAttempt{At: time.Now()}
+	code, body, err := s.post(ctx, hook, d, res.At)
+	res.DurationMS = time.Since(res.At).Milliseconds()
+	res.ResponseCode, res.ResponseBody = code, body
+	switch {
+	case err != nil:
+		res.Error = err.Error()
+	case code < 200 || code > 299:
+		res.Error = "endpoint returned " + strconv.Itoa(code)
+	default:
+		res.Status = models.WebhookDeliverySucceeded
+		return res
+	}
+
+	if d.Attempts < len(webhookBackoff) {
+		next := res.At.Add(webhookBackoff[d.Attempts])
+		res.Status, res.NextAttemptAt = models.WebhookDeliveryPending, &next
+	} else {
+		res.Status = models.WebhookDeliveryFailed
+	}
+	return res
+}
+
+func (s *WebhookService) post(ctx context.Context, hook *models.Webhook, d *models.WebhookDelivery, now time.Time) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader([]byte(d.Payload)))
+	if err != nil {
+		return 0, "", err
+	}
+	ts := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "golang-todo-webhooks/1.0")
+	req.Header.Set(WebhookIDHeader, hook.ID.Hex())
+	req.Header.Set(WebhookEventHeader, d.Event)
+	req.Header.Set(WebhookDeliveryHeader, d.ID.Hex())
+	req.Header.Set(WebhookTimestampHeader, ts)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(hook.Secret, ts, []byte(d.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookBodyLimit))
+	return resp.StatusCode, string(body), nil
+}
+
+// SignWebhook computes the X-Webhook-Signature value for a payload.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookDelivery(hook *models.Webhook, event string, data any) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	d := &models.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		WebhookID:     hook.ID,
+		UserID:        hook.UserID,
+		Event:         event,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}
+	payload, err := json.Marshal(WebhookPayload{ID: d.ID.Hex(), Event: event, CreatedAt: now, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = string(payload)
+	return d, nil
+}
+
+// checkWorkspaceAdmin leaves a workspace's webhooks to its admins and owner,
+// since they send the workspace's todos to outside URLs.
+func checkWorkspaceAdmin(ctx context.Context) error {
+	if scope, ok := database.WorkspaceFrom(ctx); ok && !scope.Role.CanManage() {
+		return errWorkspaceForbidden()
+	}
+	return nil
+}
+
+func newWebhookSecret() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return domain.Validation("url must be an absolute http(s) URL").WithCode("invalid_webhook_url")
+	}
+	if u.User != nil {
+		return domain.Validation("url must not contain credentials; use the signing secret").WithCode("invalid_webhook_url")
+	}
+	return nil
+}
+
+func validateWebhookEvents(events []string) error {
+	for _, e := range events {
+		known := false
+		for _, t := range models.TodoEventTypes {
+			if e == t.Name() {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return domain.Validation("unknown event %q", e).WithCode("invalid_webhook_event")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxWorkflowColumns    = 20
+	maxWorkflowColumnName = 50
+)
+
+var workflowKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// WorkflowInput is a project's new set of columns and transitions.
+type WorkflowInput struct {
+	Columns     []models.WorkflowColumn
+	Transitions map[string][]string
+}
+
+// Board is a project's todos grouped into its workflow columns.
+type Board struct {
+	ProjectID *primitive.ObjectID `json:"project_id,omitempty" swaggertype:"string" example:"64f1c2a7e1b2c3d4e5f60718"`
+	Columns   []BoardColumn       `json:"columns"`
+}
+
+type BoardColumn struct {
+	Key      string         `json:"key" example:"in_progress"`
+	Name     string         `json:"name" example:"In progress"`
+	WIPLimit int            `json:"wip_limit" example:"3"`
+	Done     bool           `json:"done"`
+	Count    int            `json:"count" example:"2"`
+	Todos    []*models.Todo `json:"todos"`
+}
+
+// Workflow returns a project's workflow, or the default one if it has none.
+func (s *TodoService) Workflow(ctx context.Context, userID, projectID primitive.ObjectID) (*models.Workflow, error) {
+	return s.workflowFor(ctx, userID, &projectID)
+}
+
+// SetWorkflow replaces a project's workflow. Todos in columns that no longer
+// exist, or whose done flag no longer matches, move to the first fitting column.
+// In a workspace it needs the owner or admin role.
+func (s *TodoService) SetWorkflow(ctx context.Context, userID, projectID primitive.ObjectID, in WorkflowInput) (*models.Workflow, error) {
+	if err := checkWorkflowManager(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateWorkflow(in); err != nil {
+		return nil, err
+	}
+	w := &models.Workflow{
+		UserID:      userID,
+		ProjectID:   &projectID,
+		Columns:     in.Columns,
+		Transitions: in.Transitions,
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.workflows.Put(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, s.reflow(ctx, userID, w)
+}
+
+// ResetWorkflow deletes a project's workflow, so it uses the default again.
+func (s *TodoService) ResetWorkflow(ctx context.Context, userID, projectID primitive.ObjectID) (*models.Workflow, error) {
+	if err := checkWorkflowManager(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.workflows.Delete(ctx, userID, projectID); err != nil {
+		return nil, err
+	}
+	w := models.DefaultWorkflow()
+	w.ProjectID = &projectID
+	return w, s.reflow(ctx, userID, w)
+}
+
+// Board returns the todos of a project (nil: todos without a project) grouped
+// by workflow column, in the user's default sort order.
+func (s *TodoService) Board(ctx context.Context, userID primitive.ObjectID, projectID *primitive.ObjectID) (*Board, error) {
+	w, err := s.workflowFor(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := s.listOptions(ctx, userID, TodoListQuery{ProjectID: projectID})
+	if err != nil {
+		return nil, err
+	}
+	opts.NoProject = projectID == nil
+	todos, err := s.repo.ListByUser(ctx, userID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.annotate(ctx, todos...); err != nil {
+		return nil, err
+	}
+
+	board := &Board{ProjectID: projectID, Columns: make([]BoardColumn, len(w.Columns))}
+	index := map[string]int{}
+	for i, col := range w.Columns {
+		board.Columns[i] = BoardColumn{Key: col.Key, Name: col.Name, WIPLimit: col.WIPLimit, Done: col.Done, Todos: []*models.Todo{}}
+		index[col.Key] = i
+	}
+	for _, t := range todos {
+		col := &board.Columns[index[w.StatusFor(t.Status, t.Completed)]]
+		col.Todos = append(col.Todos, t)
+		col.Count++
+	}
+	return board, nil
+}
+
+// workflowFor loads the workflow of an owner's project, falling back to the default.
+func (s *TodoService) workflowFor(ctx context.Context, ownerID primitive.ObjectID, projectID *primitive.ObjectID) (*models.Workflow, error) {
+	if projectID != nil {
+		w, err := s.workflows.Get(ctx, ownerID, *projectID)
+		if err == nil {
+			return w, nil
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+	}
+	w := models.DefaultWorkflow()
+	w.ProjectID = projectID
+	return w, nil
+}
+
+// resolveStatus keeps status and completion in step. When u sets either one or
+// moves the todo to another project, the other is derived from the workflow of
+// the todo's project and set on u. Unless u.Force is set, moving between
+// columns must follow the workflow's transitions and WIP limits.
+func (s *TodoService) resolveStatus(ctx context.Context, current *models.Todo, u *TodoUpdate) error {
+	projectChanged := u.ProjectID != nil && (current.ProjectID == nil || *current.ProjectID != *u.ProjectID)
+	if u.Status == nil && u.Completed == nil && !projectChanged {
+		return nil
+	}
+	projectID := current.ProjectID
+	if u.ProjectID != nil {
+		projectID = u.ProjectID
+	}
+	w, err := s.workflowFor(ctx, current.UserID, projectID)
+	if err != nil {
+		return err
+	}
+
+	from := statusOf(current)
+	var to string
+	switch {
+	case u.Status != nil:
+		col, ok := w.Column(*u.Status)
+		if !ok {
+			keys := make([]string, len(w.Columns))
+			for i, c := range w.Columns {
+				keys[i] = c.Key
+			}
+			return domain.Validation("status must be one of: %s", strings.Join(keys, ", ")).WithCode("invalid_status")
+		}
+		if u.Completed != nil && *u.Completed != col.Done {
+			return domain.Validation("status %s does not match completed", col.Key).WithCode("invalid_status")
+		}
+		to = col.Key
+	case u.Completed != nil:
+		to = w.StatusFor(from, *u.Completed)
+	default:
+		to = w.StatusFor(from, current.Completed)
+	}
+	col, _ := w.Column(to)
+	u.Status = &to
+	// Only touch completed when it changes, so moving between done columns keeps completed_at.
+	if u.Completed == nil && col.Done != current.Completed {
+		completed := col.Done
+		u.Completed = &completed
+	}
+
+	if u.Force || (to == from && !projectChanged) {
+		return nil
+	}
+	// Transitions are rules within one workflow; a todo entering another project may land in any column.
+	if !projectChanged && !w.Allows(from, to) {
+		return domain.Conflict("todos cannot move from %s to %s", from, to).WithCode("status_transition_not_allowed")
+	}
+	if col.WIPLimit > 0 {
+		n, err := s.repo.CountInStatus(ctx, current.UserID, projectID, to)
+		if err != nil {
+			return err
+		}
+		if n >= int64(col.WIPLimit) {
+			return domain.Conflict("%s already holds its limit of %d todos", col.Name, col.WIPLimit).WithCode("wip_limit_reached")
+		}
+	}
+	return nil
+}
+
+// reflow moves the todos of w's project into columns of w after it changed,
+// recording each move as an update by actorID.
+func (s *TodoService) reflow(ctx context.Context, actorID primitive.ObjectID, w *models.Workflow) error {
+	todos, err := s.repo.ListByUser(ctx, w.UserID, database.TodoListOptions{ProjectID: w.ProjectID, Archived: database.IncludeArchived})
+	if err != nil {
+		return err
+	}
+	for _, t := range todos {
+		to := w.StatusFor(statusOf(t), t.Completed)
+		if to == t.Status {
+			continue
+		}
+		before, err := s.repo.Update(ctx, t.UserID, t.ID, bson.M{"status": to, "updated_at": time.Now()}, nil)
+		if errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		changes := []models.FieldChange{{Field: "status", Before: before.Status, After: to}}
+		s.record(ctx, before, before.Version+1, actorID, models.TodoEventUpdated, changes)
+	}
+	return nil
+}
+
+// statusOf is the todo's status, derived from completed for todos written before statuses existed.
+func statusOf(t *models.Todo) string {
+	if t.Status == "" {
+		return models.LegacyStatus(t.Completed)
+	}
+	return t.Status
+}
+
+// checkWorkflowManager lets workspace owners and admins change workflows; in
+// personal scope the caller only ever configures their own projects.
+func checkWorkflowManager(ctx context.Context) error {
+	if scope, ok := database.WorkspaceFrom(ctx); ok {
+		return checkRole(scope.Role.TodoRole(), models.ShareRoleOwner)
+	}
+	return nil
+}
+
+func validateWorkflow(in WorkflowInput) error {
+	invalid := func(format string, args ...any) error {
+		return domain.Validation(format, args...).WithCode("invalid_workflow")
+	}
+	if len(in.Columns) < 2 || len(in.Columns) > maxWorkflowColumns {
+		return invalid("a workflow needs between 2 and %d columns", maxWorkflowColumns)
+	}
+	seen := map[string]bool{}
+	open, done := false, false
+	for i := range in.Columns {
+		col := &in.Columns[i]
+		col.Name = strings.TrimSpace(col.Name)
+		if !workflowKeyPattern.MatchString(col.Key) {
+			return invalid("column key %q must be 1-32 lowercase letters, digits, - or _", col.Key)
+		}
+		if seen[col.Key] {
+			return invalid("column key %q is used twice", col.Key)
+		}
+		seen[col.Key] = true
+		if col.Name == "" || len([]rune(col.Name)) > maxWorkflowColumnName {
+			return invalid("column %s needs a name of at most %d characters", col.Key, maxWorkflowColumnName)
+		}
+		if col.WIPLimit < 0 {
+			return invalid("column %s has a negative WIP limit", col.Key)
+		}
+		open = open || !col.Done
+		done = done || col.Done
+	}
+	if !open || !done {
+		return invalid("a workflow needs at least one open and one done column")
+	}
+	for from, next := range in.Transitions {
+		if !seen[from] {
+			return invalid("transitions refer to unknown column %q", from)
+		}
+		for _, to := range next {
+			if !seen[to] {
+				return invalid("transitions refer to unknown column %q", to)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/group14000/golang-todo/internal/database"
+	"github.com/group14000/golang-todo/internal/domain"
+	"github.com/group14000/golang-todo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkspaceService manages workspaces, their members and invitations. Scoping
+// todos to a workspace is done by WorkspaceMiddleware and the repositories.
+type WorkspaceService struct {
+	repo    database.WorkspaceRepository
+	users   database.UserRepository
+	email   *EmailService
+	baseURL string
+}
+
+func NewWorkspaceService(repo database.WorkspaceRepository, users database.UserRepository, email *EmailService, baseURL string) *WorkspaceService {
+	return &WorkspaceService{repo: repo, users: users, email: email, baseURL: baseURL}
+}
+
+// WorkspaceInvite is a pending invitation as seen by the invitee.
+type WorkspaceInvite struct {
+	WorkspaceID   primitive.ObjectID `json:"workspace_id"`
+	WorkspaceName string             `json:"workspace_name"`
+	models.WorkspaceInvitation
+}
+
+// Create makes a workspace with the caller as its owner.
+func (s *WorkspaceService) Create(ctx context.Context, userID primitive.ObjectID, name string) (*models.Workspace, error) {
+	user, err := s.users.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	ws := &models.Workspace{
+		ID:      primitive.NewObjectID(),
+		Name:    name,
+		OwnerID: userID,
+		Members: []models.WorkspaceMember{{
+			UserID:   userID,
+			Email:    normalizeEmail(user.Email),
+			Name:     user.Name,
+			Role:     models.WorkspaceRoleOwner,
+			JoinedAt: now,
+		}},
+		Invitations: []models.WorkspaceInvitation{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.Create(ctx, ws); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+func (s *WorkspaceService) List(ctx context.Context, userID primitive.ObjectID) ([]*models.Workspace, error) {
+	return s.repo.ListByMember(ctx, userID)
+}
+
+// Get returns a workspace the caller is a member of.
+func (s *WorkspaceService) Get(ctx context.Context, userID, workspaceID primitive.ObjectID) (*models.Workspace, error) {
+	return s.repo.GetForMember(ctx, workspaceID, userID)
+}
+
+// Role returns the caller's role in a workspace; non-members get workspace_not_found.
+func (s *WorkspaceService) Role(ctx context.Context, userID, workspaceID primitive.ObjectID) (models.WorkspaceRole, error) {
+	ws, err := s.repo.GetForMember(ctx, workspaceID, userID)
+	if err != nil {
+		return "", err
+	}
+	m, _ := ws.Member(userID)
+	return m.Role, nil
+}
+
+// Rename needs an admin or the owner.
+func (s *WorkspaceService) Rename(ctx context.Context, userID, workspaceID primitive.ObjectID, name string) (*models.Workspace, error) {
+	if _, err := s.manager(ctx, userID, workspaceID); err != nil {
+		return nil, err
+	}
+	return s.repo.Rename(ctx, workspaceID, name)
+}
+
+// Invite emails an invitation to join with role (admin, member or viewer).
+func (s *WorkspaceService) Invite(ctx context.Context, userID, workspaceID primitive.ObjectID, email string, role models.WorkspaceRole) (*models.WorkspaceInvitation, error) {
+	ws, err := s.manager(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if role == models.WorkspaceRoleOwner {
+		return nil, domain.Validation("a workspace has a single owner").WithCode("invalid_role")
+	}
+	email = normalizeEmail(email)
+	for _, m := range ws.Members {
+		if m.Email == email {
+			return nil, domain.Conflict("%s is already a member", email).WithCode("member_exists")
+		}
+	}
+
+	inv := models.WorkspaceInvitation{
+		ID:        primitive.NewObjectID(),
+		Email:     email,
+		Role:      role,
+		InvitedBy: userID,
+		CreatedAt: time.Now(),
+	}
+	if _, err := s.repo.AddInvitation(ctx, workspaceID, inv); err != nil {
+		return nil, err
+	}
+
+	// Invitations are also listed under GET /workspaces/invitations, so a failed email is not fatal.
+	inviter, _ := ws.Member(userID)
+	locale := ""
+	if invitee, err := s.users.FindUserByEmail(ctx, email); err == nil {
+		locale = invitee.Preferences.Locale
+	}
+	err = s.email.Send(email, "workspace_invitation", locale, map[string]any{
+		"InviterName":   inviter.Name,
+		"WorkspaceName": ws.Name,
+		"Email":         email,
+		"Role":          string(role),
+		"URL":           s.baseURL,
+	})
+	if err != nil {
+		log.Printf("workspaces: invitation email for %s in %s: %v", inv.ID.Hex(), workspaceID.Hex(), err)
+	}
+	return &inv, nil
+}
+
+// RevokeInvitation needs an admin or the owner.
+func (s *WorkspaceService) RevokeInvitation(ctx context.Context, userID, workspaceID, invitationID primitive.ObjectID) error {
+	if _, err := s.manager(ctx, userID, workspaceID); err != nil {
+		return err
+	}
+	_, err := s.repo.RemoveInvitation(ctx, workspaceID, invitationID)
+	return err
+}
+
+// Invitations lists the pending invitations for the caller's email.
+func (s *WorkspaceService) Invitations(ctx context.Context, userID primitive.ObjectID) ([]WorkspaceInvite, error) {
+	user, err := s.users.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	email := normalizeEmail(user.Email)
+	workspaces, err := s.repo.ListInvited(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	invites := []WorkspaceInvite{}
+	for _, ws := range workspaces {
+		for _, inv := range ws.Invitations {
+			if inv.Email == email {
+				invites = append(invites, WorkspaceInvite{WorkspaceID: ws.ID, WorkspaceName: ws.Name, WorkspaceInvitation: inv})
+			}
+		}
+	}
+	return invites, nil
+}
+
+// Accept joins a workspace through an invitation sent to the caller's email.
+func (s *WorkspaceService) Accept(ctx context.Context, userID, workspaceID, invitationID primitive.ObjectID) (*models.Workspace, error) {
+	user, err := s.users.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	email := normalizeEmail(user.Email)
+	invites, err := s.Invitations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, inv := range invites {
+		if inv.WorkspaceID != workspaceID || inv.ID != invitationID {
+			continue
+		}
+		member := models.WorkspaceMember{UserID: userID, Email: email, Name: user.Name, Role: inv.Role, JoinedAt: time.Now()}
+		ws, err := s.repo.AcceptInvitation(ctx, workspaceID, invitationID, member)
+		if errors.Is(err, domain.ErrNotFound) {
+			// Already a member (e.g. invited twice under different emails).
+			return s.repo.GetForMember(ctx, workspaceID, userID)
+		}
+		return ws, err
+	}
+	return nil, domain.NotFound("invitation not found").WithCode("invitation_not_found")
+}
+
+// SetRole changes a member's role. Admins and the owner may do it; the owner's
+// role cannot change.
+func (s *WorkspaceService) SetRole(ctx context.Context, userID, workspaceID, memberID primitive.ObjectID, role models.WorkspaceRole) (*models.Workspace, error) {
+	ws, err := s.manager(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if role == models.WorkspaceRoleOwner || memberID == ws.OwnerID {
+		return nil, domain.Validation("the workspace owner cannot be changed").WithCode("invalid_role")
+	}
+	return s.repo.SetMemberRole(ctx, workspaceID, memberID, role)
+}
+
+// RemoveMember removes a member (admins and the owner) or lets a member leave.
+// The owner cannot leave.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, userID, workspaceID, memberID primitive.ObjectID) error {
+	ws, err := s.repo.GetForMember(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if memberID == ws.OwnerID {
+		return domain.Validation("the workspace owner cannot be removed").WithCode("owner_cannot_leave")
+	}
+	if memberID != userID {
+		if me, _ := ws.Member(userID); !me.Role.CanManage() {
+			return errWorkspaceForbidden()
+		}
+	}
+	_, err = s.repo.RemoveMember(ctx, workspaceID, memberID)
+	return err
+}
+
+// manager loads the workspace if the caller may manage it.
+func (s *WorkspaceService) manager(ctx context.Context, userID, workspaceID primitive.ObjectID) (*models.Workspace, error) {
+	ws, err := s.repo.GetForMember(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if me, _ := ws.Member(userID); !me.Role.CanManage() {
+		return nil, errWorkspaceForbidden()
+	}
+	return ws, nil
+}
+
+func errWorkspaceForbidden() error {
+	return domain.Forbidden("only workspace admins can do this").WithCode("insufficient_permission")
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSStore keeps blobs in a GridFS bucket of the application database, using
+// the key as the file ID.
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+func NewGridFSStore(client *mongo.Client, bucket string) (*GridFSStore, error) {
+	b, err := gridfs.NewBucket(client.Database("golang-todo"), options.GridFSBucket().SetName(bucket))
+	if err != nil {
+		return nil, fmt.Errorf("storage: open GridFS bucket: %w", err)
+	}
+	return &GridFSStore{bucket: b}, nil
+}
+
+// Put replaces an existing blob by deleting it first, since GridFS file IDs are unique.
+func (s *GridFSStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		return err
+	}
+	opts := options.GridFSUpload().SetMetadata(bson.M{"content_type": contentType})
+	stream, err := s.bucket.OpenUploadStreamWithID(key, key, opts)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(stream, r)
+	if err == nil && n != size {
+		err = fmt.Errorf("storage: wrote %d bytes, expected %d", n, size)
+	}
+	if err != nil {
+		_ = stream.Abort()
+		return err
+	}
+	return stream.Close()
+}
+
+func (s *GridFSStore) Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	stream, err := s.bucket.OpenDownloadStream(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = stream.SetReadDeadline(deadline)
+	}
+	if offset > 0 {
+		if _, err := stream.Skip(offset); err != nil {
+			stream.Close()
+			return nil, err
+		}
+	}
+	return stream, nil
+}
+
+func (s *GridFSStore) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	err := s.bucket.DeleteContext(ctx, key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil
+	}
+	return err
+}
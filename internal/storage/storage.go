@@ -0,0 +1,120 @@
+// Package storage keeps uploaded file contents (blobs) behind a small interface
+// with local-filesystem, GridFS and S3-compatible backends. Metadata about the
+// files lives in Mongo alongside the rest of the data; a store only maps keys
+// to bytes.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Backend names accepted by ATTACHMENT_STORAGE.
+const (
+	BackendLocal  = "local"
+	BackendGridFS = "gridfs"
+	BackendS3     = "s3"
+)
+
+// ErrNotFound is returned by Get when no blob is stored under the key.
+var ErrNotFound = errors.New("blob not found")
+
+// Store saves and serves blobs by key. Keys are slash-separated paths made of
+// [A-Za-z0-9._-] segments, e.g. "<todo id>/<attachment id>".
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any existing blob.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get reads the blob from offset to its end.
+	Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+	// Delete removes the blob; deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Open returns a seekable reader over a blob of the given size, so it can be
+// served with http.ServeContent (which implements Range requests). The blob is
+// fetched lazily from the position of the first Read after each Seek.
+func Open(ctx context.Context, store Store, key string, size int64) io.ReadSeekCloser {
+	return &blobReader{ctx: ctx, store: store, key: key, size: size}
+}
+
+type blobReader struct {
+	ctx   context.Context
+	store Store
+	key   string
+	size  int64
+	pos   int64
+	body  io.ReadCloser
+}
+
+func (b *blobReader) Read(p []byte) (int, error) {
+	if b.pos >= b.size {
+		return 0, io.EOF
+	}
+	if b.body == nil {
+		body, err := b.store.Get(b.ctx, b.key, b.pos)
+		if err != nil {
+			return 0, err
+		}
+		b.body = body
+	}
+	n, err := b.body.Read(p)
+	b.pos += int64(n)
+	return n, err
+}
+
+func (b *blobReader) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = b.pos + offset
+	case io.SeekEnd:
+		pos = b.size + offset
+	default:
+		return 0, errors.New("storage: invalid whence")
+	}
+	if pos < 0 {
+		return 0, errors.New("storage: negative position")
+	}
+	if pos != b.pos {
+		b.closeBody()
+		b.pos = pos
+	}
+	return pos, nil
+}
+
+func (b *blobReader) Close() error {
+	return b.closeBody()
+}
+
+func (b *blobReader) closeBody() error {
+	if b.body == nil {
+		return nil
+	}
+	err := b.body.Close()
+	b.body = nil
+	return err
+}
+
+// checkKey rejects keys that could escape a directory or bucket prefix.
+func checkKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("storage: empty key")
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return fmt.Errorf("storage: invalid key %q", key)
+		}
+		for _, r := range seg {
+			ok := r == '.' || r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+			if !ok {
+				return fmt.Errorf("storage: invalid key %q", key)
+			}
+		}
+	}
+	return nil
+}